@@ -0,0 +1,384 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/core"
+	"gopkg.in/ini.v1"
+)
+
+// Telegram 单条消息的最大长度
+const telegramMaxMessageLength = 4096
+
+// 终止实例的确认码有效期
+const confirmTimeout = 60 * time.Second
+
+// Update 是 Telegram getUpdates 返回的更新
+type Update struct {
+	UpdateId int      `json:"update_id"`
+	Message  *Message `json:"message"`
+}
+
+// telegramBot 通过 getUpdates 长轮询接收配置的 chat_id 发来的命令
+type telegramBot struct {
+	api      *TelegramNotifier
+	offset   int
+	accounts map[*ini.Section]*Account // 按账号配置缓存客户端, 重新加载配置后自动重建
+	confirm  *pendingConfirm           // 等待确认的终止操作
+}
+
+// pendingConfirm 等待确认的终止实例操作
+type pendingConfirm struct {
+	code     string
+	acc      *Account
+	instance core.Instance
+	expires  time.Time
+}
+
+// botCommand Bot 命令
+type botCommand struct {
+	usage   string
+	desc    string
+	handler func(b *telegramBot, args []string) string
+}
+
+var botCommands map[string]botCommand
+
+func init() {
+	botCommands = map[string]botCommand{
+		"help":      {"/help", "显示帮助", (*telegramBot).cmdHelp},
+		"status":    {"/status", "查看各账号模板的创建进度", (*telegramBot).cmdStatus},
+		"instances": {"/instances", "列出所有账号的实例", (*telegramBot).cmdInstances},
+		"start":     {"/start <名称|OCID>", "启动实例", (*telegramBot).cmdStart},
+		"stop":      {"/stop <名称|OCID>", "停止实例", (*telegramBot).cmdStop},
+		"reboot":    {"/reboot <名称|OCID>", "重启实例", (*telegramBot).cmdReboot},
+		"terminate": {"/terminate <名称|OCID>", "终止实例 (需要确认)", (*telegramBot).cmdTerminate},
+		"confirm":   {"/confirm <确认码>", "确认终止实例", (*telegramBot).cmdConfirm},
+		"changeip":  {"/changeip <名称|OCID>", "更换实例公共IP", (*telegramBot).cmdChangeIp},
+		"ips":       {"/ips", "列出所有实例的公共IP", (*telegramBot).cmdIps},
+		"pause":     {"/pause", "暂停创建实例", (*telegramBot).cmdPause},
+		"resume":    {"/resume", "恢复创建实例", (*telegramBot).cmdResume},
+	}
+}
+
+// startTelegramBot 在配置了 bot=true 以及 token 和 chat_id 时在后台启动 Telegram Bot
+func startTelegramBot() {
+	if !botEnabled {
+		return
+	}
+	if token == "" || chat_id == "" {
+		printlnErr("Telegram Bot 启动失败", "bot=true 时需要在 [DEFAULT] 中配置 token 和 chat_id")
+		return
+	}
+	b := &telegramBot{
+		api:      &TelegramNotifier{Token: token, ChatId: chat_id, client: newHTTPClient()},
+		accounts: map[*ini.Section]*Account{},
+	}
+	go b.run()
+}
+
+// run 长轮询接收命令, 启动前收到的命令会被忽略
+func (b *telegramBot) run() {
+	var updates []Update
+	err := b.api.call("getUpdates", url.Values{"offset": {"-1"}}, &updates)
+	if err != nil {
+		printlnErr("Telegram Bot 启动失败", err.Error())
+		return
+	}
+	if len(updates) > 0 {
+		b.offset = updates[len(updates)-1].UpdateId + 1
+	}
+	printf("\033[1;36mTelegram Bot 已启动, 发送 /help 查看可用命令\033[0m\n")
+
+	for {
+		updates = nil
+		err = b.api.call("getUpdates", url.Values{
+			"offset":          {strconv.Itoa(b.offset)},
+			"timeout":         {"25"},
+			"allowed_updates": {`["message"]`},
+		}, &updates)
+		if err != nil {
+			printlnErr("Telegram Bot 获取消息失败", err.Error())
+			time.Sleep(5 * time.Second)
+			continue
+		}
+		for _, update := range updates {
+			b.offset = update.UpdateId + 1
+			msg := update.Message
+			if msg == nil || strconv.FormatInt(msg.Chat.Id, 10) != b.api.ChatId {
+				continue
+			}
+			b.handle(msg.Text)
+		}
+	}
+}
+
+// handle 执行一条命令并回复结果
+func (b *telegramBot) handle(text string) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "/") {
+		return
+	}
+	// 群组中的命令可能带有 @机器人用户名
+	name := strings.SplitN(strings.TrimPrefix(fields[0], "/"), "@", 2)[0]
+	command, ok := botCommands[strings.ToLower(name)]
+	if !ok {
+		b.reply("未知命令, 发送 /help 查看可用命令")
+		return
+	}
+	printf("Telegram Bot 收到命令: %s\n", text)
+
+	// 命令中的 OCI 请求可能很慢, 不能在此期间持有 configMutex, 否则守护进程重新加载配置时会被阻塞。
+	// 需要读取配置的地方 (如 getAccounts) 各自加读锁获取快照
+	b.reply(command.handler(b, fields[1:]))
+}
+
+// reply 以纯文本回复, 超出 Telegram 长度限制时截断
+func (b *telegramBot) reply(text string) {
+	runes := []rune(text)
+	if len(runes) > telegramMaxMessageLength {
+		text = string(runes[:telegramMaxMessageLength-4]) + "\n..."
+	}
+	err := b.api.call("sendMessage", url.Values{
+		"chat_id": {b.api.ChatId},
+		"text":    {text},
+	}, nil)
+	if err != nil {
+		printlnErr("Telegram Bot 回复失败", err.Error())
+	}
+}
+
+// getAccounts 返回当前配置中所有账号的客户端, 创建失败的账号会被跳过并记录在 errs 中。
+// 创建客户端不会发出网络请求, 整个过程持有读锁, 返回的客户端可以在释放锁后使用
+func (b *telegramBot) getAccounts() (accounts []*Account, errs []string) {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	cache := map[*ini.Section]*Account{}
+	for _, sec := range oracleSections {
+		acc, ok := b.accounts[sec]
+		if !ok {
+			var err error
+			acc, err = newAccount(sec)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("[%s] %v", sec.Name(), err))
+				continue
+			}
+		}
+		cache[sec] = acc
+		accounts = append(accounts, acc)
+	}
+	b.accounts = cache
+	return
+}
+
+// findInstance 在所有账号中按名称或 OCID 查找未终止的实例
+func (b *telegramBot) findInstance(args []string) (*Account, core.Instance, error) {
+	if len(args) != 1 {
+		return nil, core.Instance{}, errors.New("请指定实例名称或 OCID")
+	}
+	key := args[0]
+	accounts, errs := b.getAccounts()
+	var matchAcc *Account
+	var matches []core.Instance
+	for _, acc := range accounts {
+		instances, err := listAllInstances(acc)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("[%s] 获取实例失败: %v", acc.Name, err))
+			continue
+		}
+		for _, ins := range instances {
+			if ins.LifecycleState == core.InstanceLifecycleStateTerminated {
+				continue
+			}
+			if stringValue(ins.Id) == key || stringValue(ins.DisplayName) == key {
+				matchAcc = acc
+				matches = append(matches, ins)
+			}
+		}
+	}
+	switch len(matches) {
+	case 0:
+		msg := fmt.Sprintf("未找到实例 %s", key)
+		if len(errs) > 0 {
+			msg += "\n" + strings.Join(errs, "\n")
+		}
+		return nil, core.Instance{}, errors.New(msg)
+	case 1:
+		return matchAcc, matches[0], nil
+	default:
+		return nil, core.Instance{}, fmt.Errorf("找到 %d 个名为 %s 的实例, 请使用 OCID", len(matches), key)
+	}
+}
+
+func (b *telegramBot) cmdHelp(args []string) string {
+	var names []string
+	for name := range botCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	lines := []string{"可用命令:"}
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf("%s - %s", botCommands[name].usage, botCommands[name].desc))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (b *telegramBot) cmdStatus(args []string) string {
+	configMutex.RLock()
+	states, err := loadLaunchStates()
+	configMutex.RUnlock()
+	if err != nil {
+		return "读取创建进度失败: " + err.Error()
+	}
+	var lines []string
+	if isPaused() {
+		lines = append(lines, "⏸ 创建已暂停, 发送 /resume 恢复")
+	}
+	if len(states) == 0 {
+		lines = append(lines, "没有进行中的创建任务")
+		return strings.Join(lines, "\n")
+	}
+	var keys []string
+	for key := range states {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		s := states[key]
+		lines = append(lines, fmt.Sprintf("[%s] %s: 已创建 %d/%d, 第 %d 个实例已尝试 %d 次, 失败 %d 轮, 可用性域: %s, 更新于 %s",
+			s.Account, s.Template, len(s.Created), s.Sum, s.Pos+1, s.RunTimes, s.FailTimes, s.AdName, s.UpdatedAt.Format("01-02 15:04:05")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (b *telegramBot) cmdInstances(args []string) string {
+	accounts, lines := b.getAccounts()
+	for _, acc := range accounts {
+		instances, err := listAllInstances(acc)
+		if err != nil {
+			lines = append(lines, fmt.Sprintf("[%s] 获取实例失败: %v", acc.Name, err))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("[%s] 共 %d 个实例", acc.Name, len(instances)))
+		for _, ins := range instances {
+			var ocpus, memory float32
+			if ins.ShapeConfig != nil && ins.ShapeConfig.Ocpus != nil && ins.ShapeConfig.MemoryInGBs != nil {
+				ocpus, memory = *ins.ShapeConfig.Ocpus, *ins.ShapeConfig.MemoryInGBs
+			}
+			lines = append(lines, fmt.Sprintf("%s [%s] %s %gC/%gG\n%s",
+				stringValue(ins.DisplayName), getInstanceState(ins.LifecycleState), stringValue(ins.Shape), ocpus, memory, stringValue(ins.Id)))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// doInstanceAction 对实例执行启动/停止/重启操作
+func (b *telegramBot) doInstanceAction(args []string, action core.InstanceActionActionEnum, desc string) string {
+	acc, ins, err := b.findInstance(args)
+	if err != nil {
+		return err.Error()
+	}
+	_, err = instanceAction(acc, ins.Id, action)
+	if err != nil {
+		return fmt.Sprintf("[%s] %s实例 %s 失败: %v", acc.Name, desc, stringValue(ins.DisplayName), err)
+	}
+	return fmt.Sprintf("[%s] 正在%s实例 %s", acc.Name, desc, stringValue(ins.DisplayName))
+}
+
+func (b *telegramBot) cmdStart(args []string) string {
+	return b.doInstanceAction(args, core.InstanceActionActionStart, "启动")
+}
+
+func (b *telegramBot) cmdStop(args []string) string {
+	return b.doInstanceAction(args, core.InstanceActionActionSoftstop, "停止")
+}
+
+func (b *telegramBot) cmdReboot(args []string) string {
+	return b.doInstanceAction(args, core.InstanceActionActionSoftreset, "重启")
+}
+
+func (b *telegramBot) cmdTerminate(args []string) string {
+	acc, ins, err := b.findInstance(args)
+	if err != nil {
+		return err.Error()
+	}
+	b.confirm = &pendingConfirm{
+		code:     fmt.Sprintf("%04d", rand.Intn(10000)),
+		acc:      acc,
+		instance: ins,
+		expires:  time.Now().Add(confirmTimeout),
+	}
+	return fmt.Sprintf("⚠️ 即将终止 [%s] 的实例 %s 并删除其引导卷\n%s\n请在 %d 秒内发送 /confirm %s 确认",
+		acc.Name, stringValue(ins.DisplayName), stringValue(ins.Id), int(confirmTimeout.Seconds()), b.confirm.code)
+}
+
+func (b *telegramBot) cmdConfirm(args []string) string {
+	c := b.confirm
+	if c == nil || time.Now().After(c.expires) {
+		b.confirm = nil
+		return "没有等待确认的操作"
+	}
+	if len(args) != 1 || args[0] != c.code {
+		return "确认码错误"
+	}
+	b.confirm = nil
+	err := terminateInstance(c.acc, c.instance.Id)
+	if err != nil {
+		return fmt.Sprintf("[%s] 终止实例 %s 失败: %v", c.acc.Name, stringValue(c.instance.DisplayName), err)
+	}
+	return fmt.Sprintf("[%s] 正在终止实例 %s", c.acc.Name, stringValue(c.instance.DisplayName))
+}
+
+func (b *telegramBot) cmdChangeIp(args []string) string {
+	acc, ins, err := b.findInstance(args)
+	if err != nil {
+		return err.Error()
+	}
+	vnics, err := getInstanceVnics(acc, ins.Id)
+	if err != nil {
+		return fmt.Sprintf("[%s] 获取实例VNIC失败: %v", acc.Name, err)
+	}
+	publicIp, err := changePublicIp(acc, vnics)
+	if err != nil {
+		return fmt.Sprintf("[%s] 更换实例 %s 的公共IP失败: %v", acc.Name, stringValue(ins.DisplayName), err)
+	}
+	return fmt.Sprintf("[%s] 实例 %s 的新公共IP: %s", acc.Name, stringValue(ins.DisplayName), stringValue(publicIp.IpAddress))
+}
+
+func (b *telegramBot) cmdIps(args []string) string {
+	accounts, lines := b.getAccounts()
+	for _, acc := range accounts {
+		records, err := collectInstanceIPs(acc)
+		if err != nil {
+			lines = append(lines, fmt.Sprintf("[%s] 获取IP失败: %v", acc.Name, err))
+		}
+		for _, r := range records {
+			lines = append(lines, fmt.Sprintf("[%s] %s %s", acc.Name, r.VnicName, r.PublicIp))
+		}
+	}
+	if len(lines) == 0 {
+		return "没有找到公共IP"
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (b *telegramBot) cmdPause(args []string) string {
+	if !pauseLaunch() {
+		return "创建已处于暂停状态"
+	}
+	return "⏸ 已暂停创建实例, 进行中的创建请求完成后生效"
+}
+
+func (b *telegramBot) cmdResume(args []string) string {
+	if !resumeLaunch() {
+		return "创建未暂停"
+	}
+	return "▶️ 已恢复创建实例"
+}
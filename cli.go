@@ -0,0 +1,1120 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/core"
+	"gopkg.in/ini.v1"
+)
+
+// 子命令退出码
+const (
+	exitOK    = 0 // 执行成功
+	exitError = 1 // 执行失败 (API 错误、部分实例创建失败等)
+	exitUsage = 2 // 参数错误
+)
+
+// errUsage 表示命令行参数有误, 对应退出码 exitUsage
+var errUsage = errors.New("参数错误")
+
+const usageText = `用法: oci-help [-c 配置文件] [命令] [参数]
+
+不带命令运行时进入交互菜单。
+
+命令:
+  instances list [--account 账号] [-o 格式]           列出实例
+  instance start|stop|reboot|terminate <OCID> [--account 账号] [--yes]
+                                                      启动/停止/重启/终止实例
+  instance rename <OCID> --name 名称 [--account 账号] 修改实例名称
+  instance resize <OCID> [--cpus N] [--memory GB] [--account 账号]
+                                                      修改 flex 实例的 OCPU/内存, 运行中的实例会自动重启
+  launch --template 模板 [--account 账号] [--fresh] [--desired]
+                                                      按模板创建实例, 如 INSTANCE.ARM
+  launch --template 模板 --bootvolume 引导卷OCID --account 账号
+                                                      使用已有的引导卷按模板的配置和网络创建实例
+  bootvolume list [--account 账号] [-o 格式]         列出引导卷
+  bootvolume resize <OCID> --size GB [--vpus VPU] [--account 账号]
+                                                      修改引导卷大小/性能
+  backup list [--bootvolume 引导卷OCID] [--account 账号] [-o 格式]
+                                                      列出引导卷备份
+  backup create <引导卷OCID> [--name 名称] [--incremental] [--account 账号]
+                                                      创建引导卷的手动备份
+  backup delete <备份OCID> [--account 账号] [--yes]   删除引导卷备份
+  backup restore <备份OCID> [--ad 可用性域] [--name 名称] [--account 账号]
+                                                      将备份恢复为新的引导卷, 默认恢复到原引导卷的可用性域
+  backup policy <引导卷OCID> --policy bronze|silver|gold|none|策略OCID [--account 账号]
+                                                      设置引导卷的备份策略
+  backup prune --keep N [--bootvolume 引导卷OCID] [--account 账号] [--yes]
+                                                      每个引导卷只保留最新的 N 个手动备份, 不加 --yes 时只列出要删除的备份
+  volume list [--account 账号] [-o 格式]              列出块存储卷
+  volume create --size GB [--vpus VPU] [--ad 可用性域] [--name 名称] [--account 账号]
+                                                      创建块存储卷, 只有一个可用性域时可省略 --ad
+  volume resize <OCID> [--size GB] [--vpus VPU] [--account 账号]
+                                                      扩容块存储卷/修改性能 (0: 低成本; 10: 均衡; 20: 性能较高)
+  volume attach <OCID> --instance 实例OCID [--iscsi] [--readonly] [--shareable] [--account 账号]
+                                                      挂载块存储卷, iSCSI 挂载时输出需要在实例中执行的命令
+  volume detach <OCID> [--instance 实例OCID] [--account 账号]
+                                                      分离块存储卷, 挂载到多个实例时需要指定 --instance
+  volume delete <OCID> [--account 账号] [--yes]       终止块存储卷
+  image list [--account 账号] [-o 格式]               列出自定义镜像
+  image create <实例OCID> [--name 名称] [--account 账号]
+                                                      从实例创建自定义镜像, 创建期间实例将处于关机状态
+  image delete <镜像OCID> [--account 账号] [--yes]    删除自定义镜像
+  image copy <镜像OCID> --region 区域 [--bucket 存储桶] [--account 账号]
+                                                      通过对象存储将自定义镜像复制到其他区域
+  admins list [--account 账号] [-o 格式]              列出管理员
+  vcns list [--account 账号] [-o 格式]                列出虚拟云网络
+  quota list [--account 账号] [-o 格式]               查看免费额度使用情况
+  ips export [--account 账号] [--file 文件] [-o 格式] 导出实例公共IP
+  reservedip list [--account 账号] [-o 格式]          列出预留公共IP
+  reservedip create [--name 名称] [--instance 实例OCID] [--account 账号]
+                                                      创建预留公共IP, 指定实例时同时绑定
+  reservedip assign <IP OCID> --instance 实例OCID [--account 账号]
+                                                      将预留公共IP绑定到实例
+  reservedip unassign|release <IP OCID> [--account 账号] [--yes]
+                                                      解绑/释放预留公共IP (释放需要添加 --yes)
+  reservedip convert <实例OCID> [--name 名称] [--account 账号]
+                                                      将实例的临时公共IP替换为预留公共IP
+  egress list [--account 账号] [-o 格式]              查看本月出站流量
+  egress watch [--interval 分钟] [--account 账号] [--once]
+                                                      监控本月出站流量, 达到阈值时通知, 可选超出预算时停止实例
+  metrics <实例OCID> [--range 24h] [--resolution 5m] [--metric cpu,memory] [--account 账号] [-o 格式]
+                                                      查看实例的 CPU、内存、磁盘和网络监控指标
+  idle list [--account 账号] [-o 格式]                检查 Always Free 实例是否有因空闲被回收的风险
+  idle watch [--interval 分钟] [--account 账号] [--once]
+                                                      定时检查空闲实例, 有回收风险时发送通知
+  console list <实例OCID> [--account 账号] [-o 格式]  列出实例的控制台连接
+  console connect <实例OCID> [--template 模板] [--key-file 公钥文件] [--account 账号]
+                                                      创建控制台连接并输出 SSH/VNC 连接命令, 默认使用模板中的公钥
+  console delete <连接OCID> [--account 账号] [--yes]  删除控制台连接
+  console history <实例OCID> [--file 文件] [--account 账号]
+                                                      截取实例的控制台历史并保存到本地文件
+  daemon [--interval 秒]                              守护进程模式, 为所有账号执行所有实例模板
+                                                      SIGHUP 重新加载配置, SIGTERM 等待进行中的请求完成后退出
+  help                                                显示帮助
+
+输出格式 (--output/-o): table (默认), json, yaml, csv
+
+退出码: 0 成功, 1 执行失败, 2 参数错误
+`
+
+// runCommand 执行非交互式子命令并返回进程退出码
+func runCommand(args []string) int {
+	switch args[0] {
+	case "help", "-h", "--help":
+		fmt.Print(usageText)
+		return exitOK
+	}
+
+	err := loadConfig(configFilePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: 无法加载配置文件。%v\n", err)
+		return exitError
+	}
+
+	switch args[0] {
+	case "instances":
+		err = cmdInstances(args[1:])
+	case "instance":
+		err = cmdInstance(args[1:])
+	case "launch":
+		err = cmdLaunch(args[1:])
+	case "bootvolume":
+		err = cmdBootVolume(args[1:])
+	case "backup":
+		err = cmdBackup(args[1:])
+	case "image":
+		err = cmdImage(args[1:])
+	case "volume":
+		err = cmdVolume(args[1:])
+	case "ips":
+		err = cmdIPs(args[1:])
+	case "admins":
+		err = cmdAdmins(args[1:])
+	case "vcns":
+		err = cmdVcns(args[1:])
+	case "quota":
+		err = cmdQuota(args[1:])
+	case "reservedip":
+		err = cmdReservedIp(args[1:])
+	case "egress":
+		err = cmdEgress(args[1:])
+	case "metrics":
+		err = cmdMetrics(args[1:])
+	case "idle":
+		err = cmdIdle(args[1:])
+	case "console":
+		err = cmdConsole(args[1:])
+	case "daemon":
+		err = cmdDaemon(args[1:])
+	default:
+		err = fmt.Errorf("%w: 未知命令 %s", errUsage, args[0])
+	}
+	return exitCode(err)
+}
+
+// exitCode 将命令执行结果转换为退出码
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+	fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+	if errors.Is(err, errUsage) {
+		fmt.Fprint(os.Stderr, "\n"+usageText)
+		return exitUsage
+	}
+	return exitError
+}
+
+// parseArgs 解析子命令参数, 允许参数与位置参数交替出现, 返回全部位置参数
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		err := fs.Parse(args)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errUsage, err)
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	return fs
+}
+
+// selectAccounts 返回命令要操作的账号, 未指定账号时返回全部账号
+func selectAccounts(name string) ([]*ini.Section, error) {
+	if name == "" {
+		return oracleSections, nil
+	}
+	sec, err := getOracleSection(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errUsage, err)
+	}
+	return []*ini.Section{sec}, nil
+}
+
+// requireAccount 返回单个账号, 配置了多个账号时必须通过 --account 指定
+func requireAccount(name string) (*ini.Section, error) {
+	if name == "" {
+		if len(oracleSections) == 1 {
+			return oracleSections[0], nil
+		}
+		return nil, fmt.Errorf("%w: 配置了多个账号, 请使用 --account 指定", errUsage)
+	}
+	sec, err := getOracleSection(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errUsage, err)
+	}
+	return sec, nil
+}
+
+// useAccount 切换当前账号并初始化客户端
+func useAccount(sec *ini.Section) error {
+	oracleSection = sec
+	err := initOCIClient(sec)
+	if err != nil {
+		return fmt.Errorf("账号 [%s] 初始化失败: %v", sec.Name(), err)
+	}
+	return nil
+}
+
+// addOutputFlag 为子命令注册 --output/-o 参数
+func addOutputFlag(fs *flag.FlagSet) *string {
+	output := new(string)
+	fs.StringVar(output, "output", outputTable, "输出格式 json|yaml|table|csv")
+	fs.StringVar(output, "o", outputTable, "输出格式 (简写)")
+	return output
+}
+
+// collectRecords 依次在各账号中执行 collect 并汇总记录, 任一账号失败时返回错误
+func collectRecords(secs []*ini.Section, desc string, collect func(acc *Account) ([]tableRecord, error)) ([]tableRecord, error) {
+	var records []tableRecord
+	var failed []string
+	for _, sec := range secs {
+		acc, err := newAccount(sec)
+		if err != nil {
+			failed = append(failed, sec.Name())
+			continue
+		}
+		rs, err := collect(acc)
+		if err != nil {
+			printlnErr(fmt.Sprintf("[%s] %s失败", sec.Name(), desc), err.Error())
+			failed = append(failed, sec.Name())
+		}
+		records = append(records, rs...)
+	}
+	if len(failed) > 0 {
+		return records, fmt.Errorf("以下账号%s失败: %s", desc, strings.Join(failed, ", "))
+	}
+	return records, nil
+}
+
+// runListCommand 解析 "<name> list [--account] [--output]" 形式的命令并输出记录
+func runListCommand(name string, args []string, desc string, collect func(acc *Account) ([]tableRecord, error)) error {
+	fs := newFlagSet(name)
+	accountName := fs.String("account", "", "账号名称, 不指定则列出所有账号")
+	output := addOutputFlag(fs)
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 || positional[0] != "list" {
+		return fmt.Errorf("%w: %s 仅支持 list", errUsage, name)
+	}
+	if err := checkOutputFormat(*output); err != nil {
+		return err
+	}
+	secs, err := selectAccounts(*accountName)
+	if err != nil {
+		return err
+	}
+	records, collectErr := collectRecords(secs, desc, collect)
+	if err := writeRecords(os.Stdout, *output, records); err != nil {
+		return err
+	}
+	return collectErr
+}
+
+// --- instances ---
+
+func cmdInstances(args []string) error {
+	return runListCommand("instances", args, "获取实例", collectInstanceRecords)
+}
+
+// --- admins ---
+
+func cmdAdmins(args []string) error {
+	return runListCommand("admins", args, "获取管理员", func(acc *Account) ([]tableRecord, error) {
+		users, err := ListUsers(acc)
+		if err != nil {
+			return nil, err
+		}
+		var records []tableRecord
+		for _, user := range users {
+			records = append(records, newUserRecord(acc.Name, user))
+		}
+		return records, nil
+	})
+}
+
+// --- vcns ---
+
+func cmdVcns(args []string) error {
+	return runListCommand("vcns", args, "获取VCN", func(acc *Account) ([]tableRecord, error) {
+		vcns, err := listVcns(acc)
+		if err != nil {
+			return nil, err
+		}
+		var records []tableRecord
+		for _, vcn := range vcns {
+			records = append(records, newVcnRecord(acc.Name, vcn))
+		}
+		return records, nil
+	})
+}
+
+// --- quota ---
+
+func cmdQuota(args []string) error {
+	return runListCommand("quota", args, "获取免费额度使用情况", collectQuotaRecords)
+}
+
+// --- metrics ---
+
+func cmdMetrics(args []string) error {
+	fs := newFlagSet("metrics")
+	accountName := fs.String("account", "", "账号名称")
+	rangeKey := fs.String("range", "24h", "时间范围 1h|6h|24h|7d|30d")
+	resolution := fs.String("resolution", "", "采样间隔 1m|5m|1h|1d, 默认按时间范围选择")
+	metricKeys := fs.String("metric", "", "指标名称, 多个以逗号分隔, 默认全部")
+	output := addOutputFlag(fs)
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("%w: 请指定实例 OCID", errUsage)
+	}
+	if err := checkOutputFormat(*output); err != nil {
+		return err
+	}
+	r, err := findMetricRange(*rangeKey)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+	if *resolution == "" {
+		*resolution = r.Resolution
+	}
+	if err := checkMetricResolution(*resolution, r.Duration); err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+	defs, err := parseMetricKeys(*metricKeys)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+
+	sec, err := requireAccount(*accountName)
+	if err != nil {
+		return err
+	}
+	acc, err := newAccount(sec)
+	if err != nil {
+		return fmt.Errorf("账号 [%s] 初始化失败: %v", sec.Name(), err)
+	}
+	endTime := time.Now().UTC().Truncate(time.Minute)
+	metrics, err := getInstanceMetrics(acc, positional[0], defs, *resolution, endTime.Add(-r.Duration), endTime)
+	if err != nil {
+		return err
+	}
+	var records []tableRecord
+	for _, m := range metrics {
+		records = append(records, m)
+	}
+	return writeRecords(os.Stdout, *output, records)
+}
+
+// --- instance ---
+
+func cmdInstance(args []string) error {
+	fs := newFlagSet("instance")
+	accountName := fs.String("account", "", "账号名称")
+	yes := fs.Bool("yes", false, "终止实例时跳过确认")
+	name := fs.String("name", "", "新的实例名称")
+	cpus := fs.Float64("cpus", 0, "新的 OCPU 个数")
+	memory := fs.Float64("memory", 0, "新的内存大小(GB)")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 2 {
+		return fmt.Errorf("%w: 请指定操作和实例 OCID", errUsage)
+	}
+	action, instanceId := positional[0], positional[1]
+
+	var act core.InstanceActionActionEnum
+	switch action {
+	case "rename":
+		if *name == "" {
+			return fmt.Errorf("%w: rename 需要通过 --name 指定新的名称", errUsage)
+		}
+	case "resize":
+		if *cpus <= 0 && *memory <= 0 {
+			return fmt.Errorf("%w: 请至少指定 --cpus 或 --memory", errUsage)
+		}
+	case "start":
+		act = core.InstanceActionActionStart
+	case "stop":
+		act = core.InstanceActionActionSoftstop
+	case "reboot":
+		act = core.InstanceActionActionSoftreset
+	case "terminate":
+		if !*yes {
+			return fmt.Errorf("%w: 终止实例需要添加 --yes 确认", errUsage)
+		}
+	default:
+		return fmt.Errorf("%w: 不支持的操作 %s", errUsage, action)
+	}
+
+	sec, err := requireAccount(*accountName)
+	if err != nil {
+		return err
+	}
+	if err := useAccount(sec); err != nil {
+		return err
+	}
+
+	switch action {
+	case "terminate":
+		err = terminateInstance(account, &instanceId)
+	case "rename":
+		_, err = updateInstance(account, &instanceId, name, nil, nil, nil)
+	case "resize":
+		var ins core.Instance
+		ins, err = getInstance(account, &instanceId)
+		if err == nil {
+			_, err = resizeInstance(account, ins, float32(*cpus), float32(*memory))
+		}
+	default:
+		_, err = instanceAction(account, &instanceId, act)
+	}
+	if err != nil {
+		return fmt.Errorf("实例操作 %s 失败: %v", action, err)
+	}
+	printf("[%s] 实例 %s 的 %s 操作已成功发起\n", sec.Name(), instanceId, action)
+	return nil
+}
+
+// --- launch ---
+
+func cmdLaunch(args []string) error {
+	fs := newFlagSet("launch")
+	accountName := fs.String("account", "", "账号名称, 不指定则在所有包含该模板的账号中创建")
+	template := fs.String("template", "", "实例模板, 如 INSTANCE.ARM")
+	fresh := fs.Bool("fresh", false, "忽略并删除上次未完成的创建进度, 重新开始创建")
+	desired := fs.Bool("desired", false, "期望状态模式: sum 表示期望运行的实例总数, 只创建不足的部分")
+	bootVolumeId := fs.String("bootvolume", "", "使用已有的引导卷创建实例, 需要指定 --account")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 || *template == "" {
+		return fmt.Errorf("%w: 请使用 --template 指定实例模板", errUsage)
+	}
+	if *bootVolumeId != "" && *accountName == "" {
+		return fmt.Errorf("%w: 使用 --bootvolume 时需要通过 --account 指定引导卷所在的账号", errUsage)
+	}
+	if *bootVolumeId != "" && *desired {
+		return fmt.Errorf("%w: --bootvolume 不能与 --desired 同时使用", errUsage)
+	}
+	secs, err := selectAccounts(*accountName)
+	if err != nil {
+		return err
+	}
+
+	// 先在当前 goroutine 中匹配模板, 再并发地在各账号中创建
+	var secsToRun []*ini.Section
+	instanceSecs := map[string]*ini.Section{}
+	for _, sec := range secs {
+		instanceSec, err := findInstanceSection(sec, *template)
+		if err != nil {
+			if *accountName != "" {
+				return fmt.Errorf("%w: %v", errUsage, err)
+			}
+			continue
+		}
+		secsToRun = append(secsToRun, sec)
+		instanceSecs[sec.Name()] = instanceSec
+	}
+	matched := len(secsToRun)
+
+	var totalSUM, totalNUM int32
+	var failed []string
+	var mu sync.Mutex
+	runAccountsParallel(secsToRun, func(sec *ini.Section) {
+		sum, num, err := launchTemplate(sec, instanceSecs[sec.Name()], *fresh, *desired, *bootVolumeId)
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			printlnErr(fmt.Sprintf("[%s] 创建实例失败", sec.Name()), err.Error())
+			failed = append(failed, sec.Name())
+			return
+		}
+		totalSUM += sum
+		totalNUM += num
+	})
+	if matched == 0 {
+		return fmt.Errorf("%w: 未找到实例模板 [%s]", errUsage, *template)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("以下账号创建失败: %s", strings.Join(failed, ", "))
+	}
+	if totalNUM < totalSUM {
+		return fmt.Errorf("实例创建未全部成功, 总计: %d, 成功: %d, 失败: %d", totalSUM, totalNUM, totalSUM-totalNUM)
+	}
+	return nil
+}
+
+// launchTemplate 在账号 sec 中按模板 instanceSec 创建实例, bootVolumeId 不为空时使用该引导卷代替模板中的系统镜像
+func launchTemplate(sec, instanceSec *ini.Section, fresh, desired bool, bootVolumeId string) (sum, num int32, err error) {
+	acc, err := newAccount(sec)
+	if err != nil {
+		return 0, 0, fmt.Errorf("账号初始化失败: %v", err)
+	}
+	spec, err := loadInstanceTemplate(instanceSec)
+	if err != nil {
+		return 0, 0, fmt.Errorf("解析实例模板失败: %v", err)
+	}
+	if bootVolumeId != "" {
+		// 临时指定的引导卷与模板本身的创建进度无关, 不保存进度
+		spec.BootVolumeId, spec.BootVolumeDisplayName = bootVolumeId, ""
+		spec.ImageId, spec.ImageDisplayName = "", ""
+		spec.Template = ""
+	}
+	if fresh && spec.Template != "" {
+		clearLaunchState(acc.Name, spec.Template)
+	}
+	if desired {
+		spec.DesiredState = true
+	}
+	availabilityDomains, err := ListAvailabilityDomains(acc)
+	if err != nil {
+		return 0, 0, fmt.Errorf("获取可用性域失败: %v", err)
+	}
+	return LaunchInstances(acc, spec, availabilityDomains, nil)
+}
+
+// --- bootvolume ---
+
+func cmdBootVolume(args []string) error {
+	if len(args) > 0 && args[0] == "list" {
+		return runListCommand("bootvolume", args, "获取引导卷", collectBootVolumeRecords)
+	}
+
+	fs := newFlagSet("bootvolume")
+	accountName := fs.String("account", "", "账号名称")
+	size := fs.Int64("size", 0, "引导卷大小(GB)")
+	vpus := fs.Int64("vpus", 0, "引导卷性能 (10: 均衡; 20: 性能较高)")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 2 || positional[0] != "resize" {
+		return fmt.Errorf("%w: 用法 bootvolume list | bootvolume resize <OCID> --size GB [--vpus VPU]", errUsage)
+	}
+	if *size <= 0 && *vpus <= 0 {
+		return fmt.Errorf("%w: 请至少指定 --size 或 --vpus", errUsage)
+	}
+	bootVolumeId := positional[1]
+
+	sec, err := requireAccount(*accountName)
+	if err != nil {
+		return err
+	}
+	if err := useAccount(sec); err != nil {
+		return err
+	}
+
+	var sizeInGBs, vpusPerGB *int64
+	if *size > 0 {
+		sizeInGBs = size
+	}
+	if *vpus > 0 {
+		vpusPerGB = vpus
+	}
+	bootVolume, err := updateBootVolume(account, &bootVolumeId, sizeInGBs, vpusPerGB)
+	if err != nil {
+		return fmt.Errorf("修改引导卷失败: %v", err)
+	}
+	printf("[%s] 引导卷 %s 修改已成功发起\n", sec.Name(), *bootVolume.DisplayName)
+	return nil
+}
+
+// --- backup ---
+
+func cmdBackup(args []string) error {
+	fs := newFlagSet("backup")
+	accountName := fs.String("account", "", "账号名称")
+	bootVolumeId := fs.String("bootvolume", "", "引导卷 OCID")
+	name := fs.String("name", "", "备份或新引导卷的名称")
+	incremental := fs.Bool("incremental", false, "创建增量备份")
+	ad := fs.String("ad", "", "恢复到的可用性域")
+	policy := fs.String("policy", "", "备份策略名称或 OCID, none 表示取消备份策略")
+	keep := fs.Int("keep", -1, "每个引导卷保留的手动备份个数")
+	yes := fs.Bool("yes", false, "删除备份时跳过确认")
+	output := addOutputFlag(fs)
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) == 0 {
+		return fmt.Errorf("%w: 请指定操作 list|create|delete|restore|policy|prune", errUsage)
+	}
+	action := positional[0]
+	switch action {
+	case "list", "prune":
+		if len(positional) != 1 {
+			return fmt.Errorf("%w: %s 不接受位置参数", errUsage, action)
+		}
+		if action == "prune" && *keep < 0 {
+			return fmt.Errorf("%w: prune 需要通过 --keep 指定保留的备份个数", errUsage)
+		}
+	case "create", "delete", "restore", "policy":
+		if len(positional) != 2 {
+			return fmt.Errorf("%w: 请指定 %s 的 OCID", errUsage, action)
+		}
+		if action == "delete" && !*yes {
+			return fmt.Errorf("%w: 删除备份需要添加 --yes 确认", errUsage)
+		}
+		if action == "policy" && *policy == "" {
+			return fmt.Errorf("%w: policy 需要通过 --policy 指定备份策略", errUsage)
+		}
+	default:
+		return fmt.Errorf("%w: 不支持的操作 %s", errUsage, action)
+	}
+	if err := checkOutputFormat(*output); err != nil {
+		return err
+	}
+	var volumeId *string
+	if *bootVolumeId != "" {
+		volumeId = bootVolumeId
+	}
+
+	if action == "list" {
+		secs, err := selectAccounts(*accountName)
+		if err != nil {
+			return err
+		}
+		records, collectErr := collectRecords(secs, "获取引导卷备份", func(acc *Account) ([]tableRecord, error) {
+			backups, err := listBootVolumeBackups(acc, volumeId)
+			var records []tableRecord
+			for _, backup := range backups {
+				records = append(records, newBootVolumeBackupRecord(acc.Name, backup))
+			}
+			return records, err
+		})
+		if err := writeRecords(os.Stdout, *output, records); err != nil {
+			return err
+		}
+		return collectErr
+	}
+
+	sec, err := requireAccount(*accountName)
+	if err != nil {
+		return err
+	}
+	if err := useAccount(sec); err != nil {
+		return err
+	}
+
+	switch action {
+	case "create":
+		backup, err := createBootVolumeBackup(account, &positional[1], *name, *incremental)
+		if err != nil {
+			return fmt.Errorf("创建备份失败: %v", err)
+		}
+		printf("[%s] 备份 %s 创建已成功发起\n", sec.Name(), stringValue(backup.DisplayName))
+	case "delete":
+		err := deleteBootVolumeBackup(account, &positional[1])
+		if err != nil {
+			return fmt.Errorf("删除备份失败: %v", err)
+		}
+		printf("[%s] 备份 %s 删除已成功发起\n", sec.Name(), positional[1])
+	case "restore":
+		availabilityDomain := ad
+		if *ad == "" {
+			backup, err := getBootVolumeBackup(account, &positional[1])
+			if err != nil {
+				return fmt.Errorf("获取备份失败: %v", err)
+			}
+			bootVolume, err := getBootVolume(account, backup.BootVolumeId)
+			if err != nil {
+				return fmt.Errorf("原引导卷已不存在, 请通过 --ad 指定可用性域: %v", err)
+			}
+			availabilityDomain = bootVolume.AvailabilityDomain
+		}
+		bootVolume, err := restoreBootVolumeBackup(account, &positional[1], availabilityDomain, *name)
+		if err != nil {
+			return fmt.Errorf("恢复备份失败: %v", err)
+		}
+		printf("[%s] 已从备份恢复引导卷 %s (%s)\n", sec.Name(), stringValue(bootVolume.DisplayName), stringValue(bootVolume.Id))
+	case "policy":
+		var policyId *string
+		if !strings.EqualFold(*policy, "none") {
+			p, err := findVolumeBackupPolicy(account, *policy)
+			if err != nil {
+				return err
+			}
+			policyId = p.Id
+		}
+		err := setVolumeBackupPolicy(account, &positional[1], policyId)
+		if err != nil {
+			return fmt.Errorf("设置备份策略失败: %v", err)
+		}
+		printf("[%s] 引导卷 %s 的备份策略已设置为 %s\n", sec.Name(), positional[1], *policy)
+	case "prune":
+		backups, err := listBootVolumeBackups(account, volumeId)
+		if err != nil {
+			return fmt.Errorf("获取引导卷备份失败: %v", err)
+		}
+		pruned := pruneBootVolumeBackups(backups, *keep)
+		var records []tableRecord
+		for _, backup := range pruned {
+			records = append(records, newBootVolumeBackupRecord(sec.Name(), backup))
+		}
+		if !*yes {
+			// 提示输出到标准错误, 以免破坏 -o json/yaml 的输出
+			fmt.Fprintf(os.Stderr, "以下 %d 个备份将被删除, 添加 --yes 执行删除:\n", len(pruned))
+			return writeRecords(os.Stdout, *output, records)
+		}
+		var failed []string
+		for _, backup := range pruned {
+			err := deleteBootVolumeBackup(account, backup.Id)
+			if err != nil {
+				printlnErr(fmt.Sprintf("删除备份 %s 失败", stringValue(backup.DisplayName)), err.Error())
+				failed = append(failed, stringValue(backup.DisplayName))
+				continue
+			}
+			printf("[%s] 已删除备份 %s\n", sec.Name(), stringValue(backup.DisplayName))
+		}
+		if len(failed) > 0 {
+			return fmt.Errorf("以下备份删除失败: %s", strings.Join(failed, ", "))
+		}
+	}
+	return nil
+}
+
+// --- volume ---
+
+func cmdVolume(args []string) error {
+	if len(args) > 0 && args[0] == "list" {
+		return runListCommand("volume", args, "获取块存储卷", collectVolumeRecords)
+	}
+
+	fs := newFlagSet("volume")
+	accountName := fs.String("account", "", "账号名称")
+	size := fs.Int64("size", 0, "块存储卷大小(GB)")
+	vpus := fs.Int64("vpus", -1, "块存储卷性能 (0: 低成本; 10: 均衡; 20: 性能较高)")
+	ad := fs.String("ad", "", "可用性域")
+	name := fs.String("name", "", "块存储卷名称")
+	instanceId := fs.String("instance", "", "实例 OCID")
+	iscsi := fs.Bool("iscsi", false, "使用 iSCSI 挂载, 默认半虚拟化")
+	readOnly := fs.Bool("readonly", false, "只读挂载")
+	shareable := fs.Bool("shareable", false, "允许挂载到多个实例")
+	yes := fs.Bool("yes", false, "终止块存储卷时跳过确认")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) == 0 {
+		return fmt.Errorf("%w: 请指定操作 list|create|resize|attach|detach|delete", errUsage)
+	}
+	action := positional[0]
+	switch action {
+	case "create":
+		if len(positional) != 1 {
+			return fmt.Errorf("%w: create 不接受位置参数", errUsage)
+		}
+		if *size < 50 {
+			return fmt.Errorf("%w: 请通过 --size 指定大小, 最小 50 GB", errUsage)
+		}
+	case "resize", "attach", "detach", "delete":
+		if len(positional) != 2 {
+			return fmt.Errorf("%w: 请指定块存储卷的 OCID", errUsage)
+		}
+		if action == "resize" && *size <= 0 && *vpus < 0 {
+			return fmt.Errorf("%w: 请至少指定 --size 或 --vpus", errUsage)
+		}
+		if action == "attach" && *instanceId == "" {
+			return fmt.Errorf("%w: attach 需要通过 --instance 指定实例", errUsage)
+		}
+		if action == "delete" && !*yes {
+			return fmt.Errorf("%w: 终止块存储卷需要添加 --yes 确认", errUsage)
+		}
+	default:
+		return fmt.Errorf("%w: 不支持的操作 %s", errUsage, action)
+	}
+
+	sec, err := requireAccount(*accountName)
+	if err != nil {
+		return err
+	}
+	if err := useAccount(sec); err != nil {
+		return err
+	}
+
+	switch action {
+	case "create":
+		if *ad == "" {
+			availabilityDomains, err := ListAvailabilityDomains(account)
+			if err != nil {
+				return fmt.Errorf("获取可用性域失败: %v", err)
+			}
+			if len(availabilityDomains) != 1 {
+				return fmt.Errorf("%w: 账号有多个可用性域, 请通过 --ad 指定", errUsage)
+			}
+			*ad = stringValue(availabilityDomains[0].Name)
+		}
+		if *vpus < 0 {
+			*vpus = 10
+		}
+		if err := checkBlockStorageQuota(account, *size); err != nil {
+			return err
+		}
+		volume, err := createVolume(account, ad, *name, *size, *vpus)
+		if err != nil {
+			return fmt.Errorf("创建块存储卷失败: %v", err)
+		}
+		printf("[%s] 块存储卷 %s 创建已成功发起 (%s)\n", sec.Name(), stringValue(volume.DisplayName), stringValue(volume.Id))
+	case "resize":
+		var sizeInGBs, vpusPerGB *int64
+		if *size > 0 {
+			volume, err := getVolume(account, &positional[1])
+			if err != nil {
+				return fmt.Errorf("获取块存储卷失败: %v", err)
+			}
+			if *size <= *volume.SizeInGBs {
+				return fmt.Errorf("%w: 块存储卷大小只能增加, 当前 %d GB", errUsage, *volume.SizeInGBs)
+			}
+			if err := checkBlockStorageQuota(account, *size-*volume.SizeInGBs); err != nil {
+				return err
+			}
+			sizeInGBs = size
+		}
+		if *vpus >= 0 {
+			vpusPerGB = vpus
+		}
+		volume, err := updateVolume(account, &positional[1], sizeInGBs, vpusPerGB)
+		if err != nil {
+			return fmt.Errorf("修改块存储卷失败: %v", err)
+		}
+		printf("[%s] 块存储卷 %s 修改已成功发起\n", sec.Name(), stringValue(volume.DisplayName))
+	case "attach":
+		attachment, err := attachVolume(account, instanceId, &positional[1], *iscsi, *readOnly, *shareable)
+		if err != nil {
+			return fmt.Errorf("挂载块存储卷失败: %v", err)
+		}
+		printf("[%s] 块存储卷已挂载到实例 %s\n", sec.Name(), *instanceId)
+		if cmds := iscsiCommands(attachment, true); cmds != nil {
+			printf("请在实例中执行以下命令连接块存储卷:\n%s\n", strings.Join(cmds, "\n"))
+		}
+	case "detach":
+		attachments, err := listVolumeAttachments(account, &positional[1], nil)
+		if err != nil {
+			return fmt.Errorf("获取挂载信息失败: %v", err)
+		}
+		var targets []core.VolumeAttachment
+		for _, attachment := range attachments {
+			if *instanceId == "" || stringValue(attachment.GetInstanceId()) == *instanceId {
+				targets = append(targets, attachment)
+			}
+		}
+		if len(targets) == 0 {
+			return fmt.Errorf("块存储卷未挂载")
+		}
+		if len(targets) > 1 {
+			return fmt.Errorf("%w: 块存储卷挂载到了多个实例, 请通过 --instance 指定", errUsage)
+		}
+		if err := detachVolume(account, targets[0].GetId()); err != nil {
+			return fmt.Errorf("分离块存储卷失败: %v", err)
+		}
+		printf("[%s] 块存储卷 %s 分离已成功发起\n", sec.Name(), positional[1])
+		if cmds := iscsiCommands(targets[0], false); cmds != nil {
+			printf("如果尚未断开 iSCSI 连接, 请在实例中执行:\n%s\n", strings.Join(cmds, "\n"))
+		}
+	case "delete":
+		if err := deleteVolume(account, &positional[1]); err != nil {
+			return fmt.Errorf("终止块存储卷失败: %v", err)
+		}
+		printf("[%s] 块存储卷 %s 终止已成功发起\n", sec.Name(), positional[1])
+	}
+	return nil
+}
+
+// --- image ---
+
+func cmdImage(args []string) error {
+	if len(args) > 0 && args[0] == "list" {
+		return runListCommand("image", args, "获取自定义镜像", collectImageRecords)
+	}
+
+	fs := newFlagSet("image")
+	accountName := fs.String("account", "", "账号名称")
+	name := fs.String("name", "", "镜像名称")
+	region := fs.String("region", "", "复制到的区域, 如 ap-tokyo-1")
+	bucket := fs.String("bucket", defaultImageBucket, "复制时用于中转的存储桶, 不存在时自动创建")
+	yes := fs.Bool("yes", false, "删除镜像时跳过确认")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 2 {
+		return fmt.Errorf("%w: 用法 image list | image create <实例OCID> | image delete|copy <镜像OCID>", errUsage)
+	}
+	action := positional[0]
+	switch action {
+	case "create":
+	case "delete":
+		if !*yes {
+			return fmt.Errorf("%w: 删除镜像需要添加 --yes 确认", errUsage)
+		}
+	case "copy":
+		if *region == "" {
+			return fmt.Errorf("%w: copy 需要通过 --region 指定目标区域", errUsage)
+		}
+	default:
+		return fmt.Errorf("%w: 不支持的操作 %s", errUsage, action)
+	}
+
+	sec, err := requireAccount(*accountName)
+	if err != nil {
+		return err
+	}
+	if err := useAccount(sec); err != nil {
+		return err
+	}
+
+	switch action {
+	case "create":
+		image, err := createImage(account, &positional[1], *name)
+		if err != nil {
+			return fmt.Errorf("创建镜像失败: %v", err)
+		}
+		printf("[%s] 镜像 %s 创建已成功发起 (%s), 创建期间实例将处于关机状态\n", sec.Name(), stringValue(image.DisplayName), stringValue(image.Id))
+	case "delete":
+		if err := deleteImage(account, &positional[1]); err != nil {
+			return fmt.Errorf("删除镜像失败: %v", err)
+		}
+		printf("[%s] 镜像 %s 删除已成功发起\n", sec.Name(), positional[1])
+	case "copy":
+		image, err := copyImageToRegion(account, &positional[1], *region, *bucket)
+		if err != nil {
+			return fmt.Errorf("复制镜像失败: %v", err)
+		}
+		printf("[%s] 镜像已复制到区域 %s (%s)\n", sec.Name(), *region, stringValue(image.Id))
+	}
+	return nil
+}
+
+// --- reservedip ---
+
+func cmdReservedIp(args []string) error {
+	if len(args) > 0 && args[0] == "list" {
+		return runListCommand("reservedip", args, "获取预留公共IP", collectReservedIpRecords)
+	}
+
+	fs := newFlagSet("reservedip")
+	accountName := fs.String("account", "", "账号名称")
+	name := fs.String("name", "", "预留公共IP名称")
+	instanceId := fs.String("instance", "", "实例 OCID")
+	yes := fs.Bool("yes", false, "释放预留公共IP时跳过确认")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) == 0 {
+		return fmt.Errorf("%w: 请指定操作 list|create|assign|unassign|release|convert", errUsage)
+	}
+	action := positional[0]
+	switch action {
+	case "create":
+		if len(positional) != 1 {
+			return fmt.Errorf("%w: create 不接受位置参数", errUsage)
+		}
+	case "assign", "unassign", "release", "convert":
+		if len(positional) != 2 {
+			return fmt.Errorf("%w: 请指定 %s 的 OCID", errUsage, action)
+		}
+		if action == "assign" && *instanceId == "" {
+			return fmt.Errorf("%w: assign 需要通过 --instance 指定实例", errUsage)
+		}
+		if action == "release" && !*yes {
+			return fmt.Errorf("%w: 释放预留公共IP需要添加 --yes 确认", errUsage)
+		}
+	default:
+		return fmt.Errorf("%w: 不支持的操作 %s", errUsage, action)
+	}
+
+	sec, err := requireAccount(*accountName)
+	if err != nil {
+		return err
+	}
+	if err := useAccount(sec); err != nil {
+		return err
+	}
+
+	var publicIp core.PublicIp
+	switch action {
+	case "create":
+		var privateIpId *string
+		if *instanceId != "" {
+			privateIp, err := getInstancePrimaryPrivateIp(account, instanceId)
+			if err != nil {
+				return fmt.Errorf("获取实例私有IP失败: %v", err)
+			}
+			privateIpId = privateIp.Id
+		}
+		publicIp, err = createReservedPublicIp(account, *name, privateIpId)
+	case "assign":
+		publicIp, err = assignReservedPublicIp(account, &positional[1], instanceId)
+	case "unassign":
+		publicIp, err = updatePublicIpAssignment(account, &positional[1], "")
+	case "release":
+		_, err = deletePublicIp(account, &positional[1])
+	case "convert":
+		publicIp, err = convertToReservedPublicIp(account, &positional[1], *name)
+	}
+	if err != nil {
+		return fmt.Errorf("预留公共IP操作 %s 失败: %v", action, err)
+	}
+	if action == "release" {
+		printf("[%s] 预留公共IP %s 已释放\n", sec.Name(), positional[1])
+		return nil
+	}
+	printf("[%s] 预留公共IP %s 的 %s 操作已成功发起\n", sec.Name(), stringValue(publicIp.IpAddress), action)
+	return nil
+}
+
+// --- ips ---
+
+func cmdIPs(args []string) error {
+	fs := newFlagSet("ips")
+	accountName := fs.String("account", "", "账号名称")
+	file := fs.String("file", "", "导出文件路径")
+	output := new(string)
+	fs.StringVar(output, "output", "", "输出格式 json|yaml|table|csv, 指定后输出到标准输出 (或 --file)")
+	fs.StringVar(output, "o", "", "输出格式 (简写)")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 || positional[0] != "export" {
+		return fmt.Errorf("%w: ips 仅支持 export", errUsage)
+	}
+	secs, err := selectAccounts(*accountName)
+	if err != nil {
+		return err
+	}
+
+	// 指定输出格式时输出结构化记录
+	if *output != "" {
+		if err := checkOutputFormat(*output); err != nil {
+			return err
+		}
+		records, collectErr := collectRecords(secs, "导出IP", func(acc *Account) ([]tableRecord, error) {
+			ips, err := collectInstanceIPs(acc)
+			var records []tableRecord
+			for _, ip := range ips {
+				records = append(records, ip)
+			}
+			return records, err
+		})
+		w := os.Stdout
+		if *file != "" {
+			w, err = os.Create(*file)
+			if err != nil {
+				return err
+			}
+			defer w.Close()
+		}
+		if err := writeRecords(w, *output, records); err != nil {
+			return err
+		}
+		return collectErr
+	}
+
+	IPsFilePath := *file
+	if IPsFilePath == "" {
+		IPsFilePath = IPsFilePrefix + "-" + time.Now().Format("2006-01-02-150405.txt")
+	}
+	var failed []string
+	for _, sec := range secs {
+		acc, err := newAccount(sec)
+		if err != nil {
+			failed = append(failed, sec.Name())
+			continue
+		}
+		err = ListInstancesIPs(acc, IPsFilePath)
+		if err != nil {
+			failed = append(failed, sec.Name())
+		}
+	}
+	fmt.Printf("导出完成，请查看文件 %s\n", IPsFilePath)
+	if len(failed) > 0 {
+		return fmt.Errorf("以下账号导出失败: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
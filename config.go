@@ -4,6 +4,8 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"strings"
+	"sync"
 
 	"gopkg.in/ini.v1"
 )
@@ -21,17 +23,19 @@ var (
 	token               string
 	chat_id             string
 	cmd                 string
-	sendMessageUrl      string
-	editMessageUrl      string
 	EACH                bool
+	concurrency         int
+	botEnabled          bool
 	oracleSections      []*ini.Section
 	oracleSection       *ini.Section
-	oracleSectionName   string
 	instanceBaseSection *ini.Section
-	oracle              Oracle
-	instance            Instance
+	limit               = defaultLimit()
+	egress              = defaultEgress()
 )
 
+// configMutex 保护配置的重新加载。与守护进程并发运行的 goroutine (如 Telegram Bot) 读取配置时需要加读锁
+var configMutex sync.RWMutex
+
 // Oracle 账号配置结构体
 type Oracle struct {
 	User         string `ini:"user"`
@@ -62,13 +66,25 @@ type Instance struct {
 	CloudInit              string  `ini:"cloud-init"`
 	MinTime                int32   `ini:"minTime"`
 	MaxTime                int32   `ini:"maxTime"`
+	DesiredState           bool    `ini:"desiredState"`          // sum 表示期望运行的实例总数, 只创建不足的部分
+	SecurityPreset         string  `ini:"securityPreset"`        // 新建子网时应用到安全列表的预设规则
+	NsgDisplayNames        string  `ini:"nsgDisplayNames"`       // 实例网卡关联的网络安全组名称, 多个以逗号分隔
+	AssignIpv6             bool    `ini:"assignIpv6"`            // 为子网启用 IPv6 并为实例分配 IPv6 地址
+	BootVolumeId           string  `ini:"bootVolumeId"`          // 使用已有的引导卷创建实例, 不再使用系统镜像
+	BootVolumeDisplayName  string  `ini:"bootVolumeDisplayName"` // 按名称指定已有的引导卷, 与 bootVolumeId 二选一
+	ImageId                string  `ini:"imageId"`               // 使用自定义镜像创建实例, 不再按操作系统查找系统镜像
+	ImageDisplayName       string  `ini:"imageDisplayName"`      // 按名称指定自定义镜像, 与 imageId 二选一
+	Template               string  `ini:"-"`                     // 模板所在的 section 名称, 如 INSTANCE.ARM
 }
 
-// init 在 main 函数之前运行，用于解析命令行参数
+// init 在 main 函数之前运行，用于注册命令行参数
 func init() {
 	flag.StringVar(&configFilePath, "config", defConfigFilePath, "配置文件路径")
 	flag.StringVar(&configFilePath, "c", defConfigFilePath, "配置文件路径 (简写)")
-	flag.Parse()
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usageText)
+		flag.PrintDefaults()
+	}
 }
 
 // loadConfig 加载并解析 oci-help.ini 配置文件
@@ -78,31 +94,59 @@ func loadConfig(path string) error {
 		return fmt.Errorf("无法加载配置文件: %v", err)
 	}
 
+	// 先校验账号配置, 校验失败时保留当前配置不变 (守护进程重新加载配置时依赖这一点)
+	sections := []*ini.Section{}
+	for _, sec := range cfg.Sections() {
+		if isOracleSection(sec) {
+			sections = append(sections, sec)
+		}
+	}
+
+	if len(sections) == 0 {
+		return errors.New("在配置文件中未找到格式正确的甲骨文账号信息")
+	}
+	newLimit := defaultLimit()
+	if cfg.HasSection("LIMIT") {
+		err = cfg.Section("LIMIT").MapTo(&newLimit)
+		if err != nil {
+			return fmt.Errorf("解析 [LIMIT] 配置失败: %v", err)
+		}
+		newLimit.Mode = strings.ToLower(newLimit.Mode)
+		switch newLimit.Mode {
+		case limitModeRefuse, limitModeWarn, limitModeOff:
+		default:
+			return fmt.Errorf("[LIMIT] mode 只能是 %s, %s 或 %s", limitModeRefuse, limitModeWarn, limitModeOff)
+		}
+	}
+	newEgress, err := loadEgress(cfg)
+	if err != nil {
+		return err
+	}
+	newNotifiers, err := loadNotifiers(cfg)
+	if err != nil {
+		return err
+	}
+	configMutex.Lock()
+	defer configMutex.Unlock()
+	oracleSections = sections
+	limit = newLimit
+	egress = newEgress
+	notifiers = newNotifiers
+
 	defSec := cfg.Section(ini.DefaultSection)
 	proxy = defSec.Key("proxy").Value()
 	token = defSec.Key("token").Value()
 	chat_id = defSec.Key("chat_id").Value()
 	cmd = defSec.Key("cmd").Value()
+	stateFilePath = defSec.Key("stateFile").MustString(defStateFilePath)
+	concurrency = defSec.Key("concurrency").MustInt(0)
+	botEnabled = defSec.Key("bot").MustBool(false)
 	if defSec.HasKey("EACH") {
 		EACH, _ = defSec.Key("EACH").Bool()
 	} else {
 		EACH = true
 	}
 
-	sendMessageUrl = "https://api.telegram.org/bot" + token + "/sendMessage"
-	editMessageUrl = "https://api.telegram.org/bot" + token + "/editMessageText"
-
-	oracleSections = []*ini.Section{}
-	for _, sec := range cfg.Sections() {
-		if isOracleSection(sec) {
-			oracleSections = append(oracleSections, sec)
-		}
-	}
-
-	if len(oracleSections) == 0 {
-		return errors.New("在配置文件中未找到格式正确的甲骨文账号信息")
-	}
-
 	instanceBaseSection = cfg.Section("INSTANCE")
 
 	return nil
@@ -117,3 +161,60 @@ func isOracleSection(sec *ini.Section) bool {
 		sec.HasKey("key_file") &&
 		len(sec.ParentKeys()) == 0
 }
+
+// getInstanceSections 返回账号可用的全部实例模板, 包括 [INSTANCE.*] 和账号自身的子 section
+func getInstanceSections(oracleSec *ini.Section) []*ini.Section {
+	var instanceSections []*ini.Section
+	instanceSections = append(instanceSections, instanceBaseSection.ChildSections()...)
+	instanceSections = append(instanceSections, oracleSec.ChildSections()...)
+	return instanceSections
+}
+
+// findInstanceSection 按名称查找实例模板, 既可以是完整名称 (INSTANCE.ARM), 也可以是后缀 (ARM)
+func findInstanceSection(oracleSec *ini.Section, name string) (*ini.Section, error) {
+	for _, sec := range getInstanceSections(oracleSec) {
+		if sec.Name() == name || sec.Name()[strings.LastIndex(sec.Name(), ".")+1:] == name {
+			return sec, nil
+		}
+	}
+	return nil, fmt.Errorf("账号 [%s] 下未找到实例模板 [%s]", oracleSec.Name(), name)
+}
+
+// loadInstanceTemplate 将 [INSTANCE] 基础参数与模板参数合并为一个实例配置
+func loadInstanceTemplate(instanceSec *ini.Section) (Instance, error) {
+	ins := Instance{}
+	err := instanceBaseSection.MapTo(&ins)
+	if err != nil {
+		return ins, err
+	}
+	err = instanceSec.MapTo(&ins)
+	if err != nil {
+		return ins, err
+	}
+	ins.Template = instanceSec.Name()
+	if ins.SecurityPreset != "" && !isSecurityPreset(ins.SecurityPreset) {
+		return ins, fmt.Errorf("[%s] 未知的 securityPreset: %s", ins.Template, ins.SecurityPreset)
+	}
+	// 使用引导卷创建实例时不使用镜像, 同时配置时无法确定用户的意图
+	if (ins.BootVolumeId != "" || ins.BootVolumeDisplayName != "") && (ins.ImageId != "" || ins.ImageDisplayName != "") {
+		return ins, fmt.Errorf("[%s] bootVolumeId/bootVolumeDisplayName 不能与 imageId/imageDisplayName 同时配置", ins.Template)
+	}
+	return ins, nil
+}
+
+// getOracleSection 按名称查找账号配置
+func getOracleSection(name string) (*ini.Section, error) {
+	for _, sec := range oracleSections {
+		if sec.Name() == name {
+			return sec, nil
+		}
+	}
+	return nil, fmt.Errorf("未找到账号 [%s]", name)
+}
+
+// getOracleSections 返回当前账号配置的快照, 供与守护进程并发运行的 goroutine 使用
+func getOracleSections() []*ini.Section {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return append([]*ini.Section(nil), oracleSections...)
+}
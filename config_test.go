@@ -0,0 +1,49 @@
+package main
+
+import (
+	"testing"
+
+	"gopkg.in/ini.v1"
+)
+
+func TestLoadInstanceTemplate(t *testing.T) {
+	cfg, err := ini.Load([]byte(`
+[INSTANCE]
+shape=VM.Standard.A1.Flex
+
+[INSTANCE.image]
+imageId=ocid1.image.1
+
+[INSTANCE.bootvolume]
+bootVolumeDisplayName=backup
+
+[INSTANCE.both]
+imageDisplayName=custom
+bootVolumeId=ocid1.bootvolume.1
+
+[INSTANCE.preset]
+securityPreset=unknown
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldBase := instanceBaseSection
+	defer func() { instanceBaseSection = oldBase }()
+	instanceBaseSection = cfg.Section("INSTANCE")
+
+	tests := []struct {
+		template string
+		wantErr  bool
+	}{
+		{"INSTANCE.image", false},
+		{"INSTANCE.bootvolume", false},
+		{"INSTANCE.both", true},
+		{"INSTANCE.preset", true},
+	}
+	for _, tt := range tests {
+		_, err := loadInstanceTemplate(cfg.Section(tt.template))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("loadInstanceTemplate(%s) err=%v, 期望出错: %v", tt.template, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/core"
+	"gopkg.in/ini.v1"
+)
+
+// templateKey 实例模板中配置的 SSH 公钥
+type templateKey struct {
+	Template string
+	Key      string
+}
+
+// templatePublicKeys 返回账号下各实例模板配置的 SSH 公钥, 相同的公钥只保留第一个, 用于选择公钥和未指定模板时的默认公钥
+func templatePublicKeys(oracleSec *ini.Section) []templateKey {
+	var keys []templateKey
+	seen := map[string]bool{}
+	for _, instanceSec := range getInstanceSections(oracleSec) {
+		spec, err := loadInstanceTemplate(instanceSec)
+		if err != nil {
+			continue
+		}
+		key := strings.TrimSpace(spec.SSH_Public_Key)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, templateKey{spec.Template, key})
+	}
+	return keys
+}
+
+// templatePublicKey 返回实例模板 template 配置的 SSH 公钥, template 为空时返回第一个配置了公钥的模板的公钥
+func templatePublicKey(oracleSec *ini.Section, template string) (string, error) {
+	if template == "" {
+		if keys := templatePublicKeys(oracleSec); len(keys) > 0 {
+			return keys[0].Key, nil
+		}
+		return "", nil
+	}
+	// 指定模板时直接读取该模板, 与其他模板的公钥相同时也能找到
+	instanceSec, err := findInstanceSection(oracleSec, template)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", errUsage, err)
+	}
+	spec, err := loadInstanceTemplate(instanceSec)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(spec.SSH_Public_Key), nil
+}
+
+// ConsoleConnectionRecord 实例的控制台连接
+type ConsoleConnectionRecord struct {
+	Id                  string `json:"id" yaml:"id"`
+	InstanceId          string `json:"instanceId" yaml:"instanceId"`
+	State               string `json:"state" yaml:"state"`
+	Fingerprint         string `json:"fingerprint" yaml:"fingerprint"`
+	ConnectionString    string `json:"connectionString" yaml:"connectionString"`
+	VncConnectionString string `json:"vncConnectionString" yaml:"vncConnectionString"`
+}
+
+func newConsoleConnectionRecord(c core.InstanceConsoleConnection) ConsoleConnectionRecord {
+	return ConsoleConnectionRecord{
+		Id:                  stringValue(c.Id),
+		InstanceId:          stringValue(c.InstanceId),
+		State:               string(c.LifecycleState),
+		Fingerprint:         stringValue(c.Fingerprint),
+		ConnectionString:    stringValue(c.ConnectionString),
+		VncConnectionString: stringValue(c.VncConnectionString),
+	}
+}
+
+func (r ConsoleConnectionRecord) tableHeader() []string {
+	return []string{"OCID", "状态", "公钥指纹"}
+}
+
+func (r ConsoleConnectionRecord) tableRow() []string {
+	return []string{r.Id, r.State, r.Fingerprint}
+}
+
+// printConsoleConnectionCommands 输出连接串口控制台和 VNC 的命令
+func printConsoleConnectionCommands(c core.InstanceConsoleConnection) {
+	fmt.Println("串口控制台 (使用与公钥对应的私钥, 不在默认位置时请在 ssh 后添加 -i 私钥路径):")
+	fmt.Println(stringValue(c.ConnectionString))
+	fmt.Println("VNC (执行后使用 VNC 客户端连接 localhost:5900):")
+	fmt.Println(stringValue(c.VncConnectionString))
+}
+
+var unsafeFileNameChars = regexp.MustCompile(`[^\w.-]+`)
+
+// consoleHistoryFileName 返回默认的控制台历史文件名, 如实例 web-1 在 2023-01-02 15:04:05 截取的历史为 console-web-1-20230102-150405.log
+func consoleHistoryFileName(instance core.Instance) string {
+	name := unsafeFileNameChars.ReplaceAllString(stringValue(instance.DisplayName), "_")
+	return fmt.Sprintf("console-%s-%s.log", name, time.Now().Format("20060102-150405"))
+}
+
+// saveConsoleHistory 截取实例的控制台历史并保存到 file, 返回保存的字节数。
+// 截取的历史只用于下载, 保存后会从甲骨文删除
+func saveConsoleHistory(acc *Account, instanceId *string, file string) (int, error) {
+	history, err := captureConsoleHistory(acc, instanceId)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err := deleteConsoleHistory(acc, history.Id); err != nil {
+			printlnErr(fmt.Sprintf("[%s] 删除控制台历史失败", acc.Name), err.Error())
+		}
+	}()
+	content, err := getConsoleHistoryContent(acc, history.Id)
+	if err != nil {
+		return 0, fmt.Errorf("下载控制台历史失败: %v", err)
+	}
+	err = ioutil.WriteFile(file, []byte(content), 0644)
+	if err != nil {
+		return 0, err
+	}
+	return len(content), nil
+}
+
+// cmdConsole 管理实例的控制台连接并截取控制台历史, 用于实例无法通过 SSH 访问时排查问题
+func cmdConsole(args []string) error {
+	fs := newFlagSet("console")
+	accountName := fs.String("account", "", "账号名称")
+	template := fs.String("template", "", "使用此实例模板中的 SSH 公钥, 默认使用第一个配置了公钥的模板")
+	keyFile := fs.String("key-file", "", "SSH 公钥文件, 如 ~/.ssh/id_rsa.pub")
+	file := fs.String("file", "", "控制台历史保存的文件, 默认为 console-实例名称-时间.log")
+	yes := fs.Bool("yes", false, "删除控制台连接时跳过确认")
+	output := addOutputFlag(fs)
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 2 {
+		return fmt.Errorf("%w: 用法 console list|connect|history <实例OCID> | console delete <连接OCID>", errUsage)
+	}
+	action, id := positional[0], positional[1]
+	switch action {
+	case "list":
+		if err := checkOutputFormat(*output); err != nil {
+			return err
+		}
+	case "connect", "history":
+	case "delete":
+		if !*yes {
+			return fmt.Errorf("%w: 删除控制台连接需要添加 --yes 确认", errUsage)
+		}
+	default:
+		return fmt.Errorf("%w: 不支持的操作 %s", errUsage, action)
+	}
+
+	sec, err := requireAccount(*accountName)
+	if err != nil {
+		return err
+	}
+	acc, err := newAccount(sec)
+	if err != nil {
+		return fmt.Errorf("账号 [%s] 初始化失败: %v", sec.Name(), err)
+	}
+
+	switch action {
+	case "list":
+		connections, err := listConsoleConnections(acc, &id)
+		if err != nil {
+			return fmt.Errorf("获取控制台连接失败: %v", err)
+		}
+		var records []tableRecord
+		for _, c := range connections {
+			records = append(records, newConsoleConnectionRecord(c))
+		}
+		return writeRecords(os.Stdout, *output, records)
+	case "connect":
+		var publicKey string
+		if *keyFile != "" {
+			data, err := ioutil.ReadFile(*keyFile)
+			if err != nil {
+				return fmt.Errorf("读取公钥文件失败: %v", err)
+			}
+			publicKey = strings.TrimSpace(string(data))
+		} else {
+			publicKey, err = templatePublicKey(sec, *template)
+			if err != nil {
+				return err
+			}
+		}
+		if publicKey == "" {
+			return fmt.Errorf("%w: 未找到 SSH 公钥, 请在实例模板中配置 ssh_authorized_key 或使用 --key-file 指定", errUsage)
+		}
+		connection, err := createConsoleConnection(acc, &id, publicKey)
+		if err != nil {
+			return fmt.Errorf("创建控制台连接失败: %v", err)
+		}
+		printf("[%s] 控制台连接已创建: %s\n", sec.Name(), stringValue(connection.Id))
+		printConsoleConnectionCommands(connection)
+	case "delete":
+		err := deleteConsoleConnection(acc, &id)
+		if err != nil {
+			return fmt.Errorf("删除控制台连接失败: %v", err)
+		}
+		printf("[%s] 控制台连接 %s 的删除操作已成功发起\n", sec.Name(), id)
+	case "history":
+		if *file == "" {
+			instance, err := getInstance(acc, &id)
+			if err != nil {
+				return fmt.Errorf("获取实例失败: %v", err)
+			}
+			*file = consoleHistoryFileName(instance)
+		}
+		n, err := saveConsoleHistory(acc, &id, *file)
+		if err != nil {
+			return fmt.Errorf("截取控制台历史失败: %v", err)
+		}
+		printf("[%s] 控制台历史已保存到 %s (%d 字节)\n", sec.Name(), *file, n)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+
+	"gopkg.in/ini.v1"
+)
+
+// TestTemplatePublicKey 指定的模板与其他模板使用相同的公钥时也能找到公钥
+func TestTemplatePublicKey(t *testing.T) {
+	cfg, err := ini.Load([]byte(`
+[INSTANCE]
+shape=VM.Standard.A1.Flex
+
+[INSTANCE.A]
+ssh_authorized_key=ssh-ed25519 AAAA shared
+
+[INSTANCE.B]
+ssh_authorized_key=ssh-ed25519 AAAA shared
+
+[acc]
+[acc.C]
+ssh_authorized_key=ssh-ed25519 CCCC own
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldBase := instanceBaseSection
+	defer func() { instanceBaseSection = oldBase }()
+	instanceBaseSection = cfg.Section("INSTANCE")
+	acc := cfg.Section("acc")
+
+	tests := []struct {
+		template string
+		want     string
+		wantErr  bool
+	}{
+		{"", "ssh-ed25519 AAAA shared", false},
+		{"B", "ssh-ed25519 AAAA shared", false},
+		{"INSTANCE.B", "ssh-ed25519 AAAA shared", false},
+		{"C", "ssh-ed25519 CCCC own", false},
+		{"D", "", true},
+	}
+	for _, tt := range tests {
+		got, err := templatePublicKey(acc, tt.template)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("templatePublicKey(%q) = %q, %v, 期望 %q", tt.template, got, err, tt.want)
+		}
+	}
+}
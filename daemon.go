@@ -0,0 +1,246 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"gopkg.in/ini.v1"
+)
+
+// daemonProgress 记录守护进程中各账号模板的执行情况, 重新加载配置后据此跳过已完成的模板。
+// 模板内部的创建进度由进度文件记录, 见 state.go。
+type daemonProgress struct {
+	mu       sync.Mutex      // 多个账号并发执行时保护 finished 和 failed
+	finished map[string]bool // 已执行完毕 (全部成功或重试次数用尽) 的模板
+	failed   map[string]bool // 执行完毕但未全部成功的模板
+}
+
+func daemonKey(accountName, template string) string {
+	return accountName + "/" + template
+}
+
+// cmdDaemon 以无人值守方式为所有账号执行所有实例模板。
+// SIGHUP: 等待进行中的创建请求完成后重新加载配置并继续;
+// SIGTERM/SIGINT: 等待进行中的创建请求完成后退出。
+func cmdDaemon(args []string) error {
+	fs := newFlagSet("daemon")
+	interval := fs.Int("interval", 60, "模板因网络等原因未能执行时, 下一轮重试前的等待时间(秒)")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 {
+		return fmt.Errorf("%w: daemon 不接受位置参数", errUsage)
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGTERM, syscall.SIGINT, syscall.SIGHUP)
+	defer signal.Stop(sigCh)
+
+	progress := &daemonProgress{
+		finished: map[string]bool{},
+		failed:   map[string]bool{},
+	}
+	printf("\033[1;36m守护进程已启动, PID: %d\033[0m\n", os.Getpid())
+	startTelegramBot()
+	for {
+		stop := make(chan struct{})
+		done := make(chan bool)
+		go func() {
+			done <- runDaemonRound(progress, stop)
+		}()
+
+		var sig os.Signal
+		select {
+		case pending := <-done:
+			if !pending {
+				printf("\033[1;36m所有实例模板已执行完毕, 守护进程退出\033[0m\n")
+				return progress.err()
+			}
+			printf("\033[1;33m部分模板本轮未能执行, %d 秒后重试\033[0m\n", *interval)
+			select {
+			case <-time.After(time.Duration(*interval) * time.Second):
+				continue
+			case sig = <-sigCh:
+			}
+		case sig = <-sigCh:
+			printf("\033[1;33m收到信号 %s, 等待进行中的创建请求完成...\033[0m\n", sig)
+			close(stop)
+			<-done
+		}
+
+		if sig != syscall.SIGHUP {
+			printf("\033[1;36m守护进程已退出\033[0m\n")
+			return nil
+		}
+		reloadConfig()
+	}
+}
+
+// reloadConfig 收到 SIGHUP 后重新加载配置, 失败时继续使用原配置
+func reloadConfig() {
+	err := loadConfig(configFilePath)
+	if err != nil {
+		printlnErr("重新加载配置失败, 继续使用原配置", err.Error())
+	} else {
+		printf("\033[1;36m配置已重新加载\033[0m\n")
+	}
+}
+
+// runWatchLoop 每隔 interval 执行一次 round, once 为 true 时只执行一次。
+// SIGHUP: 重新加载配置并立即执行一次; SIGTERM/SIGINT: 退出。name 用于启动和退出时的提示
+func runWatchLoop(name string, interval time.Duration, once bool, round func()) {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGTERM, syscall.SIGINT, syscall.SIGHUP)
+	defer signal.Stop(sigCh)
+
+	if !once {
+		printf("\033[1;36m%s已启动, PID: %d, 每 %d 分钟检查一次\033[0m\n", name, os.Getpid(), int(interval/time.Minute))
+	}
+	for {
+		round()
+		if once {
+			return
+		}
+
+		select {
+		case <-time.After(interval):
+		case sig := <-sigCh:
+			if sig != syscall.SIGHUP {
+				printf("\033[1;36m%s已退出\033[0m\n", name)
+				return
+			}
+			reloadConfig()
+		}
+	}
+}
+
+// isFinished 检查模板是否已执行完毕
+func (p *daemonProgress) isFinished(key string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.finished[key]
+}
+
+// finish 标记模板已执行完毕, failed 表示未全部创建成功
+func (p *daemonProgress) finish(key string, failed bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.finished[key] = true
+	if failed {
+		p.failed[key] = true
+	}
+}
+
+// err 汇总未全部创建成功的模板
+func (p *daemonProgress) err() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.failed) == 0 {
+		return nil
+	}
+	var keys []string
+	for key := range p.failed {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return fmt.Errorf("以下模板未全部创建成功: %s", strings.Join(keys, ", "))
+}
+
+// runDaemonRound 并发地为每个账号执行尚未完成的实例模板, stop 关闭后尽快返回。
+// 同时执行的账号数由 concurrency 限制, 同一账号下的模板同时执行。
+// 返回值表示是否还有未执行完毕的模板。
+func runDaemonRound(progress *daemonProgress, stop <-chan struct{}) (pending bool) {
+	IPsFilePath := IPsFilePrefix + "-" + time.Now().Format("2006-01-02-150405.txt")
+	var mu sync.Mutex
+	runAccountsParallel(oracleSections, func(sec *ini.Section) {
+		if runDaemonAccount(progress, sec, IPsFilePath, stop) {
+			mu.Lock()
+			pending = true
+			mu.Unlock()
+		}
+	})
+	return pending
+}
+
+// runDaemonAccount 并发执行单个账号下尚未完成的实例模板, 无限重试的模板不会阻塞其他模板。
+// 返回值表示该账号是否还有未执行完毕的模板
+func runDaemonAccount(progress *daemonProgress, sec *ini.Section, IPsFilePath string, stop <-chan struct{}) (pending bool) {
+	var instanceSections []*ini.Section
+	for _, instanceSec := range getInstanceSections(sec) {
+		if !progress.isFinished(daemonKey(sec.Name(), instanceSec.Name())) {
+			instanceSections = append(instanceSections, instanceSec)
+		}
+	}
+	if len(instanceSections) == 0 {
+		return false
+	}
+	if isStopped(stop) {
+		return true
+	}
+	acc, err := newAccount(sec)
+	if err != nil {
+		printlnErr(fmt.Sprintf("[%s] 账号初始化失败, 稍后重试", sec.Name()), err.Error())
+		return true
+	}
+
+	var created int32
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, instanceSec := range instanceSections {
+		wg.Add(1)
+		go func(instanceSec *ini.Section) {
+			defer wg.Done()
+			key := daemonKey(sec.Name(), instanceSec.Name())
+			num, err := runDaemonTemplate(progress, key, acc, instanceSec, stop)
+			if err != nil {
+				printlnErr(fmt.Sprintf("[%s] 执行模板 %s 失败", acc.Name, instanceSec.Name()), err.Error())
+				if !errors.Is(err, errRetryLater) {
+					progress.finish(key, true)
+				}
+			}
+			mu.Lock()
+			if !progress.isFinished(key) {
+				pending = true
+			}
+			created += num
+			mu.Unlock()
+		}(instanceSec)
+	}
+	wg.Wait()
+
+	if created > 0 && !isStopped(stop) {
+		batchListInstancesIp(acc, IPsFilePath)
+		command(cmd)
+	}
+	return pending
+}
+
+// runDaemonTemplate 执行单个实例模板, 返回成功创建的个数。重新执行时会从进度文件中恢复, 不会重复创建
+func runDaemonTemplate(progress *daemonProgress, key string, acc *Account, instanceSec *ini.Section, stop <-chan struct{}) (int32, error) {
+	spec, err := loadInstanceTemplate(instanceSec)
+	if err != nil {
+		return 0, err
+	}
+	availabilityDomains, err := ListAvailabilityDomains(acc)
+	if err != nil {
+		return 0, fmt.Errorf("%w: 获取可用性域失败: %v", errRetryLater, err)
+	}
+
+	sum, num, err := LaunchInstances(acc, spec, availabilityDomains, stop)
+	if err != nil {
+		// 未发起创建请求, 不标记为已完成, 守护进程将在下一轮重试
+		return 0, err
+	}
+	if !isStopped(stop) {
+		progress.finish(key, num < sum)
+	}
+	return num, nil
+}
@@ -0,0 +1,350 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/core"
+	"gopkg.in/ini.v1"
+)
+
+// 默认的出站流量通知记录文件
+const defEgressStateFilePath = "./oci-help-egress.json"
+
+// Egress 出站流量预算配置, 对应配置文件中的 [EGRESS]
+type Egress struct {
+	BudgetInGBs float64 `ini:"budgetInGBs"` // 每月出站流量预算 (GB)
+	Thresholds  string  `ini:"thresholds"`  // 通知阈值 (预算的百分比), 多个以逗号分隔
+	PerInstance bool    `ini:"perInstance"` // 预算按单个实例计算, 否则按账号下所有实例的合计计算
+	Stop        bool    `ini:"stop"`        // 超出预算时停止实例
+	StateFile   string  `ini:"stateFile"`   // 已发送通知的记录文件, 避免重启后重复通知
+
+	thresholds []float64 // 解析后的通知阈值, 从小到大排列, 最后一个为 100
+}
+
+// defaultEgress 返回默认的出站流量预算: 甲骨文每月免费的 10 TB
+func defaultEgress() Egress {
+	return Egress{
+		BudgetInGBs: 10240,
+		Thresholds:  "80,95",
+		StateFile:   defEgressStateFilePath,
+		thresholds:  []float64{80, 95, 100},
+	}
+}
+
+// parseEgressThresholds 解析通知阈值, 超出预算 (100%) 总是会通知
+func parseEgressThresholds(s string) ([]float64, error) {
+	var thresholds []float64
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(field), "%"))
+		if field == "" {
+			continue
+		}
+		t, err := strconv.ParseFloat(field, 64)
+		if err != nil || t <= 0 || t > 100 {
+			return nil, fmt.Errorf("无效的通知阈值: %s", field)
+		}
+		if t < 100 {
+			thresholds = append(thresholds, t)
+		}
+	}
+	sort.Float64s(thresholds)
+	return append(thresholds, 100), nil
+}
+
+// loadEgress 解析 [EGRESS] 配置, 没有该 section 时使用默认值
+func loadEgress(cfg *ini.File) (Egress, error) {
+	e := defaultEgress()
+	if !cfg.HasSection("EGRESS") {
+		return e, nil
+	}
+	err := cfg.Section("EGRESS").MapTo(&e)
+	if err != nil {
+		return e, fmt.Errorf("解析 [EGRESS] 配置失败: %v", err)
+	}
+	if e.BudgetInGBs <= 0 {
+		return e, fmt.Errorf("[EGRESS] budgetInGBs 必须大于 0")
+	}
+	e.thresholds, err = parseEgressThresholds(e.Thresholds)
+	if err != nil {
+		return e, fmt.Errorf("[EGRESS] %v", err)
+	}
+	return e, nil
+}
+
+// monthStart 返回 t 所在月份的第一天 (UTC), 甲骨文按 UTC 自然月统计流量
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// bytesToGB 将字节数转换为 GB
+func bytesToGB(bytes float64) float64 {
+	return bytes / 1024 / 1024 / 1024
+}
+
+// instanceEgress 单个实例本月的出站流量
+type instanceEgress struct {
+	instance core.Instance
+	bytes    float64
+}
+
+// getMonthEgress 获取账号下各实例本月以来的出站流量, 按流量从大到小排列。
+// total 为账号本月的出站流量合计, 包括已终止且不再显示的实例
+func getMonthEgress(acc *Account, now time.Time) (list []instanceEgress, total float64, err error) {
+	usage, err := getEgressByInstance(acc, monthStart(now), now)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, bytes := range usage {
+		total += bytes
+	}
+	instances, err := listAllInstances(acc)
+	if err != nil {
+		return nil, 0, fmt.Errorf("获取实例失败: %v", err)
+	}
+	for _, ins := range instances {
+		if ins.LifecycleState == core.InstanceLifecycleStateTerminated && usage[*ins.Id] == 0 {
+			continue
+		}
+		list = append(list, instanceEgress{ins, usage[*ins.Id]})
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].bytes > list[j].bytes })
+	return list, total, nil
+}
+
+// EgressRecord 实例本月的出站流量
+type EgressRecord struct {
+	Account     string  `json:"account" yaml:"account"`
+	Name        string  `json:"name" yaml:"name"`
+	Id          string  `json:"id" yaml:"id"`
+	State       string  `json:"state" yaml:"state"`
+	EgressInGBs float64 `json:"egressInGBs" yaml:"egressInGBs"`
+	BudgetInGBs float64 `json:"budgetInGBs" yaml:"budgetInGBs"`
+}
+
+func (r EgressRecord) tableHeader() []string {
+	return []string{"账号", "实例", "状态", "本月出站(GB)", "预算(GB)"}
+}
+
+func (r EgressRecord) tableRow() []string {
+	return []string{r.Account, r.Name, r.State, fmt.Sprintf("%.2f", r.EgressInGBs), fmt.Sprintf("%g", r.BudgetInGBs)}
+}
+
+// collectEgressRecords 获取账号下各实例本月的出站流量, 按账号计算预算时最后附加一行合计
+func collectEgressRecords(acc *Account) ([]tableRecord, error) {
+	list, total, err := getMonthEgress(acc, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	var records []tableRecord
+	for _, e := range list {
+		r := EgressRecord{
+			Account:     acc.Name,
+			Name:        stringValue(e.instance.DisplayName),
+			Id:          stringValue(e.instance.Id),
+			State:       string(e.instance.LifecycleState),
+			EgressInGBs: bytesToGB(e.bytes),
+		}
+		if egress.PerInstance {
+			r.BudgetInGBs = egress.BudgetInGBs
+		}
+		records = append(records, r)
+	}
+	if !egress.PerInstance {
+		records = append(records, EgressRecord{Account: acc.Name, Name: "合计", EgressInGBs: bytesToGB(total), BudgetInGBs: egress.BudgetInGBs})
+	}
+	return records, nil
+}
+
+// egressState 本月已发送的通知, 记录各账号或实例已达到的最高阈值
+type egressState struct {
+	Month    string             `json:"month"`    // 如 2026-01, 进入新的月份时清空记录
+	Notified map[string]float64 `json:"notified"` // key 为账号名称或 账号/实例OCID
+}
+
+// egressStateMutex 多个账号并发检查时保护 egressState.Notified
+var egressStateMutex sync.Mutex
+
+func loadEgressState(path, month string) (*egressState, error) {
+	state := &egressState{Month: month, Notified: map[string]float64{}}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, err
+	}
+	var saved egressState
+	if len(content) > 0 {
+		err = json.Unmarshal(content, &saved)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if saved.Month == month && saved.Notified != nil {
+		state.Notified = saved.Notified
+	}
+	return state, nil
+}
+
+// save 先写入临时文件再重命名, 避免进程中途退出时损坏记录文件
+func (s *egressState) save(path string) error {
+	content, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".oci-help-egress-*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(content)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// crossedThreshold 返回 percent 达到的最高阈值, 如果该阈值已通知过则返回 0
+func (s *egressState) crossedThreshold(key string, percent float64, thresholds []float64) float64 {
+	var crossed float64
+	for _, t := range thresholds {
+		if percent >= t {
+			crossed = t
+		}
+	}
+	if crossed == 0 || crossed <= s.Notified[key] {
+		return 0
+	}
+	return crossed
+}
+
+// checkEgress 检查一个账号本月的出站流量, 达到阈值时发送通知, 超出预算且开启了 stop 时停止实例
+func checkEgress(acc *Account, state *egressState, cfg Egress, now time.Time) error {
+	list, total, err := getMonthEgress(acc, now)
+	if err != nil {
+		return err
+	}
+	budget := cfg.BudgetInGBs * 1024 * 1024 * 1024
+
+	// check 检查一个预算对象 (账号或实例), targets 为超出预算时需要停止的实例
+	check := func(key, name string, bytes float64, targets []core.Instance) {
+		percent := bytes / budget * 100
+		egressStateMutex.Lock()
+		threshold := state.crossedThreshold(key, percent, cfg.thresholds)
+		if threshold > 0 {
+			state.Notified[key] = threshold
+		}
+		egressStateMutex.Unlock()
+		if threshold > 0 {
+			text := fmt.Sprintf("%s 本月出站流量: %.2f GB, 已达到预算 %g GB 的 %.1f%%", name, bytesToGB(bytes), cfg.BudgetInGBs, percent)
+			printf("\033[1;33m[%s] %s\033[0m\n", acc.Name, text)
+			notify("出站流量提醒", fmt.Sprintf("账号: %s\n%s", acc.Name, text))
+		}
+		if percent < 100 || !cfg.Stop {
+			return
+		}
+		for _, ins := range targets {
+			if ins.LifecycleState != core.InstanceLifecycleStateRunning {
+				continue
+			}
+			_, err := instanceAction(acc, ins.Id, core.InstanceActionActionSoftstop)
+			if err != nil {
+				printlnErr(fmt.Sprintf("[%s] 停止实例 %s 失败", acc.Name, stringValue(ins.DisplayName)), err.Error())
+				notify("出站流量超出预算", fmt.Sprintf("账号: %s\n停止实例 %s 失败: %v", acc.Name, stringValue(ins.DisplayName), err))
+				continue
+			}
+			printf("\033[1;31m[%s] 出站流量超出预算, 已停止实例 %s\033[0m\n", acc.Name, stringValue(ins.DisplayName))
+			notify("出站流量超出预算", fmt.Sprintf("账号: %s\n已停止实例: %s", acc.Name, stringValue(ins.DisplayName)))
+		}
+	}
+
+	if cfg.PerInstance {
+		for _, e := range list {
+			check(acc.Name+"/"+*e.instance.Id, "实例 "+stringValue(e.instance.DisplayName), e.bytes, []core.Instance{e.instance})
+		}
+		return nil
+	}
+	var instances []core.Instance
+	for _, e := range list {
+		instances = append(instances, e.instance)
+	}
+	check(acc.Name, "账号", total, instances)
+	return nil
+}
+
+// runEgressRound 并发检查所有账号的出站流量, 并保存通知记录
+func runEgressRound(secs []*ini.Section, cfg Egress) {
+	now := time.Now()
+	state, err := loadEgressState(cfg.StateFile, monthStart(now).Format("2006-01"))
+	if err != nil {
+		printlnErr("读取出站流量通知记录失败", err.Error())
+		return
+	}
+	runAccountsParallel(secs, func(sec *ini.Section) {
+		acc, err := newAccount(sec)
+		if err != nil {
+			printlnErr(fmt.Sprintf("[%s] 账号初始化失败", sec.Name()), err.Error())
+			return
+		}
+		err = checkEgress(acc, state, cfg, now)
+		if err != nil {
+			printlnErr(fmt.Sprintf("[%s] 检查出站流量失败", sec.Name()), err.Error())
+		}
+	})
+	err = state.save(cfg.StateFile)
+	if err != nil {
+		printlnErr("保存出站流量通知记录失败", err.Error())
+	}
+}
+
+// cmdEgress 查看本月出站流量, 或以监控模式定时检查所有账号的出站流量。
+// SIGHUP: 重新加载配置并立即检查一次; SIGTERM/SIGINT: 退出
+func cmdEgress(args []string) error {
+	if len(args) > 0 && args[0] == "list" {
+		return runListCommand("egress", args, "获取出站流量", collectEgressRecords)
+	}
+
+	fs := newFlagSet("egress")
+	accountName := fs.String("account", "", "账号名称, 不指定则检查所有账号")
+	interval := fs.Int("interval", 60, "检查间隔(分钟)")
+	once := fs.Bool("once", false, "只检查一次")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 || positional[0] != "watch" {
+		return fmt.Errorf("%w: egress 仅支持 list 和 watch", errUsage)
+	}
+	if *interval <= 0 {
+		return fmt.Errorf("%w: --interval 必须大于 0", errUsage)
+	}
+	if _, err := selectAccounts(*accountName); err != nil {
+		return err
+	}
+
+	runWatchLoop("出站流量监控", time.Duration(*interval)*time.Minute, *once, func() {
+		configMutex.RLock()
+		secs, err := selectAccounts(*accountName)
+		cfg := egress
+		configMutex.RUnlock()
+		if err != nil {
+			printlnErr("账号配置已变更", err.Error())
+			return
+		}
+		runEgressRound(secs, cfg)
+	})
+	return nil
+}
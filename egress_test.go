@@ -0,0 +1,60 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseEgressThresholds(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []float64
+		wantErr bool
+	}{
+		{"", []float64{100}, false},
+		{"80,95", []float64{80, 95, 100}, false},
+		// 顺序无关, 允许带百分号和空格
+		{" 95% , 80 ,", []float64{80, 95, 100}, false},
+		{"50.5", []float64{50.5, 100}, false},
+		// 100 总是会通知, 不重复添加
+		{"90,100", []float64{90, 100}, false},
+		{"0", nil, true},
+		{"-10", nil, true},
+		{"101", nil, true},
+		{"80,abc", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parseEgressThresholds(tt.in)
+		if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseEgressThresholds(%q) = %v, %v, 期望 %v, 出错: %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestCrossedThreshold(t *testing.T) {
+	thresholds := []float64{80, 95, 100}
+	tests := []struct {
+		notified float64
+		percent  float64
+		want     float64
+	}{
+		{0, 50, 0},
+		{0, 80, 80},
+		// 一次跨过多个阈值时只返回最高的
+		{0, 96, 95},
+		{0, 150, 100},
+		// 已通知过的阈值不再返回
+		{80, 85, 0},
+		{80, 95, 95},
+		{95, 99, 0},
+		{95, 100, 100},
+		{100, 200, 0},
+	}
+	for _, tt := range tests {
+		s := &egressState{Notified: map[string]float64{"acc": tt.notified}}
+		got := s.crossedThreshold("acc", tt.percent, thresholds)
+		if got != tt.want {
+			t.Errorf("已通知 %v%%, 使用 %v%%: crossedThreshold = %v, 期望 %v", tt.notified, tt.percent, got, tt.want)
+		}
+	}
+}
@@ -5,6 +5,7 @@ go 1.17
 require (
 	github.com/oracle/oci-go-sdk/v65 v65.95.2
 	gopkg.in/ini.v1 v1.66.2
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
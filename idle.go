@@ -0,0 +1,242 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/core"
+	"gopkg.in/ini.v1"
+)
+
+// 甲骨文会回收 7 天内 CPU、网络 (A1 还包括内存) 的 95 百分位使用率均低于 20% 的 Always Free 实例
+const (
+	idleWindow     = 7 * 24 * time.Hour
+	idleThreshold  = 20.0
+	idleResolution = "5m"
+)
+
+// 空闲检查结果
+const (
+	idleRiskNone   = "正常"
+	idleRiskSoon   = "即将有风险" // 实例运行不足 7 天, 按目前的使用率, 满 7 天后会被判定为空闲
+	idleRiskAtRisk = "有风险"   // 最近 7 天均低于阈值, 随时可能被回收
+	idleRiskNoData = "无数据"   // 没有监控数据, 无法判断
+)
+
+// IdleRecord Always Free 实例最近 7 天的 95 百分位使用率
+type IdleRecord struct {
+	Account    string    `json:"account" yaml:"account"`
+	Name       string    `json:"name" yaml:"name"`
+	Id         string    `json:"id" yaml:"id"`
+	Shape      string    `json:"shape" yaml:"shape"`
+	CpuP95     float64   `json:"cpuP95" yaml:"cpuP95"`
+	MemoryP95  float64   `json:"memoryP95" yaml:"memoryP95"` // 只有 A1 实例检查内存
+	NetworkP95 float64   `json:"networkP95" yaml:"networkP95"`
+	Since      time.Time `json:"since" yaml:"since"` // 统计的起始时间, 实例运行不足 7 天时为创建时间
+	Risk       string    `json:"risk" yaml:"risk"`
+}
+
+func (r IdleRecord) tableHeader() []string {
+	return []string{"账号", "实例", "配置", "CPU P95", "内存 P95", "网络 P95", "统计起始", "回收风险"}
+}
+
+func (r IdleRecord) tableRow() []string {
+	percent := func(v float64) string {
+		if r.Risk == idleRiskNoData {
+			return "-"
+		}
+		return fmt.Sprintf("%.1f%%", v)
+	}
+	memory := "-"
+	if r.isA1() {
+		memory = percent(r.MemoryP95)
+	}
+	return []string{r.Account, r.Name, r.Shape, percent(r.CpuP95), memory, percent(r.NetworkP95), r.Since.Local().Format("2006-01-02 15:04"), r.Risk}
+}
+
+func (r IdleRecord) isA1() bool {
+	return strings.EqualFold(r.Shape, shapeA1Flex)
+}
+
+// checkInstanceIdle 统计实例最近 7 天 (运行不足 7 天时从创建时间开始) 的 95 百分位使用率并判断回收风险。
+// 网络使用率为流入和流出中较大的一个占实例网络带宽的百分比
+func checkInstanceIdle(acc *Account, ins core.Instance, now time.Time) (IdleRecord, error) {
+	r := IdleRecord{
+		Account: acc.Name,
+		Name:    stringValue(ins.DisplayName),
+		Id:      stringValue(ins.Id),
+		Shape:   stringValue(ins.Shape),
+		Since:   now.Add(-idleWindow),
+	}
+	young := ins.TimeCreated != nil && ins.TimeCreated.After(r.Since)
+	if young {
+		r.Since = ins.TimeCreated.Time
+	}
+	if now.Sub(r.Since) < metricResolutions[idleResolution] {
+		r.Risk = idleRiskNoData
+		return r, nil
+	}
+
+	defs, _ := parseMetricKeys("cpu,memory,net-in,net-out")
+	metrics, err := getInstanceMetrics(acc, r.Id, defs, idleResolution, r.Since, now)
+	if err != nil {
+		return r, err
+	}
+	values := map[string]MetricRecord{}
+	for _, m := range metrics {
+		values[m.Metric] = m
+	}
+	if len(values["cpu"].Points) == 0 {
+		r.Risk = idleRiskNoData
+		return r, nil
+	}
+	r.CpuP95 = values["cpu"].P95
+	r.MemoryP95 = values["memory"].P95
+	if ins.ShapeConfig != nil && ins.ShapeConfig.NetworkingBandwidthInGbps != nil && *ins.ShapeConfig.NetworkingBandwidthInGbps > 0 {
+		bandwidth := float64(*ins.ShapeConfig.NetworkingBandwidthInGbps) * 1e9 / 8
+		network := values["net-in"].P95
+		if values["net-out"].P95 > network {
+			network = values["net-out"].P95
+		}
+		r.NetworkP95 = network / bandwidth * 100
+	}
+
+	idle := r.CpuP95 < idleThreshold && r.NetworkP95 < idleThreshold
+	if r.isA1() {
+		idle = idle && r.MemoryP95 < idleThreshold
+	}
+	switch {
+	case !idle:
+		r.Risk = idleRiskNone
+	case young:
+		r.Risk = idleRiskSoon
+	default:
+		r.Risk = idleRiskAtRisk
+	}
+	return r, nil
+}
+
+// isAlwaysFreeShape 检查是否是 Always Free 的 shape
+func isAlwaysFreeShape(shape string) bool {
+	return strings.EqualFold(shape, shapeA1Flex) || strings.EqualFold(shape, shapeE2Micro)
+}
+
+// collectIdleRecords 检查账号下所有运行中的 Always Free 实例的回收风险
+func collectIdleRecords(acc *Account) ([]tableRecord, error) {
+	instances, err := listAllInstances(acc)
+	if err != nil {
+		return nil, fmt.Errorf("获取实例失败: %v", err)
+	}
+	now := time.Now().UTC().Truncate(time.Minute)
+	var records []tableRecord
+	var failed []string
+	for _, ins := range instances {
+		if ins.LifecycleState != core.InstanceLifecycleStateRunning || !isAlwaysFreeShape(stringValue(ins.Shape)) {
+			continue
+		}
+		r, err := checkInstanceIdle(acc, ins, now)
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", stringValue(ins.DisplayName), err))
+			continue
+		}
+		records = append(records, r)
+	}
+	if len(failed) > 0 {
+		return records, fmt.Errorf("以下实例检查失败: %s", strings.Join(failed, "; "))
+	}
+	return records, nil
+}
+
+// idleNotified 记录各实例上次发送回收风险通知的时间, 每个实例每天最多通知一次
+var (
+	idleNotifiedMutex sync.Mutex
+	idleNotified      = map[string]time.Time{}
+)
+
+// notifyIdleRecords 为有回收风险的实例发送通知
+func notifyIdleRecords(records []tableRecord) {
+	for _, record := range records {
+		r := record.(IdleRecord)
+		if r.Risk != idleRiskAtRisk && r.Risk != idleRiskSoon {
+			continue
+		}
+		idleNotifiedMutex.Lock()
+		last, ok := idleNotified[r.Id]
+		due := !ok || time.Since(last) >= 24*time.Hour
+		if due {
+			idleNotified[r.Id] = time.Now()
+		}
+		idleNotifiedMutex.Unlock()
+		if !due {
+			continue
+		}
+
+		text := fmt.Sprintf("账号: %s\n实例: %s\nCPU P95: %.1f%%  网络 P95: %.1f%%", r.Account, r.Name, r.CpuP95, r.NetworkP95)
+		if r.isA1() {
+			text += fmt.Sprintf("  内存 P95: %.1f%%", r.MemoryP95)
+		}
+		if r.Risk == idleRiskSoon {
+			text += fmt.Sprintf("\n将于 %s 满 7 天, 届时可能被判定为空闲实例", r.Since.Add(idleWindow).Local().Format("2006-01-02 15:04"))
+		} else {
+			text += "\n最近 7 天的使用率均低于 20%, 随时可能被回收"
+		}
+		printf("\033[1;33m[%s] 实例 %s 有被回收的风险\033[0m\n", r.Account, r.Name)
+		notify("空闲实例回收提醒", text)
+	}
+}
+
+// cmdIdle 检查 Always Free 实例是否有因空闲被回收的风险, watch 模式下定时检查并发送通知。
+// SIGHUP: 重新加载配置并立即检查一次; SIGTERM/SIGINT: 退出
+func cmdIdle(args []string) error {
+	if len(args) > 0 && args[0] == "list" {
+		return runListCommand("idle", args, "检查空闲实例", collectIdleRecords)
+	}
+
+	fs := newFlagSet("idle")
+	accountName := fs.String("account", "", "账号名称, 不指定则检查所有账号")
+	interval := fs.Int("interval", 360, "检查间隔(分钟)")
+	once := fs.Bool("once", false, "只检查一次")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 || positional[0] != "watch" {
+		return fmt.Errorf("%w: idle 仅支持 list 和 watch", errUsage)
+	}
+	if *interval <= 0 {
+		return fmt.Errorf("%w: --interval 必须大于 0", errUsage)
+	}
+	if _, err := selectAccounts(*accountName); err != nil {
+		return err
+	}
+
+	runWatchLoop("空闲实例监控", time.Duration(*interval)*time.Minute, *once, func() {
+		configMutex.RLock()
+		secs, err := selectAccounts(*accountName)
+		configMutex.RUnlock()
+		if err != nil {
+			printlnErr("账号配置已变更", err.Error())
+			return
+		}
+		runIdleRound(secs)
+	})
+	return nil
+}
+
+// runIdleRound 并发检查所有账号的 Always Free 实例, 有回收风险时发送通知
+func runIdleRound(secs []*ini.Section) {
+	runAccountsParallel(secs, func(sec *ini.Section) {
+		acc, err := newAccount(sec)
+		if err != nil {
+			printlnErr(fmt.Sprintf("[%s] 账号初始化失败", sec.Name()), err.Error())
+			return
+		}
+		records, err := collectIdleRecords(acc)
+		if err != nil {
+			printlnErr(fmt.Sprintf("[%s] 检查空闲实例失败", sec.Name()), err.Error())
+		}
+		notifyIdleRecords(records)
+	})
+}
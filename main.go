@@ -1,8 +1,10 @@
 package main
 
 import (
+	"flag"
 	"log"
 	"math/rand"
+	"os"
 	"time"
 )
 
@@ -10,11 +12,20 @@ func main() {
 	// 初始化随机数种子
 	rand.Seed(time.Now().UnixNano())
 
+	// 在 main 中解析命令行参数, 以免 go test 的参数被当作本程序的参数
+	flag.Parse()
+
+	// 带子命令时以非交互方式运行, 执行完毕后按结果退出
+	if flag.NArg() > 0 {
+		os.Exit(runCommand(flag.Args()))
+	}
+
 	// 加载并解析配置文件
-	err := loadConfig(defConfigFilePath)
+	err := loadConfig(configFilePath)
 	if err != nil {
 		log.Fatalf("错误: 无法加载配置文件。%v", err)
 	}
+	startTelegramBot()
 
 	// 如果配置文件中定义了多个账号，则让用户选择一个
 	// 如果只有一个账号，则直接使用
@@ -28,6 +39,6 @@ func main() {
 	} else if len(oracleSections) > 1 {
 		listOracleAccounts()
 	} else {
-		log.Fatalf("错误: 在 %s 中未找到有效的甲骨文账号配置。", defConfigFilePath)
+		log.Fatalf("错误: 在 %s 中未找到有效的甲骨文账号配置。", configFilePath)
 	}
 }
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// metricDef 实例监控指标, 数据来自 oci_computeagent, 需要实例启用 Compute Instance Monitoring 插件
+type metricDef struct {
+	Key   string // 命令行中使用的名称
+	Name  string // 甲骨文监控中的指标名称
+	Label string
+	Rate  bool   // true: 按采样间隔求和后换算为每秒速率; false: 取平均值 (百分比)
+	Unit  string // 速率的单位, B/s 会自动换算为 KB/s、MB/s 等
+}
+
+var instanceMetrics = []metricDef{
+	{"cpu", "CpuUtilization", "CPU 使用率", false, "%"},
+	{"memory", "MemoryUtilization", "内存使用率", false, "%"},
+	{"disk-read", "DiskBytesRead", "磁盘读取", true, "B/s"},
+	{"disk-write", "DiskBytesWritten", "磁盘写入", true, "B/s"},
+	{"disk-read-iops", "DiskIopsRead", "磁盘读 IOPS", true, "次/s"},
+	{"disk-write-iops", "DiskIopsWritten", "磁盘写 IOPS", true, "次/s"},
+	{"net-in", "NetworkBytesIn", "网络流入", true, "B/s"},
+	{"net-out", "NetworkBytesOut", "网络流出", true, "B/s"},
+}
+
+// metricRange 可选的查询时间范围及默认采样间隔
+type metricRange struct {
+	Key        string
+	Label      string
+	Duration   time.Duration
+	Resolution string
+}
+
+var metricRanges = []metricRange{
+	{"1h", "最近 1 小时", time.Hour, "1m"},
+	{"6h", "最近 6 小时", 6 * time.Hour, "5m"},
+	{"24h", "最近 24 小时", 24 * time.Hour, "5m"},
+	{"7d", "最近 7 天", 7 * 24 * time.Hour, "1h"},
+	{"30d", "最近 30 天", 30 * 24 * time.Hour, "1h"},
+}
+
+// metricResolutions 可选的采样间隔
+var metricResolutions = map[string]time.Duration{
+	"1m": time.Minute,
+	"5m": 5 * time.Minute,
+	"1h": time.Hour,
+	"1d": 24 * time.Hour,
+}
+
+// 单个指标最多查询的数据点, 相当于 1 分钟间隔查询 7 天
+const maxMetricPoints = 7 * 24 * 60
+
+// sparklineWidth 趋势图的宽度 (字符数)
+const sparklineWidth = 48
+
+// findMetricRange 按名称查找时间范围
+func findMetricRange(key string) (metricRange, error) {
+	var keys []string
+	for _, r := range metricRanges {
+		if r.Key == key {
+			return r, nil
+		}
+		keys = append(keys, r.Key)
+	}
+	return metricRange{}, fmt.Errorf("不支持的时间范围 %s, 可选 %s", key, strings.Join(keys, "|"))
+}
+
+// checkMetricResolution 检查采样间隔是否受支持, 且数据点不会过多
+func checkMetricResolution(resolution string, d time.Duration) error {
+	step, ok := metricResolutions[resolution]
+	if !ok {
+		return fmt.Errorf("不支持的采样间隔 %s, 可选 1m|5m|1h|1d", resolution)
+	}
+	if step > d {
+		return fmt.Errorf("采样间隔 %s 大于时间范围", resolution)
+	}
+	if d/step > maxMetricPoints {
+		return fmt.Errorf("采样间隔 %s 过小, 数据点过多, 请使用更大的采样间隔", resolution)
+	}
+	return nil
+}
+
+// parseMetricKeys 解析以逗号分隔的指标名称, 为空时返回全部指标
+func parseMetricKeys(s string) ([]metricDef, error) {
+	if strings.TrimSpace(s) == "" {
+		return instanceMetrics, nil
+	}
+	var defs []metricDef
+	for _, key := range strings.Split(s, ",") {
+		key = strings.TrimSpace(key)
+		found := false
+		for _, def := range instanceMetrics {
+			if def.Key == key {
+				defs = append(defs, def)
+				found = true
+				break
+			}
+		}
+		if !found {
+			var keys []string
+			for _, def := range instanceMetrics {
+				keys = append(keys, def.Key)
+			}
+			return nil, fmt.Errorf("不支持的指标 %s, 可选 %s", key, strings.Join(keys, ","))
+		}
+	}
+	return defs, nil
+}
+
+// metricPoint 时间序列中的一个数据点, 速率类指标已换算为每秒的值
+type metricPoint struct {
+	Time  time.Time `json:"time" yaml:"time"`
+	Value float64   `json:"value" yaml:"value"`
+}
+
+// MetricRecord 实例一个监控指标的统计结果
+type MetricRecord struct {
+	Account    string        `json:"account" yaml:"account"`
+	InstanceId string        `json:"instanceId" yaml:"instanceId"`
+	Metric     string        `json:"metric" yaml:"metric"`
+	Unit       string        `json:"unit" yaml:"unit"`
+	Resolution string        `json:"resolution" yaml:"resolution"`
+	Min        float64       `json:"min" yaml:"min"`
+	Avg        float64       `json:"avg" yaml:"avg"`
+	Max        float64       `json:"max" yaml:"max"`
+	P95        float64       `json:"p95" yaml:"p95"`
+	Points     []metricPoint `json:"points" yaml:"points"`
+
+	def   metricDef
+	chart string // 趋势图
+}
+
+func (r MetricRecord) tableHeader() []string {
+	return []string{"指标", "最小", "平均", "最大", "P95", "趋势"}
+}
+
+func (r MetricRecord) tableRow() []string {
+	if len(r.Points) == 0 {
+		return []string{r.def.Label, "-", "-", "-", "-", "无数据"}
+	}
+	return []string{r.def.Label, r.format(r.Min), r.format(r.Avg), r.format(r.Max), r.format(r.P95), r.chart}
+}
+
+// format 按指标的单位格式化数值
+func (r MetricRecord) format(v float64) string {
+	switch r.def.Unit {
+	case "%":
+		return fmt.Sprintf("%.1f%%", v)
+	case "B/s":
+		units := []string{"B/s", "KB/s", "MB/s", "GB/s"}
+		i := 0
+		for v >= 1024 && i < len(units)-1 {
+			v /= 1024
+			i++
+		}
+		return fmt.Sprintf("%.1f %s", v, units[i])
+	default:
+		return fmt.Sprintf("%.1f %s", v, r.def.Unit)
+	}
+}
+
+// getInstanceMetrics 查询实例在 startTime 至 endTime 期间的监控指标并计算统计值
+func getInstanceMetrics(acc *Account, instanceId string, defs []metricDef, resolution string, startTime, endTime time.Time) ([]MetricRecord, error) {
+	step := metricResolutions[resolution]
+	var records []MetricRecord
+	for _, def := range defs {
+		statistic := "mean"
+		if def.Rate {
+			statistic = "sum"
+		}
+		datapoints, err := getInstanceMetricSeries(acc, instanceId, def.Name, statistic, resolution, startTime, endTime)
+		if err != nil {
+			return records, fmt.Errorf("查询%s失败: %v", def.Label, err)
+		}
+		r := MetricRecord{
+			Account:    acc.Name,
+			InstanceId: instanceId,
+			Metric:     def.Key,
+			Unit:       def.Unit,
+			Resolution: resolution,
+			def:        def,
+		}
+		for _, dp := range datapoints {
+			if dp.Timestamp == nil || dp.Value == nil {
+				continue
+			}
+			v := *dp.Value
+			if def.Rate {
+				v /= step.Seconds()
+			}
+			r.Points = append(r.Points, metricPoint{dp.Timestamp.Time, v})
+		}
+		sort.Slice(r.Points, func(i, j int) bool { return r.Points[i].Time.Before(r.Points[j].Time) })
+		r.Min, r.Avg, r.Max, r.P95 = metricStats(r.Points)
+		r.chart = sparkline(r.Points, startTime, endTime, sparklineWidth)
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+// metricStats 计算最小值、平均值、最大值和 95 百分位数
+func metricStats(points []metricPoint) (min, avg, max, p95 float64) {
+	if len(points) == 0 {
+		return
+	}
+	values := make([]float64, len(points))
+	var sum float64
+	for i, p := range points {
+		values[i] = p.Value
+		sum += p.Value
+	}
+	sort.Float64s(values)
+	rank := int(math.Ceil(0.95*float64(len(values)))) - 1
+	return values[0], sum / float64(len(values)), values[len(values)-1], values[rank]
+}
+
+// sparkline 将时间序列按时间均分为 width 段, 每段取平均值后绘制为趋势图, 没有数据的时间段显示为空格
+func sparkline(points []metricPoint, startTime, endTime time.Time, width int) string {
+	blocks := []rune("▁▂▃▄▅▆▇█")
+	span := endTime.Sub(startTime)
+	if len(points) == 0 || span <= 0 {
+		return ""
+	}
+	sums := make([]float64, width)
+	counts := make([]int, width)
+	for _, p := range points {
+		i := int(float64(p.Time.Sub(startTime)) / float64(span) * float64(width))
+		if i < 0 || i >= width {
+			continue
+		}
+		sums[i] += p.Value
+		counts[i]++
+	}
+	var max float64
+	for i := range sums {
+		if counts[i] > 0 {
+			sums[i] /= float64(counts[i])
+			max = math.Max(max, sums[i])
+		}
+	}
+	var sb strings.Builder
+	for i := range sums {
+		switch {
+		case counts[i] == 0:
+			sb.WriteRune(' ')
+		case max == 0:
+			sb.WriteRune(blocks[0])
+		default:
+			sb.WriteRune(blocks[int(sums[i]/max*float64(len(blocks)-1)+0.5)])
+		}
+	}
+	return sb.String()
+}
+
+// printMetricRecords 以趋势图的形式输出监控指标, 供交互菜单使用
+func printMetricRecords(records []MetricRecord, startTime, endTime time.Time) {
+	axis := fmt.Sprintf("%-*s%s", sparklineWidth-len("01-02 15:04"), startTime.Local().Format("01-02 15:04"), endTime.Local().Format("01-02 15:04"))
+	for _, r := range records {
+		fmt.Printf("\n\033[1m%s\033[0m\n", r.def.Label)
+		if len(r.Points) == 0 {
+			fmt.Println("  无数据")
+			continue
+		}
+		fmt.Printf("  %s\n", r.chart)
+		fmt.Printf("  %s\n", axis)
+		fmt.Printf("  最小 %s  平均 %s  最大 %s  P95 %s\n", r.format(r.Min), r.format(r.Avg), r.format(r.Max), r.format(r.P95))
+	}
+}
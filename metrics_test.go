@@ -0,0 +1,71 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMetricStats(t *testing.T) {
+	points := func(values ...float64) []metricPoint {
+		var ps []metricPoint
+		for _, v := range values {
+			ps = append(ps, metricPoint{Value: v})
+		}
+		return ps
+	}
+	var twenty []float64
+	for i := 20; i >= 1; i-- {
+		twenty = append(twenty, float64(i))
+	}
+
+	tests := []struct {
+		name               string
+		points             []metricPoint
+		min, avg, max, p95 float64
+	}{
+		{"空", nil, 0, 0, 0, 0},
+		{"1个点", points(7), 7, 7, 7, 7},
+		// 样本较少时 95 百分位数按最近秩取值, 即最大值
+		{"2个点", points(10, 2), 2, 6, 10, 10},
+		{"5个点", points(5, 1, 4, 2, 3), 1, 3, 5, 5},
+		// 第 ceil(0.95*20)=19 个值
+		{"20个点", points(twenty...), 1, 10.5, 20, 19},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			min, avg, max, p95 := metricStats(tt.points)
+			if min != tt.min || avg != tt.avg || max != tt.max || p95 != tt.p95 {
+				t.Errorf("metricStats = %v, %v, %v, %v, 期望 %v, %v, %v, %v", min, avg, max, p95, tt.min, tt.avg, tt.max, tt.p95)
+			}
+		})
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(4 * time.Hour)
+	at := func(h float64, v float64) metricPoint {
+		return metricPoint{start.Add(time.Duration(h * float64(time.Hour))), v}
+	}
+
+	tests := []struct {
+		name   string
+		points []metricPoint
+		end    time.Time
+		want   string
+	}{
+		{"无数据", nil, end, ""},
+		{"时间范围无效", []metricPoint{at(0, 1)}, start, ""},
+		// 每段取平均值, 没有数据的时间段为空格, 超出时间范围的点忽略
+		{"正常", []metricPoint{at(0, 1), at(0.5, 3), at(2, 4), at(3, 8), at(4, 100), at(-1, 100)}, end, "▃ ▅█"},
+		{"全为0", []metricPoint{at(0, 0), at(3, 0)}, end, "▁  ▁"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := sparkline(tt.points, start, tt.end, 4)
+			if got != tt.want {
+				t.Errorf("sparkline = %q, 期望 %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,519 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"gopkg.in/ini.v1"
+)
+
+// Notifier 消息通知后端
+type Notifier interface {
+	// Name 返回后端名称, 用于打印错误信息
+	Name() string
+	// Send 发送一条新消息, 返回的 id 可用于 Edit。不支持编辑消息的后端返回空字符串
+	Send(title, text string) (id string, err error)
+	// Edit 修改一条已发送的消息
+	Edit(id, title, text string) error
+}
+
+// notifierConfig 可以从配置文件中创建的通知后端
+type notifierConfig interface {
+	Notifier
+	// check 检查配置是否完整
+	check() error
+}
+
+// notifierTypes 配置文件中 [NOTIFY.*] 的 type 与通知后端的对应关系
+var notifierTypes = map[string]func() notifierConfig{
+	"telegram":   func() notifierConfig { return &TelegramNotifier{} },
+	"webhook":    func() notifierConfig { return &WebhookNotifier{} },
+	"discord":    func() notifierConfig { return &DiscordNotifier{} },
+	"slack":      func() notifierConfig { return &SlackNotifier{} },
+	"bark":       func() notifierConfig { return &BarkNotifier{} },
+	"serverchan": func() notifierConfig { return &ServerChanNotifier{} },
+	"ntfy":       func() notifierConfig { return &NtfyNotifier{} },
+	"email":      func() notifierConfig { return &EmailNotifier{} },
+	"pushplus":   func() notifierConfig { return &PushPlusNotifier{} },
+}
+
+// notifiers 当前启用的通知后端, 可以同时启用多个
+var notifiers []Notifier
+
+// loadNotifiers 读取通知配置: [DEFAULT] 中的 token/chat_id 以及所有 [NOTIFY.*] section。
+// [NOTIFY.*] 通过 type 指定后端类型, 未指定时使用 section 名称中最后一个点之后的部分, 如 [NOTIFY.slack]
+func loadNotifiers(cfg *ini.File) ([]Notifier, error) {
+	var list []Notifier
+	defSec := cfg.Section(ini.DefaultSection)
+	if defSec.Key("token").String() != "" && defSec.Key("chat_id").String() != "" {
+		list = append(list, &TelegramNotifier{
+			Token:  defSec.Key("token").String(),
+			ChatId: defSec.Key("chat_id").String(),
+		})
+	}
+	for _, sec := range cfg.Section("NOTIFY").ChildSections() {
+		typ := sec.Key("type").String()
+		if typ == "" {
+			typ = sec.Name()[strings.LastIndex(sec.Name(), ".")+1:]
+		}
+		newNotifier, ok := notifierTypes[strings.ToLower(typ)]
+		if !ok {
+			return nil, fmt.Errorf("[%s] 未知的通知类型: %s", sec.Name(), typ)
+		}
+		n := newNotifier()
+		err := sec.MapTo(n)
+		if err != nil {
+			return nil, fmt.Errorf("[%s] 解析通知配置失败: %v", sec.Name(), err)
+		}
+		err = n.check()
+		if err != nil {
+			return nil, fmt.Errorf("[%s] %v", sec.Name(), err)
+		}
+		list = append(list, n)
+	}
+	return list, nil
+}
+
+// notify 向所有通知后端发送一条消息, 发送失败时只打印错误
+func notify(title, text string) {
+	newNotifyThread(title).send(text)
+}
+
+// notifyThread 一组前后关联的消息, 如"正在启动"和随后的"启动成功"。
+// 第一次调用 send 时发送新消息, 之后支持编辑的后端会修改原消息, 其余后端发送新消息。
+type notifyThread struct {
+	mu        sync.Mutex
+	title     string
+	notifiers []Notifier
+	ids       []string // 各后端已发送消息的 id, 与 notifiers 一一对应
+}
+
+func newNotifyThread(title string) *notifyThread {
+	return &notifyThread{
+		title:     title,
+		notifiers: notifiers,
+		ids:       make([]string, len(notifiers)),
+	}
+}
+
+// send 并发地向所有后端发送或更新消息, 等待全部完成后返回
+func (t *notifyThread) send(text string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var wg sync.WaitGroup
+	for i, n := range t.notifiers {
+		wg.Add(1)
+		go func(i int, n Notifier) {
+			defer wg.Done()
+			if t.ids[i] != "" {
+				err := n.Edit(t.ids[i], t.title, text)
+				if err == nil {
+					return
+				}
+				printlnErr(n.Name()+" 消息编辑失败, 将发送新消息", err.Error())
+			}
+			id, err := n.Send(t.title, text)
+			if err != nil {
+				printlnErr(n.Name()+" 消息提醒发送失败", err.Error())
+				return
+			}
+			t.ids[i] = id
+		}(i, n)
+	}
+	wg.Wait()
+}
+
+// newHTTPClient 返回发送通知使用的 HTTP 客户端, 配置了代理时通过代理发送
+func newHTTPClient() *http.Client {
+	client := common.BaseClient{HTTPClient: &http.Client{}}
+	setProxyOrNot(&client)
+	httpClient := client.HTTPClient.(*http.Client)
+	httpClient.Timeout = 30 * time.Second
+	return httpClient
+}
+
+// doRequest 发送请求, 非 2xx 响应视为失败。result 不为 nil 时将响应解析为 JSON
+func doRequest(req *http.Request, result interface{}) error {
+	resp, err := newHTTPClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	if result != nil {
+		return json.Unmarshal(body, result)
+	}
+	return nil
+}
+
+// sendJSON 以 JSON 格式发送 payload
+func sendJSON(method, rawURL string, payload, result interface{}) error {
+	content, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(method, rawURL, bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	return doRequest(req, result)
+}
+
+// --- 通用 Webhook ---
+
+// WebhookNotifier 以 JSON 格式 POST 到指定地址: {"title": "...", "text": "...", "time": "..."}
+type WebhookNotifier struct {
+	URL string `ini:"url"`
+}
+
+func (w *WebhookNotifier) Name() string { return "Webhook" }
+
+func (w *WebhookNotifier) check() error {
+	if w.URL == "" {
+		return errors.New("url 不能为空")
+	}
+	return nil
+}
+
+func (w *WebhookNotifier) Send(title, text string) (string, error) {
+	payload := map[string]string{
+		"title": "甲骨文通知 " + title,
+		"text":  text,
+		"time":  time.Now().Format(time.RFC3339),
+	}
+	return "", sendJSON(http.MethodPost, w.URL, payload, nil)
+}
+
+func (w *WebhookNotifier) Edit(id, title, text string) error {
+	return errors.New("不支持编辑消息")
+}
+
+// --- Discord ---
+
+// DiscordNotifier 通过 Discord 频道的 Webhook 发送消息, 支持编辑已发送的消息
+type DiscordNotifier struct {
+	URL string `ini:"url"` // https://discord.com/api/webhooks/{id}/{token}
+}
+
+func (d *DiscordNotifier) Name() string { return "Discord" }
+
+func (d *DiscordNotifier) check() error {
+	if d.URL == "" {
+		return errors.New("url 不能为空")
+	}
+	return nil
+}
+
+func (d *DiscordNotifier) content(title, text string) map[string]string {
+	return map[string]string{"content": "🔰**甲骨文通知** " + title + "\n" + text}
+}
+
+func (d *DiscordNotifier) Send(title, text string) (string, error) {
+	var msg struct {
+		Id string `json:"id"`
+	}
+	err := sendJSON(http.MethodPost, d.URL+"?wait=true", d.content(title, text), &msg)
+	return msg.Id, err
+}
+
+func (d *DiscordNotifier) Edit(id, title, text string) error {
+	return sendJSON(http.MethodPatch, d.URL+"/messages/"+id, d.content(title, text), nil)
+}
+
+// --- Slack ---
+
+// SlackNotifier 通过 Slack 的 Incoming Webhook 发送消息
+type SlackNotifier struct {
+	URL string `ini:"url"`
+}
+
+func (s *SlackNotifier) Name() string { return "Slack" }
+
+func (s *SlackNotifier) check() error {
+	if s.URL == "" {
+		return errors.New("url 不能为空")
+	}
+	return nil
+}
+
+func (s *SlackNotifier) Send(title, text string) (string, error) {
+	payload := map[string]string{"text": "🔰*甲骨文通知* " + title + "\n" + text}
+	return "", sendJSON(http.MethodPost, s.URL, payload, nil)
+}
+
+func (s *SlackNotifier) Edit(id, title, text string) error {
+	return errors.New("不支持编辑消息")
+}
+
+// --- Bark ---
+
+// BarkNotifier 通过 Bark 推送到 iOS 设备
+type BarkNotifier struct {
+	URL string `ini:"url"` // 包含设备 Key 的推送地址, 如 https://api.day.app/xxxxxx
+}
+
+func (b *BarkNotifier) Name() string { return "Bark" }
+
+func (b *BarkNotifier) check() error {
+	if b.URL == "" {
+		return errors.New("url 不能为空")
+	}
+	return nil
+}
+
+func (b *BarkNotifier) Send(title, text string) (string, error) {
+	payload := map[string]string{
+		"title": "甲骨文通知 " + title,
+		"body":  text,
+		"group": "oci-help",
+	}
+	return "", sendJSON(http.MethodPost, strings.TrimRight(b.URL, "/"), payload, nil)
+}
+
+func (b *BarkNotifier) Edit(id, title, text string) error {
+	return errors.New("不支持编辑消息")
+}
+
+// --- Server酱 ---
+
+// ServerChanNotifier 通过 Server酱 推送到微信
+type ServerChanNotifier struct {
+	SendKey string `ini:"sendkey"`
+}
+
+func (s *ServerChanNotifier) Name() string { return "Server酱" }
+
+func (s *ServerChanNotifier) check() error {
+	if s.SendKey == "" {
+		return errors.New("sendkey 不能为空")
+	}
+	return nil
+}
+
+func (s *ServerChanNotifier) Send(title, text string) (string, error) {
+	data := url.Values{
+		"title": {"甲骨文通知 " + title},
+		"desp":  {text},
+	}
+	req, err := http.NewRequest(http.MethodPost, "https://sctapi.ftqq.com/"+s.SendKey+".send", strings.NewReader(data.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	var result struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	err = doRequest(req, &result)
+	if err == nil && result.Code != 0 {
+		err = errors.New(result.Message)
+	}
+	return "", err
+}
+
+func (s *ServerChanNotifier) Edit(id, title, text string) error {
+	return errors.New("不支持编辑消息")
+}
+
+// --- ntfy ---
+
+// NtfyNotifier 通过 ntfy 推送, 支持自建服务器和访问令牌
+type NtfyNotifier struct {
+	URL   string `ini:"url"`   // 主题地址, 如 https://ntfy.sh/oci-help
+	Token string `ini:"token"` // 访问令牌 (可选)
+}
+
+func (n *NtfyNotifier) Name() string { return "ntfy" }
+
+func (n *NtfyNotifier) check() error {
+	if n.URL == "" {
+		return errors.New("url 不能为空")
+	}
+	return nil
+}
+
+func (n *NtfyNotifier) Send(title, text string) (string, error) {
+	req, err := http.NewRequest(http.MethodPost, n.URL, strings.NewReader(text))
+	if err != nil {
+		return "", err
+	}
+	// HTTP 头只能包含 ASCII 字符, 中文标题需要编码
+	req.Header.Set("Title", mime.BEncoding.Encode("UTF-8", "甲骨文通知 "+title))
+	if n.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.Token)
+	}
+	return "", doRequest(req, nil)
+}
+
+func (n *NtfyNotifier) Edit(id, title, text string) error {
+	return errors.New("不支持编辑消息")
+}
+
+// --- PushPlus ---
+
+// PushPlusNotifier 通过 PushPlus 推送到微信
+type PushPlusNotifier struct {
+	Token string `ini:"token"`
+}
+
+func (p *PushPlusNotifier) Name() string { return "PushPlus" }
+
+func (p *PushPlusNotifier) check() error {
+	if p.Token == "" {
+		return errors.New("token 不能为空")
+	}
+	return nil
+}
+
+func (p *PushPlusNotifier) Send(title, text string) (string, error) {
+	payload := map[string]string{
+		"token":    p.Token,
+		"title":    "甲骨文通知 " + title,
+		"content":  text,
+		"template": "txt",
+	}
+	var result struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	}
+	err := sendJSON(http.MethodPost, "https://www.pushplus.plus/send", payload, &result)
+	if err == nil && result.Code != 200 {
+		err = errors.New(result.Msg)
+	}
+	return "", err
+}
+
+func (p *PushPlusNotifier) Edit(id, title, text string) error {
+	return errors.New("不支持编辑消息")
+}
+
+// --- 邮件 ---
+
+// EmailNotifier 通过 SMTP 发送邮件。端口为 465 时使用 SSL, 其他端口在服务器支持时使用 STARTTLS。
+// 邮件不经过配置的代理发送。
+type EmailNotifier struct {
+	Host     string `ini:"host"`
+	Port     int    `ini:"port"`
+	Username string `ini:"username"`
+	Password string `ini:"password"`
+	From     string `ini:"from"`
+	To       string `ini:"to"` // 多个收件人用逗号分隔
+}
+
+// emailTimeout 发送一封邮件 (连接、认证和发送) 的最长时间
+var emailTimeout = 30 * time.Second
+
+func (e *EmailNotifier) Name() string { return "Email" }
+
+func (e *EmailNotifier) check() error {
+	if e.Host == "" || e.To == "" {
+		return errors.New("host 和 to 不能为空")
+	}
+	if e.Port == 0 {
+		e.Port = 25
+	}
+	if e.From == "" {
+		e.From = e.Username
+	}
+	if e.From == "" {
+		return errors.New("from 和 username 不能同时为空")
+	}
+	return nil
+}
+
+func (e *EmailNotifier) recipients() []string {
+	var to []string
+	for _, addr := range strings.Split(e.To, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			to = append(to, addr)
+		}
+	}
+	return to
+}
+
+func (e *EmailNotifier) Send(title, text string) (string, error) {
+	to := e.recipients()
+	var msg bytes.Buffer
+	msg.WriteString("From: " + e.From + "\r\n")
+	msg.WriteString("To: " + strings.Join(to, ", ") + "\r\n")
+	msg.WriteString("Subject: " + mime.BEncoding.Encode("UTF-8", "甲骨文通知 "+title) + "\r\n")
+	msg.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+	msg.WriteString(base64.StdEncoding.EncodeToString([]byte(text)) + "\r\n")
+
+	// smtp.SendMail 没有超时, SMTP 服务器无响应时会一直阻塞创建实例, 因此自行建立连接并设置截止时间
+	addr := net.JoinHostPort(e.Host, strconv.Itoa(e.Port))
+	conn, err := net.DialTimeout("tcp", addr, emailTimeout)
+	if err != nil {
+		return "", err
+	}
+	conn.SetDeadline(time.Now().Add(emailTimeout))
+	if e.Port == 465 {
+		conn = tls.Client(conn, &tls.Config{ServerName: e.Host})
+	}
+	c, err := smtp.NewClient(conn, e.Host)
+	if err != nil {
+		conn.Close()
+		return "", err
+	}
+	defer c.Close()
+	if e.Port != 465 {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err = c.StartTLS(&tls.Config{ServerName: e.Host}); err != nil {
+				return "", err
+			}
+		}
+	}
+	if e.Username != "" {
+		if err = c.Auth(smtp.PlainAuth("", e.Username, e.Password, e.Host)); err != nil {
+			return "", err
+		}
+	}
+	if err = c.Mail(e.From); err != nil {
+		return "", err
+	}
+	for _, addr := range to {
+		if err = c.Rcpt(addr); err != nil {
+			return "", err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return "", err
+	}
+	if _, err = w.Write(msg.Bytes()); err != nil {
+		return "", err
+	}
+	if err = w.Close(); err != nil {
+		return "", err
+	}
+	return "", c.Quit()
+}
+
+func (e *EmailNotifier) Edit(id, title, text string) error {
+	return errors.New("不支持编辑消息")
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeNotifier 记录收到的消息, 支持编辑消息
+type fakeNotifier struct {
+	mu       sync.Mutex
+	messages map[string]string // 消息 id 与最新内容
+}
+
+func (n *fakeNotifier) Name() string { return "Fake" }
+
+func (n *fakeNotifier) Send(title, text string) (string, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.messages == nil {
+		n.messages = map[string]string{}
+	}
+	id := fmt.Sprint(len(n.messages) + 1)
+	n.messages[id] = title + " " + text
+	return id, nil
+}
+
+func (n *fakeNotifier) Edit(id, title, text string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if _, ok := n.messages[id]; !ok {
+		return fmt.Errorf("消息 %s 不存在", id)
+	}
+	n.messages[id] = title + " " + text
+	return nil
+}
+
+// count 返回收到的新消息个数
+func (n *fakeNotifier) count() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return len(n.messages)
+}
+
+// TestNotifyConcurrent 在多个 goroutine 中同时发送通知, 配合 go test -race 检查数据竞争
+func TestNotifyConcurrent(t *testing.T) {
+	oldNotifiers := notifiers
+	defer func() { notifiers = oldNotifiers }()
+	a, b := &fakeNotifier{}, &fakeNotifier{}
+	notifiers = []Notifier{a, b}
+
+	const goroutines = 20
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			title := fmt.Sprintf("[acc%d]", i)
+			notify(title, "开始创建")
+			// 同一组消息的后续内容应修改原消息, 而不是发送新消息
+			thread := newNotifyThread(title)
+			thread.send("正在启动")
+			thread.send("启动成功")
+		}(i)
+	}
+	wg.Wait()
+
+	for _, n := range []*fakeNotifier{a, b} {
+		if n.count() != goroutines*2 {
+			t.Errorf("收到 %d 条新消息, 期望 %d 条", n.count(), goroutines*2)
+		}
+	}
+}
+
+// TestEmailNotifierTimeout SMTP 服务器接受连接后无响应时, 发送邮件在超时后返回错误
+func TestEmailNotifierTimeout(t *testing.T) {
+	oldTimeout := emailTimeout
+	defer func() { emailTimeout = oldTimeout }()
+	emailTimeout = 200 * time.Millisecond
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	e := &EmailNotifier{Host: host, To: "to@example.com", From: "from@example.com"}
+	e.Port, _ = strconv.Atoi(port)
+	start := time.Now()
+	if _, err := e.Send("[acc]", "test"); err == nil {
+		t.Error("SMTP 服务器无响应时应返回错误")
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("发送邮件 %s 后才返回", d)
+	}
+}
@@ -2,82 +2,117 @@ package main
 
 import (
 	"context"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"math"
+	"net"
 	"net/http"
 	"net/url"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/oracle/oci-go-sdk/v65/common"
 	"github.com/oracle/oci-go-sdk/v65/core"
 	"github.com/oracle/oci-go-sdk/v65/identity"
 	"github.com/oracle/oci-go-sdk/v65/monitoring"
+	"github.com/oracle/oci-go-sdk/v65/objectstorage"
 	"gopkg.in/ini.v1"
 )
 
-// 全局 OCI 客户端变量
+// 全局 OCI 变量
 var (
-	provider         common.ConfigurationProvider
-	computeClient    core.ComputeClient
-	networkClient    core.VirtualNetworkClient
-	storageClient    core.BlockstorageClient
-	identityClient   identity.IdentityClient
-	monitoringClient monitoring.MonitoringClient
-	ctx              = context.Background()
+	ctx     = context.Background()
+	account *Account // 当前菜单或命令行操作的账号, 同时操作多个账号时应各自使用 newAccount 创建的 Account
 )
 
-// initOCIClient 根据指定的账号配置，初始化所有 OCI 服务客户端
-func initOCIClient(oracleSec *ini.Section) (err error) {
-	oracleSectionName = oracleSec.Name()
-	oracle = Oracle{}
-	err = oracleSec.MapTo(&oracle)
+// Account 账号上下文, 包含账号配置和该账号的 OCI 服务客户端。
+// 每个账号使用各自的 Account, 多个账号或模板可以在不同的 goroutine 中同时创建实例。
+type Account struct {
+	Name       string
+	Oracle     Oracle
+	Compute    core.ComputeClient
+	Network    core.VirtualNetworkClient
+	Storage    core.BlockstorageClient
+	Identity   identity.IdentityClient
+	Monitoring monitoring.MonitoringClient
+	Object     objectstorage.ObjectStorageClient
+}
+
+// newAccount 根据指定的账号配置创建账号上下文, 初始化所有 OCI 服务客户端
+func newAccount(oracleSec *ini.Section) (acc *Account, err error) {
+	acc = &Account{Name: oracleSec.Name()}
+	err = oracleSec.MapTo(&acc.Oracle)
+	if err != nil {
+		printlnErr(fmt.Sprintf("[%s] 解析账号相关参数失败", acc.Name), err.Error())
+		return
+	}
+
+	provider, err := getProvider(acc.Oracle)
 	if err != nil {
-		printlnErr("解析账号相关参数失败", err.Error())
+		printlnErr(fmt.Sprintf("[%s] 获取 Provider 失败", acc.Name), err.Error())
 		return
 	}
 
-	provider, err = getProvider(oracle)
+	acc.Compute, err = core.NewComputeClientWithConfigurationProvider(provider)
 	if err != nil {
-		printlnErr("获取 Provider 失败", err.Error())
+		printlnErr(fmt.Sprintf("[%s] 创建 ComputeClient 失败", acc.Name), err.Error())
 		return
 	}
+	setProxyOrNot(&acc.Compute.BaseClient)
 
-	computeClient, err = core.NewComputeClientWithConfigurationProvider(provider)
+	acc.Network, err = core.NewVirtualNetworkClientWithConfigurationProvider(provider)
 	if err != nil {
-		printlnErr("创建 ComputeClient 失败", err.Error()); return
+		printlnErr(fmt.Sprintf("[%s] 创建 VirtualNetworkClient 失败", acc.Name), err.Error())
+		return
 	}
-	setProxyOrNot(&computeClient.BaseClient)
+	setProxyOrNot(&acc.Network.BaseClient)
 
-	networkClient, err = core.NewVirtualNetworkClientWithConfigurationProvider(provider)
+	acc.Storage, err = core.NewBlockstorageClientWithConfigurationProvider(provider)
 	if err != nil {
-		printlnErr("创建 VirtualNetworkClient 失败", err.Error()); return
+		printlnErr(fmt.Sprintf("[%s] 创建 BlockstorageClient 失败", acc.Name), err.Error())
+		return
 	}
-	setProxyOrNot(&networkClient.BaseClient)
+	setProxyOrNot(&acc.Storage.BaseClient)
 
-	storageClient, err = core.NewBlockstorageClientWithConfigurationProvider(provider)
+	acc.Identity, err = identity.NewIdentityClientWithConfigurationProvider(provider)
 	if err != nil {
-		printlnErr("创建 BlockstorageClient 失败", err.Error()); return
+		printlnErr(fmt.Sprintf("[%s] 创建 IdentityClient 失败", acc.Name), err.Error())
+		return
 	}
-	setProxyOrNot(&storageClient.BaseClient)
+	setProxyOrNot(&acc.Identity.BaseClient)
 
-	identityClient, err = identity.NewIdentityClientWithConfigurationProvider(provider)
+	acc.Monitoring, err = monitoring.NewMonitoringClientWithConfigurationProvider(provider)
 	if err != nil {
-		printlnErr("创建 IdentityClient 失败", err.Error()); return
+		printlnErr(fmt.Sprintf("[%s] 创建 MonitoringClient 失败", acc.Name), err.Error())
+		return
 	}
-	setProxyOrNot(&identityClient.BaseClient)
+	setProxyOrNot(&acc.Monitoring.BaseClient)
 
-	monitoringClient, err = monitoring.NewMonitoringClientWithConfigurationProvider(provider)
+	acc.Object, err = objectstorage.NewObjectStorageClientWithConfigurationProvider(provider)
 	if err != nil {
-		printlnErr("创建 MonitoringClient 失败", err.Error()); return
+		printlnErr(fmt.Sprintf("[%s] 创建 ObjectStorageClient 失败", acc.Name), err.Error())
+		return
 	}
-	setProxyOrNot(&monitoringClient.BaseClient)
+	setProxyOrNot(&acc.Object.BaseClient)
 
 	return
 }
 
+// initOCIClient 根据指定的账号配置，初始化所有 OCI 服务客户端并设置为当前账号
+func initOCIClient(oracleSec *ini.Section) (err error) {
+	acc, err := newAccount(oracleSec)
+	if err != nil {
+		return
+	}
+	account = acc
+	return
+}
+
 func getProvider(o Oracle) (common.ConfigurationProvider, error) {
 	content, err := ioutil.ReadFile(o.Key_file)
 	if err != nil {
@@ -105,34 +140,34 @@ func setProxyOrNot(client *common.BaseClient) {
 
 // --- IAM (管理员) 功能 ---
 
-func ListUsers() ([]identity.User, error) {
+func ListUsers(acc *Account) ([]identity.User, error) {
 	req := identity.ListUsersRequest{
-		CompartmentId:   &oracle.Tenancy,
+		CompartmentId:   &acc.Oracle.Tenancy,
 		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
 	}
-	resp, err := identityClient.ListUsers(ctx, req)
+	resp, err := acc.Identity.ListUsers(ctx, req)
 	return resp.Items, err
 }
 
-func CreateUser(name, description, email string) (identity.User, error) {
+func CreateUser(acc *Account, name, description, email string) (identity.User, error) {
 	req := identity.CreateUserRequest{
 		CreateUserDetails: identity.CreateUserDetails{
-			CompartmentId: &oracle.Tenancy,
+			CompartmentId: &acc.Oracle.Tenancy,
 			Name:          &name,
 			Description:   &description,
 			Email:         &email,
 		},
 	}
-	resp, err := identityClient.CreateUser(ctx, req)
+	resp, err := acc.Identity.CreateUser(ctx, req)
 	return resp.User, err
 }
 
-func AddUserToAdminGroup(userId *string) error {
+func AddUserToAdminGroup(acc *Account, userId *string) error {
 	listGroupsReq := identity.ListGroupsRequest{
-		CompartmentId: &oracle.Tenancy,
+		CompartmentId: &acc.Oracle.Tenancy,
 		Name:          common.String("Administrators"),
 	}
-	listGroupsResp, err := identityClient.ListGroups(ctx, listGroupsReq)
+	listGroupsResp, err := acc.Identity.ListGroups(ctx, listGroupsReq)
 	if err != nil || len(listGroupsResp.Items) == 0 {
 		return fmt.Errorf("找不到 Administrators 组: %v", err)
 	}
@@ -144,27 +179,27 @@ func AddUserToAdminGroup(userId *string) error {
 			GroupId: adminGroup.Id,
 		},
 	}
-	_, err = identityClient.AddUserToGroup(ctx, addUserReq)
+	_, err = acc.Identity.AddUserToGroup(ctx, addUserReq)
 	return err
 }
 
-func DeleteUser(userId *string) error {
+func DeleteUser(acc *Account, userId *string) error {
 	req := identity.DeleteUserRequest{
 		UserId: userId,
 	}
-	_, err := identityClient.DeleteUser(ctx, req)
+	_, err := acc.Identity.DeleteUser(ctx, req)
 	return err
 }
 
-func GetUser(userId *string) (identity.User, error) {
+func GetUser(acc *Account, userId *string) (identity.User, error) {
 	req := identity.GetUserRequest{
 		UserId: userId,
 	}
-	resp, err := identityClient.GetUser(ctx, req)
+	resp, err := acc.Identity.GetUser(ctx, req)
 	return resp.User, err
 }
 
-func UpdateUser(userId *string, description, email *string) (identity.User, error) {
+func UpdateUser(acc *Account, userId *string, description, email *string) (identity.User, error) {
 	req := identity.UpdateUserRequest{
 		UserId: userId,
 		UpdateUserDetails: identity.UpdateUserDetails{
@@ -172,15 +207,15 @@ func UpdateUser(userId *string, description, email *string) (identity.User, erro
 			Email:       email,
 		},
 	}
-	resp, err := identityClient.UpdateUser(ctx, req)
+	resp, err := acc.Identity.UpdateUser(ctx, req)
 	return resp.User, err
 }
 
-func ResetMFA(userId *string) error {
+func ResetMFA(acc *Account, userId *string) error {
 	listMfaDevicesReq := identity.ListMfaTotpDevicesRequest{
 		UserId: userId,
 	}
-	resp, err := identityClient.ListMfaTotpDevices(ctx, listMfaDevicesReq)
+	resp, err := acc.Identity.ListMfaTotpDevices(ctx, listMfaDevicesReq)
 	if err != nil {
 		return fmt.Errorf("获取MFA设备列表失败: %v", err)
 	}
@@ -190,7 +225,7 @@ func ResetMFA(userId *string) error {
 			UserId:          userId,
 			MfaTotpDeviceId: device.Id,
 		}
-		_, err := identityClient.DeleteMfaTotpDevice(ctx, deleteReq)
+		_, err := acc.Identity.DeleteMfaTotpDevice(ctx, deleteReq)
 		if err != nil {
 			fmt.Printf("警告: 删除MFA设备 %s 失败: %v\n", *device.Id, err)
 		}
@@ -200,49 +235,258 @@ func ResetMFA(userId *string) error {
 
 // --- IPv6 和网络功能 ---
 
-func GetSubnet(subnetId *string) (core.Subnet, error) {
+func GetSubnet(acc *Account, subnetId *string) (core.Subnet, error) {
 	req := core.GetSubnetRequest{
 		SubnetId: subnetId,
 	}
-	resp, err := networkClient.GetSubnet(ctx, req)
+	resp, err := acc.Network.GetSubnet(ctx, req)
 	return resp.Subnet, err
 }
 
-func ListIpv6s(vnicId *string) ([]core.Ipv6, error) {
+func ListIpv6s(acc *Account, vnicId *string) ([]core.Ipv6, error) {
 	req := core.ListIpv6sRequest{
 		VnicId: vnicId,
 	}
-	resp, err := networkClient.ListIpv6s(ctx, req)
+	resp, err := acc.Network.ListIpv6s(ctx, req)
 	return resp.Items, err
 }
 
-func AddIpv6(vnicId *string) (core.Ipv6, error) {
+func AddIpv6(acc *Account, vnicId *string) (core.Ipv6, error) {
 	req := core.CreateIpv6Request{
 		CreateIpv6Details: core.CreateIpv6Details{
 			VnicId: vnicId,
 		},
 	}
-	resp, err := networkClient.CreateIpv6(ctx, req)
+	resp, err := acc.Network.CreateIpv6(ctx, req)
 	return resp.Ipv6, err
 }
 
-func DeleteIpv6(ipv6Id *string) error {
+func DeleteIpv6(acc *Account, ipv6Id *string) error {
 	req := core.DeleteIpv6Request{
 		Ipv6Id: ipv6Id,
 	}
-	_, err := networkClient.DeleteIpv6(ctx, req)
+	_, err := acc.Network.DeleteIpv6(ctx, req)
 	return err
 }
 
-func GetSecurityList(securityListId *string) (core.SecurityList, error) {
+// --- 启用 IPv6 ---
+
+func getVcn(acc *Account, vcnId *string) (core.Vcn, error) {
+	req := core.GetVcnRequest{
+		VcnId:           vcnId,
+		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
+	}
+	resp, err := acc.Network.GetVcn(ctx, req)
+	return resp.Vcn, err
+}
+
+func getRouteTable(acc *Account, rtId *string) (core.RouteTable, error) {
+	req := core.GetRouteTableRequest{
+		RtId:            rtId,
+		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
+	}
+	resp, err := acc.Network.GetRouteTable(ctx, req)
+	return resp.RouteTable, err
+}
+
+// subnetIpv6Cidrs 返回子网的所有 IPv6 前缀
+func subnetIpv6Cidrs(subnet core.Subnet) []string {
+	cidrs := append([]string{}, subnet.Ipv6CidrBlocks...)
+	if subnet.Ipv6CidrBlock != nil && !containsString(cidrs, *subnet.Ipv6CidrBlock) {
+		cidrs = append(cidrs, *subnet.Ipv6CidrBlock)
+	}
+	return cidrs
+}
+
+// nextIpv6SubnetCidr 从 VCN 的 IPv6 前缀中分配一个未被其他子网使用的 /64
+func nextIpv6SubnetCidr(vcnCidr string, used []string) (string, error) {
+	_, prefix, err := net.ParseCIDR(vcnCidr)
+	if err != nil {
+		return "", err
+	}
+	ones, _ := prefix.Mask.Size()
+	if ones > 64 {
+		return "", fmt.Errorf("VCN 的 IPv6 前缀 %s 小于 /64", vcnCidr)
+	}
+	count := uint64(1) << uint(64-ones)
+	if count > 256 {
+		count = 256
+	}
+	base := binary.BigEndian.Uint64(prefix.IP[:8])
+	for i := uint64(0); i < count; i++ {
+		ip := make(net.IP, net.IPv6len)
+		binary.BigEndian.PutUint64(ip[:8], base+i)
+		cidr := (&net.IPNet{IP: ip, Mask: net.CIDRMask(64, 128)}).String()
+		if !containsString(used, cidr) {
+			return cidr, nil
+		}
+	}
+	return "", fmt.Errorf("VCN 的 IPv6 前缀 %s 中没有可用的 /64", vcnCidr)
+}
+
+// enableIpv6 为子网启用 IPv6: 为 VCN 分配甲骨文的 IPv6 GUA 前缀, 为子网分配 /64,
+// 在路由表中添加指向 Internet 网关的 ::/0 路由, 并在安全列表中添加 IPv6 规则。已完成的步骤会跳过
+func enableIpv6(acc *Account, subnet core.Subnet) (core.Subnet, error) {
+	vcn, err := getVcn(acc, subnet.VcnId)
+	if err != nil {
+		return subnet, fmt.Errorf("获取VCN失败: %v", err)
+	}
+	if len(vcn.Ipv6CidrBlocks) == 0 {
+		printf("[%s] 正在为VCN %s 分配 IPv6 前缀...\n", acc.Name, *vcn.DisplayName)
+		req := core.AddIpv6VcnCidrRequest{
+			VcnId:                 vcn.Id,
+			AddVcnIpv6CidrDetails: core.AddVcnIpv6CidrDetails{IsOracleGuaAllocationEnabled: common.Bool(true)},
+			RequestMetadata:       getCustomRequestMetadataWithRetryPolicy(),
+		}
+		_, err = acc.Network.AddIpv6VcnCidr(ctx, req)
+		if err != nil {
+			return subnet, fmt.Errorf("为VCN分配 IPv6 前缀失败: %v", err)
+		}
+		for i := 0; i < 60 && len(vcn.Ipv6CidrBlocks) == 0; i++ {
+			time.Sleep(2 * time.Second)
+			vcn, err = getVcn(acc, subnet.VcnId)
+			if err != nil {
+				return subnet, fmt.Errorf("获取VCN失败: %v", err)
+			}
+		}
+		if len(vcn.Ipv6CidrBlocks) == 0 {
+			return subnet, errors.New("等待VCN分配 IPv6 前缀超时")
+		}
+		printf("[%s] VCN IPv6 前缀: %s\n", acc.Name, strings.Join(vcn.Ipv6CidrBlocks, ", "))
+	}
+
+	if len(subnetIpv6Cidrs(subnet)) == 0 {
+		subnets, err := listSubnets(acc, vcn.Id)
+		if err != nil {
+			return subnet, fmt.Errorf("获取子网失败: %v", err)
+		}
+		var used []string
+		for _, s := range subnets {
+			used = append(used, subnetIpv6Cidrs(s)...)
+		}
+		cidr, err := nextIpv6SubnetCidr(vcn.Ipv6CidrBlocks[0], used)
+		if err != nil {
+			return subnet, err
+		}
+		printf("[%s] 正在为子网 %s 分配 IPv6 前缀 %s...\n", acc.Name, *subnet.DisplayName, cidr)
+		req := core.AddIpv6SubnetCidrRequest{
+			SubnetId:                 subnet.Id,
+			AddSubnetIpv6CidrDetails: core.AddSubnetIpv6CidrDetails{Ipv6CidrBlock: common.String(cidr)},
+			RequestMetadata:          getCustomRequestMetadataWithRetryPolicy(),
+		}
+		_, err = acc.Network.AddIpv6SubnetCidr(ctx, req)
+		if err != nil {
+			return subnet, fmt.Errorf("为子网分配 IPv6 前缀失败: %v", err)
+		}
+		for i := 0; i < 60 && len(subnetIpv6Cidrs(subnet)) == 0; i++ {
+			time.Sleep(2 * time.Second)
+			subnet, err = GetSubnet(acc, subnet.Id)
+			if err != nil {
+				return subnet, fmt.Errorf("获取子网失败: %v", err)
+			}
+		}
+		if len(subnetIpv6Cidrs(subnet)) == 0 {
+			return subnet, errors.New("等待子网分配 IPv6 前缀超时")
+		}
+	}
+
+	err = addIpv6Route(acc, subnet)
+	if err != nil {
+		return subnet, err
+	}
+	for _, securityListId := range subnet.SecurityListIds {
+		err = addIpv6SecurityRules(acc, common.String(securityListId))
+		if err != nil {
+			return subnet, err
+		}
+	}
+	return subnet, nil
+}
+
+// addIpv6Route 在子网的路由表中添加指向 Internet 网关的 ::/0 路由
+func addIpv6Route(acc *Account, subnet core.Subnet) error {
+	rt, err := getRouteTable(acc, subnet.RouteTableId)
+	if err != nil {
+		return fmt.Errorf("获取路由表失败: %v", err)
+	}
+	for _, rule := range rt.RouteRules {
+		if stringValue(rule.Destination) == "::/0" {
+			return nil
+		}
+	}
+	gateway, err := createOrGetInternetGateway(acc, subnet.VcnId)
+	if err != nil {
+		return fmt.Errorf("获取Internet网关失败: %v", err)
+	}
+	rules := append(rt.RouteRules, core.RouteRule{
+		NetworkEntityId: gateway.Id,
+		Destination:     common.String("::/0"),
+		DestinationType: core.RouteRuleDestinationTypeCidrBlock,
+	})
+	req := core.UpdateRouteTableRequest{
+		RtId:                    rt.Id,
+		UpdateRouteTableDetails: core.UpdateRouteTableDetails{RouteRules: rules},
+		RequestMetadata:         getCustomRequestMetadataWithRetryPolicy(),
+	}
+	_, err = acc.Network.UpdateRouteTable(ctx, req)
+	if err != nil {
+		return fmt.Errorf("添加 IPv6 路由规则失败: %v", err)
+	}
+	printf("[%s] IPv6 路由规则添加成功\n", acc.Name)
+	return nil
+}
+
+// addIpv6SecurityRules 在安全列表中添加允许所有 IPv6 出站流量的规则,
+// 并为每条来源为 0.0.0.0/0 的入站规则添加来源为 ::/0 的相同规则 (ICMP 替换为 ICMPv6)
+func addIpv6SecurityRules(acc *Account, securityListId *string) error {
+	sl, etag, err := GetSecurityList(acc, securityListId)
+	if err != nil {
+		return fmt.Errorf("获取安全列表失败: %v", err)
+	}
+	ingress := append([]core.IngressSecurityRule{}, sl.IngressSecurityRules...)
+	for _, r := range sl.IngressSecurityRules {
+		if stringValue(r.Source) != "0.0.0.0/0" {
+			continue
+		}
+		r.Source = common.String("::/0")
+		if stringValue(r.Protocol) == protocolICMP {
+			r.Protocol = common.String(protocolICMPv6)
+			r.IcmpOptions = nil
+		}
+		ingress = appendIngressRule(ingress, r)
+	}
+	egress := append([]core.EgressSecurityRule{}, sl.EgressSecurityRules...)
+	hasEgress := false
+	for _, r := range egress {
+		if stringValue(r.Destination) == "::/0" {
+			hasEgress = true
+		}
+	}
+	if !hasEgress {
+		egress = append(egress, ruleOptions{Protocol: protocolAll}.egress("::/0"))
+	}
+	if len(ingress) == len(sl.IngressSecurityRules) && len(egress) == len(sl.EgressSecurityRules) {
+		return nil
+	}
+	_, err = UpdateSecurityList(acc, securityListId, etag, ingress, egress)
+	if err != nil {
+		return fmt.Errorf("添加 IPv6 安全规则失败: %v", err)
+	}
+	printf("[%s] 已在安全列表 %s 中添加 IPv6 规则\n", acc.Name, stringValue(sl.DisplayName))
+	return nil
+}
+
+// GetSecurityList 获取安全列表和它的 etag, 修改时传给 UpdateSecurityList 以免覆盖其他人同时做的修改
+func GetSecurityList(acc *Account, securityListId *string) (core.SecurityList, string, error) {
 	req := core.GetSecurityListRequest{
 		SecurityListId: securityListId,
 	}
-	resp, err := networkClient.GetSecurityList(ctx, req)
-	return resp.SecurityList, err
+	resp, err := acc.Network.GetSecurityList(ctx, req)
+	return resp.SecurityList, stringValue(resp.Etag), err
 }
 
-func UpdateSecurityList(securityListId *string, ingressRules []core.IngressSecurityRule, egressRules []core.EgressSecurityRule) (core.SecurityList, error) {
+// UpdateSecurityList 更新安全列表的规则。etag 不为空时, 安全列表在获取后被修改过则更新失败
+func UpdateSecurityList(acc *Account, securityListId *string, etag string, ingressRules []core.IngressSecurityRule, egressRules []core.EgressSecurityRule) (core.SecurityList, error) {
 	req := core.UpdateSecurityListRequest{
 		SecurityListId: securityListId,
 		UpdateSecurityListDetails: core.UpdateSecurityListDetails{
@@ -250,23 +494,193 @@ func UpdateSecurityList(securityListId *string, ingressRules []core.IngressSecur
 			EgressSecurityRules:  egressRules,
 		},
 	}
-	resp, err := networkClient.UpdateSecurityList(ctx, req)
+	if etag != "" {
+		req.IfMatch = common.String(etag)
+	}
+	resp, err := acc.Network.UpdateSecurityList(ctx, req)
+	if servErr, ok := common.IsServiceError(err); ok && servErr.GetHTTPStatusCode() == http.StatusPreconditionFailed {
+		return resp.SecurityList, fmt.Errorf("安全列表已被其他操作修改, 请重新获取后再试: %v", err)
+	}
 	return resp.SecurityList, err
 }
 
+// applySecurityPresetToList 将预设规则应用到安全列表的入站规则。
+// preset 为空时不修改, 保留甲骨文的默认规则 (仅开放 SSH 和必要的 ICMP), 允许所有入站流量需要显式指定 allow-all
+func applySecurityPresetToList(acc *Account, securityListId *string, preset string) error {
+	if preset == "" || preset == presetNone {
+		return nil
+	}
+	var myIp string
+	if preset == presetSSHMyIp {
+		var err error
+		myIp, err = getMyPublicIp()
+		if err != nil {
+			return fmt.Errorf("获取本机公共IP失败: %v", err)
+		}
+	}
+	sl, etag, err := GetSecurityList(acc, securityListId)
+	if err != nil {
+		return err
+	}
+	vcn, err := getVcn(acc, sl.VcnId)
+	if err != nil {
+		return fmt.Errorf("获取 VCN 失败: %v", err)
+	}
+	ingressRules, err := applySecurityPreset(preset, sl.IngressSecurityRules, myIp, vcn.CidrBlocks)
+	if err != nil {
+		return err
+	}
+	_, err = UpdateSecurityList(acc, securityListId, etag, ingressRules, sl.EgressSecurityRules)
+	if err == nil {
+		printf("[%s] 已将预设规则 %s 应用到安全列表 %s\n", acc.Name, preset, stringValue(sl.DisplayName))
+	}
+	return err
+}
+
+// --- 网络安全组 ---
+
+// listNetworkSecurityGroups 列出网络安全组, vcnId 为 nil 时列出所有 VCN 中的网络安全组
+func listNetworkSecurityGroups(acc *Account, vcnId *string) (nsgs []core.NetworkSecurityGroup, err error) {
+	req := core.ListNetworkSecurityGroupsRequest{
+		CompartmentId:   common.String(acc.Oracle.Tenancy),
+		VcnId:           vcnId,
+		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
+	}
+	for {
+		resp, err := acc.Network.ListNetworkSecurityGroups(ctx, req)
+		if err != nil {
+			return nsgs, err
+		}
+		nsgs = append(nsgs, resp.Items...)
+		if resp.OpcNextPage == nil {
+			return nsgs, nil
+		}
+		req.Page = resp.OpcNextPage
+	}
+}
+
+func createNetworkSecurityGroup(acc *Account, vcnId *string, displayName string) (core.NetworkSecurityGroup, error) {
+	req := core.CreateNetworkSecurityGroupRequest{
+		CreateNetworkSecurityGroupDetails: core.CreateNetworkSecurityGroupDetails{
+			CompartmentId: common.String(acc.Oracle.Tenancy),
+			VcnId:         vcnId,
+			DisplayName:   common.String(displayName),
+		},
+		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
+	}
+	resp, err := acc.Network.CreateNetworkSecurityGroup(ctx, req)
+	return resp.NetworkSecurityGroup, err
+}
+
+func deleteNetworkSecurityGroup(acc *Account, nsgId *string) error {
+	req := core.DeleteNetworkSecurityGroupRequest{
+		NetworkSecurityGroupId: nsgId,
+		RequestMetadata:        getCustomRequestMetadataWithRetryPolicy(),
+	}
+	_, err := acc.Network.DeleteNetworkSecurityGroup(ctx, req)
+	return err
+}
+
+func listNsgSecurityRules(acc *Account, nsgId *string) (rules []core.SecurityRule, err error) {
+	req := core.ListNetworkSecurityGroupSecurityRulesRequest{
+		NetworkSecurityGroupId: nsgId,
+		RequestMetadata:        getCustomRequestMetadataWithRetryPolicy(),
+	}
+	for {
+		resp, err := acc.Network.ListNetworkSecurityGroupSecurityRules(ctx, req)
+		if err != nil {
+			return rules, err
+		}
+		rules = append(rules, resp.Items...)
+		if resp.OpcNextPage == nil {
+			return rules, nil
+		}
+		req.Page = resp.OpcNextPage
+	}
+}
+
+func addNsgSecurityRules(acc *Account, nsgId *string, rules []core.AddSecurityRuleDetails) error {
+	req := core.AddNetworkSecurityGroupSecurityRulesRequest{
+		NetworkSecurityGroupId: nsgId,
+		AddNetworkSecurityGroupSecurityRulesDetails: core.AddNetworkSecurityGroupSecurityRulesDetails{
+			SecurityRules: rules,
+		},
+		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
+	}
+	_, err := acc.Network.AddNetworkSecurityGroupSecurityRules(ctx, req)
+	return err
+}
+
+func removeNsgSecurityRules(acc *Account, nsgId *string, ruleIds []string) error {
+	req := core.RemoveNetworkSecurityGroupSecurityRulesRequest{
+		NetworkSecurityGroupId: nsgId,
+		RemoveNetworkSecurityGroupSecurityRulesDetails: core.RemoveNetworkSecurityGroupSecurityRulesDetails{
+			SecurityRuleIds: ruleIds,
+		},
+		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
+	}
+	_, err := acc.Network.RemoveNetworkSecurityGroupSecurityRules(ctx, req)
+	return err
+}
+
+// updateVnicNsgs 设置网卡关联的网络安全组, nsgIds 为空时解除所有关联
+func updateVnicNsgs(acc *Account, vnicId *string, nsgIds []string) (core.Vnic, error) {
+	if nsgIds == nil {
+		nsgIds = []string{}
+	}
+	req := core.UpdateVnicRequest{
+		VnicId:            vnicId,
+		UpdateVnicDetails: core.UpdateVnicDetails{NsgIds: nsgIds},
+		RequestMetadata:   getCustomRequestMetadataWithRetryPolicy(),
+	}
+	resp, err := acc.Network.UpdateVnic(ctx, req)
+	return resp.Vnic, err
+}
+
+// getNsgIdsByNames 按名称查找 VCN 中的网络安全组, names 为逗号分隔的名称列表
+func getNsgIdsByNames(acc *Account, vcnId *string, names string) (ids []string, err error) {
+	var wanted []string
+	for _, name := range strings.Split(names, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			wanted = append(wanted, name)
+		}
+	}
+	if len(wanted) == 0 {
+		return nil, nil
+	}
+	nsgs, err := listNetworkSecurityGroups(acc, vcnId)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range wanted {
+		var id *string
+		for _, nsg := range nsgs {
+			if stringValue(nsg.DisplayName) == name && nsg.LifecycleState == core.NetworkSecurityGroupLifecycleStateAvailable {
+				id = nsg.Id
+				break
+			}
+		}
+		if id == nil {
+			return nil, fmt.Errorf("未找到网络安全组 %s", name)
+		}
+		ids = append(ids, *id)
+	}
+	return ids, nil
+}
+
 // --- 监控功能 ---
 
-func GetInstanceNetworkMetrics(instanceId, startTime, endTime string) (float64, float64, error) {
+func GetInstanceNetworkMetrics(acc *Account, instanceId, startTime, endTime string) (float64, float64, error) {
 	namespace := "oci_computeagent"
 	queryIn := fmt.Sprintf("NetworkBytesIn[1m]{resourceId = \"%s\"}.sum()", instanceId)
-	respIn, err := queryMetrics(namespace, queryIn, startTime, endTime)
+	respIn, err := queryMetrics(acc, namespace, queryIn, startTime, endTime, "")
 	if err != nil {
 		return 0, 0, fmt.Errorf("查询流入流量失败: %v", err)
 	}
 	bytesIn := aggregateMetricData(respIn.Items)
 
 	queryOut := fmt.Sprintf("NetworkBytesOut[1m]{resourceId = \"%s\"}.sum()", instanceId)
-	respOut, err := queryMetrics(namespace, queryOut, startTime, endTime)
+	respOut, err := queryMetrics(acc, namespace, queryOut, startTime, endTime, "")
 	if err != nil {
 		return 0, 0, fmt.Errorf("查询流出流量失败: %v", err)
 	}
@@ -275,9 +689,42 @@ func GetInstanceNetworkMetrics(instanceId, startTime, endTime string) (float64,
 	return bytesIn, bytesOut, nil
 }
 
-func queryMetrics(namespace, query, startTime, endTime string) (monitoring.SummarizeMetricsDataResponse, error) {
+// getEgressByInstance 统计 startTime 至 endTime 期间账号下各实例的出站流量 (字节), key 为实例 OCID
+func getEgressByInstance(acc *Account, startTime, endTime time.Time) (map[string]float64, error) {
+	query := "NetworkBytesOut[1h].groupBy(resourceId).sum()"
+	resp, err := queryMetrics(acc, "oci_computeagent", query, startTime.Format(time.RFC3339), endTime.Format(time.RFC3339), "1h")
+	if err != nil {
+		return nil, fmt.Errorf("查询流出流量失败: %v", err)
+	}
+	egress := map[string]float64{}
+	for _, item := range resp.Items {
+		id := item.Dimensions["resourceId"]
+		if id == "" {
+			continue
+		}
+		egress[id] += aggregateMetricData([]monitoring.MetricData{item})
+	}
+	return egress, nil
+}
+
+// getInstanceMetricSeries 查询实例的监控指标时间序列, 多个数据流 (如多块磁盘、多个网卡) 合并为一条
+func getInstanceMetricSeries(acc *Account, instanceId, metric, statistic, interval string, startTime, endTime time.Time) ([]monitoring.AggregatedDatapoint, error) {
+	query := fmt.Sprintf("%s[%s]{resourceId = \"%s\"}.grouping().%s()", metric, interval, instanceId, statistic)
+	resp, err := queryMetrics(acc, "oci_computeagent", query, startTime.Format(time.RFC3339), endTime.Format(time.RFC3339), interval)
+	if err != nil {
+		return nil, err
+	}
+	var datapoints []monitoring.AggregatedDatapoint
+	for _, item := range resp.Items {
+		datapoints = append(datapoints, item.AggregatedDatapoints...)
+	}
+	return datapoints, nil
+}
+
+// queryMetrics 查询监控数据, resolution 为空时使用默认的聚合间隔
+func queryMetrics(acc *Account, namespace, query, startTime, endTime, resolution string) (monitoring.SummarizeMetricsDataResponse, error) {
 	req := monitoring.SummarizeMetricsDataRequest{
-		CompartmentId: &oracle.Tenancy,
+		CompartmentId: &acc.Oracle.Tenancy,
 		SummarizeMetricsDataDetails: monitoring.SummarizeMetricsDataDetails{
 			Namespace: &namespace,
 			Query:     &query,
@@ -285,7 +732,10 @@ func queryMetrics(namespace, query, startTime, endTime string) (monitoring.Summa
 			EndTime:   &common.SDKTime{Time: parseTime(endTime)},
 		},
 	}
-	return monitoringClient.SummarizeMetricsData(ctx, req)
+	if resolution != "" {
+		req.SummarizeMetricsDataDetails.Resolution = &resolution
+	}
+	return acc.Monitoring.SummarizeMetricsData(ctx, req)
 }
 
 func aggregateMetricData(items []monitoring.MetricData) float64 {
@@ -307,11 +757,23 @@ func parseTime(timeStr string) time.Time {
 
 // --- 实例和计算功能 ---
 
-func LaunchInstances(ads []identity.AvailabilityDomain) (sum, num int32) {
+// errRetryLater 表示因网络等临时错误未能执行, 稍后可以重试
+var errRetryLater = errors.New("稍后重试")
+
+// LaunchInstances 在账号 acc 中按实例模板 spec 创建实例, 返回计划创建的个数和成功的个数。
+// 获取镜像、Shape、子网等信息失败时未发起任何创建请求, 返回包装了 errRetryLater 的错误。
+// 不依赖全局的账号和模板配置, 可以在多个 goroutine 中为不同的账号或模板同时调用。
+// stop 被关闭后不再发起新的创建请求, 已发出的请求会正常完成。stop 可以为 nil。
+func LaunchInstances(acc *Account, spec Instance, ads []identity.AvailabilityDomain, stop <-chan struct{}) (sum, num int32, err error) {
 	var adCount int32 = int32(len(ads))
-	adName := common.String(instance.AvailabilityDomain)
-	each := instance.Each
-	sum = instance.Sum
+	tag := fmt.Sprintf("[%s]", acc.Name) // 通知消息中的账号名称
+	adName := common.String(spec.AvailabilityDomain)
+	each := spec.Each
+	sum = spec.Sum
+	if spec.DesiredState && each > 0 {
+		printf("\033[1;33m[%s] 期望状态模式下忽略 each 参数\033[0m\n", acc.Name)
+		each = 0
+	}
 	var usableAds = make([]identity.AvailabilityDomain, 0)
 	var AD_NOT_FIXED bool = false
 	var EACH_AD bool = false
@@ -325,35 +787,108 @@ func LaunchInstances(ads []identity.AvailabilityDomain) (sum, num int32) {
 			usableAds = ads
 		}
 	}
-	name := instance.InstanceDisplayName
+	state := restoreLaunchState(acc, spec, int32(len(ads)))
+	// 上次已创建完所有实例, 只是未来得及删除进度 (如使用引导卷创建的实例已挂载了该引导卷, 不能再查找引导卷)
+	if state != nil && state.Sum > 0 && state.Pos >= state.Sum {
+		printf("\033[1;32m[%s] 模板 %s 上次已执行完毕\033[0m\n", acc.Name, spec.Template)
+		clearLaunchState(acc.Name, spec.Template)
+		return state.Sum, int32(len(state.Created)), nil
+	}
+	// 使用已有的引导卷创建实例: 一个引导卷只能用于一个实例, 且实例只能创建在引导卷所在的可用性域
+	var bootVolume *core.BootVolume
+	if spec.BootVolumeId != "" || spec.BootVolumeDisplayName != "" {
+		printf("[%s] 正在获取引导卷...\n", acc.Name)
+		v, err := getLaunchBootVolume(acc, spec)
+		if err != nil {
+			// 引导卷不存在、有重名或已挂载是配置问题, 重试也不会成功, 只有获取失败时才包装 errRetryLater
+			return 0, 0, fmt.Errorf("获取引导卷失败: %w", err)
+		}
+		bootVolume = &v
+		printf("[%s] 引导卷: %s\n", acc.Name, *v.DisplayName)
+		if sum > 1 || each > 0 {
+			printf("\033[1;33m[%s] 使用已有的引导卷时只能创建 1 个实例, 已忽略 sum 和 each 参数\033[0m\n", acc.Name)
+		}
+		if spec.AvailabilityDomain != "" && spec.AvailabilityDomain != *v.AvailabilityDomain {
+			printf("\033[1;33m[%s] 引导卷位于 %s, 已忽略 availabilityDomain 参数\033[0m\n", acc.Name, *v.AvailabilityDomain)
+		}
+		sum, each = 1, 0
+		adName = v.AvailabilityDomain
+		AD_NOT_FIXED, EACH_AD = false, false
+		usableAds = nil
+	}
+	name := spec.InstanceDisplayName
 	if name == "" {
 		name = time.Now().Format("instance-20060102-1504")
 	}
-	displayName := common.String(name)
-	if sum > 1 {
-		displayName = common.String(name + "-1")
+	if state != nil && state.Name != "" {
+		name = state.Name
+	}
+	// 期望状态模式: 已存在的同配置实例计入 sum, 只创建不足的部分
+	var existing int32
+	// 已被占用的实例名称, 创建多个实例时使用最小的未被占用的序号
+	usedNames := map[string]bool{}
+	if spec.DesiredState {
+		// 未配置实例名称时每次生成的名称都不同, 无法识别已由模板创建的实例
+		if spec.InstanceDisplayName == "" {
+			return 0, 0, errors.New("期望状态模式需要在模板中配置 instanceDisplayName")
+		}
+		instances, err := listAllInstances(acc)
+		if err != nil {
+			return 0, 0, fmt.Errorf("%w: 获取现有实例失败: %v", errRetryLater, err)
+		}
+		existing = countTemplateInstances(instances, spec.Shape, spec.InstanceDisplayName)
+		for _, ins := range templateInstances(instances, spec.Shape, spec.InstanceDisplayName) {
+			usedNames[stringValue(ins.DisplayName)] = true
+		}
+		printf("\033[1;36m[%s] 期望实例个数: %d, 现有同配置实例: %d\033[0m\n", acc.Name, sum, existing)
+		if existing >= sum {
+			printf("\033[1;32m[%s] 现有实例已满足期望个数, 无需创建\033[0m\n", acc.Name)
+			clearLaunchState(acc.Name, spec.Template)
+			return 0, 0, nil
+		}
+		sum -= existing
+		// 已创建的实例已经计入 existing, 进度中只保留尝试次数等信息
+		if state != nil {
+			state.Pos, state.Created = 0, nil
+		}
 	}
 	request := core.LaunchInstanceRequest{}
-	request.CompartmentId = common.String(oracle.Tenancy)
-	request.DisplayName = displayName
-	fmt.Println("正在获取系统镜像...")
-	image, err := GetImage(ctx, computeClient)
-	if err != nil {
-		printlnErr("获取系统镜像失败", err.Error())
-		return
+	request.CompartmentId = common.String(acc.Oracle.Tenancy)
+	request.DisplayName = common.String(name)
+	var imageId *string
+	var bootVolumeSize float64
+	if bootVolume != nil {
+		imageId = bootVolume.ImageId
+		bootVolumeSize = float64(*bootVolume.SizeInGBs)
+		request.SourceDetails = core.InstanceSourceViaBootVolumeDetails{BootVolumeId: bootVolume.Id}
+	} else {
+		printf("[%s] 正在获取系统镜像...\n", acc.Name)
+		image, err := GetImage(acc, spec)
+		if err != nil {
+			return 0, 0, fmt.Errorf("%w: 获取系统镜像失败: %v", errRetryLater, err)
+		}
+		printf("[%s] 系统镜像: %s\n", acc.Name, *image.DisplayName)
+		imageId = image.Id
+		sd := core.InstanceSourceViaImageDetails{}
+		sd.ImageId = image.Id
+		if spec.BootVolumeSizeInGBs > 0 {
+			sd.BootVolumeSizeInGBs = common.Int64(spec.BootVolumeSizeInGBs)
+			bootVolumeSize = float64(spec.BootVolumeSizeInGBs)
+		} else {
+			bootVolumeSize = math.Round(float64(*image.SizeInMBs) / float64(1024))
+		}
+		request.SourceDetails = sd
 	}
-	fmt.Println("系统镜像:", *image.DisplayName)
 	var shape core.Shape
-	if strings.Contains(strings.ToLower(instance.Shape), "flex") && instance.Ocpus > 0 && instance.MemoryInGBs > 0 {
-		shape.Shape = &instance.Shape
-		shape.Ocpus = &instance.Ocpus
-		shape.MemoryInGBs = &instance.MemoryInGBs
+	if strings.Contains(strings.ToLower(spec.Shape), "flex") && spec.Ocpus > 0 && spec.MemoryInGBs > 0 {
+		shape.Shape = &spec.Shape
+		shape.Ocpus = &spec.Ocpus
+		shape.MemoryInGBs = &spec.MemoryInGBs
 	} else {
-		fmt.Println("正在获取Shape信息...")
-		shape, err = getShape(image.Id, instance.Shape)
+		printf("[%s] 正在获取Shape信息...\n", acc.Name)
+		shape, err = getShape(acc, imageId, spec.Shape)
 		if err != nil {
-			printlnErr("获取Shape信息失败", err.Error())
-			return
+			return 0, 0, fmt.Errorf("%w: 获取Shape信息失败: %v", errRetryLater, err)
 		}
 	}
 	request.Shape = shape.Shape
@@ -362,62 +897,171 @@ func LaunchInstances(ads []identity.AvailabilityDomain) (sum, num int32) {
 			Ocpus:       shape.Ocpus,
 			MemoryInGBs: shape.MemoryInGBs,
 		}
-		if instance.Burstable == "1/8" {
+		if spec.Burstable == "1/8" {
 			request.ShapeConfig.BaselineOcpuUtilization = core.LaunchInstanceShapeConfigDetailsBaselineOcpuUtilization8
-		} else if instance.Burstable == "1/2" {
+		} else if spec.Burstable == "1/2" {
 			request.ShapeConfig.BaselineOcpuUtilization = core.LaunchInstanceShapeConfigDetailsBaselineOcpuUtilization2
 		}
 	}
-	fmt.Println("正在获取子网...")
-	subnet, err := CreateOrGetNetworkInfrastructure(ctx, networkClient)
+	printf("[%s] 正在获取子网...\n", acc.Name)
+	subnet, err := CreateOrGetNetworkInfrastructure(acc, spec)
 	if err != nil {
-		printlnErr("获取子网失败", err.Error())
-		return
+		return 0, 0, fmt.Errorf("%w: 获取子网失败: %v", errRetryLater, err)
 	}
-	fmt.Println("子网:", *subnet.DisplayName)
+	printf("[%s] 子网: %s\n", acc.Name, *subnet.DisplayName)
 	request.CreateVnicDetails = &core.CreateVnicDetails{SubnetId: subnet.Id}
-	sd := core.InstanceSourceViaImageDetails{}
-	sd.ImageId = image.Id
-	if instance.BootVolumeSizeInGBs > 0 {
-		sd.BootVolumeSizeInGBs = common.Int64(instance.BootVolumeSizeInGBs)
+	if spec.AssignIpv6 {
+		request.CreateVnicDetails.AssignIpv6Ip = common.Bool(true)
+	}
+	if spec.NsgDisplayNames != "" {
+		request.CreateVnicDetails.NsgIds, err = getNsgIdsByNames(acc, subnet.VcnId, spec.NsgDisplayNames)
+		if err != nil {
+			return 0, 0, fmt.Errorf("%w: 获取网络安全组失败: %v", errRetryLater, err)
+		}
 	}
-	request.SourceDetails = sd
 	request.IsPvEncryptionInTransitEnabled = common.Bool(true)
 	metaData := map[string]string{}
-	metaData["ssh_authorized_keys"] = instance.SSH_Public_Key
-	if instance.CloudInit != "" {
-		metaData["user_data"] = instance.CloudInit
+	metaData["ssh_authorized_keys"] = spec.SSH_Public_Key
+	if spec.CloudInit != "" {
+		metaData["user_data"] = spec.CloudInit
 	}
 	request.Metadata = metaData
-	minTime := instance.MinTime
-	maxTime := instance.MaxTime
+	minTime := spec.MinTime
+	maxTime := spec.MaxTime
 	SKIP_RETRY_MAP := make(map[int32]bool)
 	var usableAdsTemp = make([]identity.AvailabilityDomain, 0)
-	retry := instance.Retry
+	retry := spec.Retry
 	var failTimes int32 = 0
 	var runTimes int32 = 0
 	var adIndex int32 = 0
 	var pos int32 = 0
 	var SUCCESS = false
 	var startTime = time.Now()
-	var bootVolumeSize float64
-	if instance.BootVolumeSizeInGBs > 0 {
-		bootVolumeSize = float64(instance.BootVolumeSizeInGBs)
+	if state != nil {
+		pos = state.Pos
+		num = int32(len(state.Created))
+		runTimes = state.RunTimes
+		failTimes = state.FailTimes
+		startTime = state.StartTime
+		adIndex = state.AdIndex
+		if adIndex < 0 || adIndex >= int32(len(ads)) {
+			adIndex = 0
+		}
+		if AD_NOT_FIXED && EACH_AD && state.AdName != "" {
+			adName = common.String(state.AdName)
+		}
+		if AD_NOT_FIXED && !EACH_AD {
+			for _, ad := range ads {
+				if !containsString(state.ExcludedAds, *ad.Name) {
+					usableAdsTemp = append(usableAdsTemp, ad)
+				}
+			}
+			if len(usableAdsTemp) > 0 {
+				usableAds = usableAdsTemp
+				adCount = int32(len(usableAds))
+				for index, ad := range usableAds {
+					if skip, ok := state.SkipAds[*ad.Name]; ok {
+						SKIP_RETRY_MAP[int32(index)] = skip
+					}
+				}
+			}
+			usableAdsTemp = nil
+		}
 	} else {
-		bootVolumeSize = math.Round(float64(*image.SizeInMBs) / float64(1024))
+		state = &LaunchState{Account: acc.Name, Template: spec.Template, Name: name, Fingerprint: launchFingerprint(spec), AdCount: int32(len(ads))}
 	}
-	printf("\033[1;36m[%s] 开始创建 %s 实例, OCPU: %g 内存: %g 引导卷: %g \033[0m\n", oracleSectionName, *shape.Shape, *shape.Ocpus, *shape.MemoryInGBs, bootVolumeSize)
-	if EACH {
-		text := fmt.Sprintf("正在尝试创建第 %d 个实例...⏳\n区域: %s\n实例配置: %s\nOCPU计数: %g\n内存(GB): %g\n引导卷(GB): %g\n创建个数: %d", pos+1, oracle.Region, *shape.Shape, *shape.Ocpus, *shape.MemoryInGBs, bootVolumeSize, sum)
-		_, err := sendMessage("", text)
+	state.Sum = sum
+	for _, c := range state.Created {
+		usedNames[c.DisplayName] = true
+	}
+	// 检查剩余待创建的实例是否会超出免费额度
+	var ocpus, memoryInGBs float32
+	if shape.Ocpus != nil {
+		ocpus = *shape.Ocpus
+	}
+	if shape.MemoryInGBs != nil {
+		memoryInGBs = *shape.MemoryInGBs
+	}
+	// 已有的引导卷已经计入用量, 不再占用新的存储额度
+	newBootVolumeSize := int64(bootVolumeSize)
+	if bootVolume != nil {
+		newBootVolumeSize = 0
+	}
+	violations, reservation, err := checkFreeTierQuota(acc, *shape.Shape, ocpus, memoryInGBs, newBootVolumeSize, sum-pos)
+	defer reservation.release()
+	if err != nil {
+		printlnErr(fmt.Sprintf("[%s] 检查免费额度失败", acc.Name), err.Error())
+	} else if len(violations) > 0 {
+		text := fmt.Sprintf("模板 %s 将超出免费额度:\n%s", spec.Template, strings.Join(violations, "\n"))
+		if limit.Mode == limitModeRefuse {
+			printlnErr(fmt.Sprintf("[%s] %s", acc.Name, text), "已拒绝创建, 可在配置文件 [LIMIT] 中调整额度或将 mode 设置为 warn/off")
+			notify(tag, text+"\n已拒绝创建")
+			return sum, num, nil
+		}
+		printf("\033[1;33m[%s] %s\033[0m\n", acc.Name, text)
+		notify(tag, text)
+	}
+	// saveProgress 保存创建进度, next 为 true 表示当前实例已创建成功, 下次从下一个实例开始
+	saveProgress := func(next bool) {
+		if state.Template == "" {
+			return
+		}
+		state.Pos, state.RunTimes, state.FailTimes, state.StartTime = pos, runTimes, failTimes, startTime
+		state.AdIndex, state.AdName = adIndex, *adName
+		state.ExcludedAds, state.SkipAds = nil, nil
+		if next {
+			state.Pos, state.RunTimes, state.FailTimes, state.StartTime = pos+1, 0, 0, time.Now()
+		} else if AD_NOT_FIXED && !EACH_AD {
+			var usableNames []string
+			for _, ad := range usableAds {
+				usableNames = append(usableNames, *ad.Name)
+			}
+			for _, ad := range ads {
+				if !containsString(usableNames, *ad.Name) {
+					state.ExcludedAds = append(state.ExcludedAds, *ad.Name)
+				}
+			}
+			state.SkipAds = map[string]bool{}
+			for index, skip := range SKIP_RETRY_MAP {
+				state.SkipAds[*usableAds[index].Name] = skip
+			}
+		}
+		err := saveLaunchState(state)
 		if err != nil {
-			printlnErr("Telegram 消息提醒发送失败", err.Error())
+			printlnErr(fmt.Sprintf("[%s] 保存创建进度失败", acc.Name), err.Error())
 		}
 	}
+	// 开始前先保存一次进度, 第一个实例创建成功后未来得及保存进度时, 下次也能据此识别出该实例
+	saveProgress(false)
+	printf("\033[1;36m[%s] 开始创建 %s 实例, OCPU: %g 内存: %g 引导卷: %g \033[0m\n", acc.Name, *shape.Shape, *shape.Ocpus, *shape.MemoryInGBs, bootVolumeSize)
+	if EACH {
+		text := fmt.Sprintf("正在尝试创建第 %d 个实例...⏳\n区域: %s\n实例配置: %s\nOCPU计数: %g\n内存(GB): %g\n引导卷(GB): %g\n创建个数: %d", pos+1, acc.Oracle.Region, *shape.Shape, *shape.Ocpus, *shape.MemoryInGBs, bootVolumeSize, sum)
+		notify(tag, text)
+	}
 	for pos < sum {
+		if isPaused() {
+			printf("\033[1;33m[%s] 已暂停创建, 等待恢复...\033[0m\n", acc.Name)
+			saveProgress(false)
+			waitIfPaused(stop)
+		}
+		if isStopped(stop) {
+			saveProgress(false)
+			printf("\033[1;33m[%s] 收到停止信号, 已停止创建, 成功创建 %d 个实例\033[0m\n", acc.Name, num)
+			break
+		}
+		// 每次尝试都重写进度文件的开销太大, 连续失败时只定期保存尝试次数, 创建成功、暂停和停止时立即保存
+		if runTimes > 0 && runTimes%launchStateSaveInterval == 0 {
+			saveProgress(false)
+		}
+		if existing+sum > 1 {
+			request.DisplayName = common.String(nextInstanceName(name, usedNames))
+		}
 		if AD_NOT_FIXED {
 			if EACH_AD {
 				if pos%each == 0 && failTimes == 0 {
+					if adIndex >= int32(len(ads)) {
+						adIndex = 0
+					}
 					adName = ads[adIndex].Name
 					adIndex++
 				}
@@ -433,56 +1077,57 @@ func LaunchInstances(ads []identity.AvailabilityDomain) (sum, num int32) {
 			}
 		}
 		runTimes++
-		printf("\033[1;36m[%s] 正在尝试创建第 %d 个实例, AD: %s\033[0m\n", oracleSectionName, pos+1, *adName)
-		printf("\033[1;36m[%s] 当前尝试次数: %d \033[0m\n", oracleSectionName, runTimes)
+		printf("\033[1;36m[%s] 正在尝试创建第 %d 个实例, AD: %s\033[0m\n", acc.Name, pos+1, *adName)
+		printf("\033[1;36m[%s] 当前尝试次数: %d \033[0m\n", acc.Name, runTimes)
 		request.AvailabilityDomain = adName
-		createResp, err := computeClient.LaunchInstance(ctx, request)
+		createResp, err := acc.Compute.LaunchInstance(ctx, request)
 		if err == nil {
 			SUCCESS = true
 			num++
+			reservation.take()
+			usedNames[*createResp.Instance.DisplayName] = true
+			state.Created = append(state.Created, CreatedInstance{
+				Id:                 *createResp.Instance.Id,
+				DisplayName:        *createResp.Instance.DisplayName,
+				AvailabilityDomain: *createResp.Instance.AvailabilityDomain,
+				TimeCreated:        time.Now(),
+			})
+			saveProgress(true)
 			duration := fmtDuration(time.Since(startTime))
-			printf("\033[1;32m[%s] 第 %d 个实例抢到了🎉, 正在启动中请稍等...⌛️ \033[0m\n", oracleSectionName, pos+1)
-			var msg Message
-			var msgErr error
+			printf("\033[1;32m[%s] 第 %d 个实例抢到了🎉, 正在启动中请稍等...⌛️ \033[0m\n", acc.Name, pos+1)
+			thread := newNotifyThread(tag)
 			var text string
 			if EACH {
-				text = fmt.Sprintf("第 %d 个实例抢到了🎉, 正在启动中请稍等...⌛️\n区域: %s\n实例名称: %s\n公共IP: 获取中...⏳\n可用性域:%s\n实例配置: %s\nOCPU计数: %g\n内存(GB): %g\n引导卷(GB): %g\n创建个数: %d\n尝试次数: %d\n耗时: %s", pos+1, oracle.Region, *createResp.Instance.DisplayName, *createResp.Instance.AvailabilityDomain, *shape.Shape, *shape.Ocpus, *shape.MemoryInGBs, bootVolumeSize, sum, runTimes, duration)
-				msg, msgErr = sendMessage("", text)
+				text = fmt.Sprintf("第 %d 个实例抢到了🎉, 正在启动中请稍等...⌛️\n区域: %s\n实例名称: %s\n公共IP: 获取中...⏳\n可用性域:%s\n实例配置: %s\nOCPU计数: %g\n内存(GB): %g\n引导卷(GB): %g\n创建个数: %d\n尝试次数: %d\n耗时: %s", pos+1, acc.Oracle.Region, *createResp.Instance.DisplayName, *createResp.Instance.AvailabilityDomain, *shape.Shape, *shape.Ocpus, *shape.MemoryInGBs, bootVolumeSize, sum, runTimes, duration)
+				thread.send(text)
 			}
 			var strIps string
-			ips, err := getInstancePublicIps(createResp.Instance.Id)
+			ips, err := getInstancePublicIps(acc, createResp.Instance.Id)
 			if err != nil {
-				printf("\033[1;32m[%s] 第 %d 个实例抢到了🎉, 但是启动失败❌ 错误信息: \033[0m%s\n", oracleSectionName, pos+1, err.Error())
-				text = fmt.Sprintf("第 %d 个实例抢到了🎉, 但是启动失败❌实例已被终止😔\n区域: %s\n实例名称: %s\n可用性域:%s\n实例配置: %s\nOCPU计数: %g\n内存(GB): %g\n引导卷(GB): %g\n创建个数: %d\n尝试次数: %d\n耗时: %s", pos+1, oracle.Region, *createResp.Instance.DisplayName, *createResp.Instance.AvailabilityDomain, *shape.Shape, *shape.Ocpus, *shape.MemoryInGBs, bootVolumeSize, sum, runTimes, duration)
+				printf("\033[1;32m[%s] 第 %d 个实例抢到了🎉, 但是启动失败❌ 错误信息: \033[0m%s\n", acc.Name, pos+1, err.Error())
+				text = fmt.Sprintf("第 %d 个实例抢到了🎉, 但是启动失败❌实例已被终止😔\n区域: %s\n实例名称: %s\n可用性域:%s\n实例配置: %s\nOCPU计数: %g\n内存(GB): %g\n引导卷(GB): %g\n创建个数: %d\n尝试次数: %d\n耗时: %s", pos+1, acc.Oracle.Region, *createResp.Instance.DisplayName, *createResp.Instance.AvailabilityDomain, *shape.Shape, *shape.Ocpus, *shape.MemoryInGBs, bootVolumeSize, sum, runTimes, duration)
 			} else {
 				strIps = strings.Join(ips, ",")
-				printf("\033[1;32m[%s] 第 %d 个实例抢到了🎉, 启动成功✅. 实例名称: %s, 公共IP: %s\033[0m\n", oracleSectionName, pos+1, *createResp.Instance.DisplayName, strIps)
-				text = fmt.Sprintf("第 %d 个实例抢到了🎉, 启动成功✅\n区域: %s\n实例名称: %s\n公共IP: %s\n可用性域:%s\n实例配置: %s\nOCPU计数: %g\n内存(GB): %g\n引导卷(GB): %g\n创建个数: %d\n尝试次数: %d\n耗时: %s", pos+1, oracle.Region, *createResp.Instance.DisplayName, strIps, *createResp.Instance.AvailabilityDomain, *shape.Shape, *shape.Ocpus, *shape.MemoryInGBs, bootVolumeSize, sum, runTimes, duration)
+				printf("\033[1;32m[%s] 第 %d 个实例抢到了🎉, 启动成功✅. 实例名称: %s, 公共IP: %s\033[0m\n", acc.Name, pos+1, *createResp.Instance.DisplayName, strIps)
+				text = fmt.Sprintf("第 %d 个实例抢到了🎉, 启动成功✅\n区域: %s\n实例名称: %s\n公共IP: %s\n可用性域:%s\n实例配置: %s\nOCPU计数: %g\n内存(GB): %g\n引导卷(GB): %g\n创建个数: %d\n尝试次数: %d\n耗时: %s", pos+1, acc.Oracle.Region, *createResp.Instance.DisplayName, strIps, *createResp.Instance.AvailabilityDomain, *shape.Shape, *shape.Ocpus, *shape.MemoryInGBs, bootVolumeSize, sum, runTimes, duration)
 			}
 			if EACH {
-				if msgErr != nil {
-					sendMessage("", text)
-				} else {
-					editMessage(msg.MessageId, "", text)
-				}
+				thread.send(text)
 			}
-			sleepRandomSecond(minTime, maxTime)
-			displayName = common.String(fmt.Sprintf("%s-%d", name, pos+1))
-			request.DisplayName = displayName
+			sleepRandomSecondUntil(minTime, maxTime, stop)
 		} else {
 			SUCCESS = false
 			errInfo := err.Error()
 			SKIP_RETRY := false
 			servErr, isServErr := common.IsServiceError(err)
-			if isServErr && (400 <= servErr.GetHTTPStatusCode() && servErr.GetHTTPStatusCode() <= 405) || (servErr.GetHTTPStatusCode() == 409 && !strings.EqualFold(servErr.GetCode(), "IncorrectState")) || servErr.GetHTTPStatusCode() == 412 || servErr.GetHTTPStatusCode() == 413 || servErr.GetHTTPStatusCode() == 422 || servErr.GetHTTPStatusCode() == 431 || servErr.GetHTTPStatusCode() == 501 {
-				if isServErr {
-					errInfo = servErr.GetMessage()
-				}
+			// 非服务端错误 (网络错误、超时等) 时 servErr 为 nil, 只能在 isServErr 为 true 时判断状态码
+			if isServErr && ((400 <= servErr.GetHTTPStatusCode() && servErr.GetHTTPStatusCode() <= 405) || (servErr.GetHTTPStatusCode() == 409 && !strings.EqualFold(servErr.GetCode(), "IncorrectState")) || servErr.GetHTTPStatusCode() == 412 || servErr.GetHTTPStatusCode() == 413 || servErr.GetHTTPStatusCode() == 422 || servErr.GetHTTPStatusCode() == 431 || servErr.GetHTTPStatusCode() == 501) {
+				errInfo = servErr.GetMessage()
 				duration := fmtDuration(time.Since(startTime))
-				printf("\033[1;31m[%s] 第 %d 个实例创建失败了❌, 错误信息: \033[0m%s\n", oracleSectionName, pos+1, errInfo)
+				printf("\033[1;31m[%s] 第 %d 个实例创建失败了❌, 错误信息: \033[0m%s\n", acc.Name, pos+1, errInfo)
 				if EACH {
-					text := fmt.Sprintf("第 %d 个实例创建失败了❌\n错误信息: %s\n区域: %s\n可用性域: %s\n实例配置: %s\nOCPU计数: %g\n内存(GB): %g\n引导卷(GB): %g\n创建个数: %d\n尝试次数: %d\n耗时:%s", pos+1, errInfo, oracle.Region, *adName, *shape.Shape, *shape.Ocpus, *shape.MemoryInGBs, bootVolumeSize, sum, runTimes, duration)
-					sendMessage("", text)
+					text := fmt.Sprintf("第 %d 个实例创建失败了❌\n错误信息: %s\n区域: %s\n可用性域: %s\n实例配置: %s\nOCPU计数: %g\n内存(GB): %g\n引导卷(GB): %g\n创建个数: %d\n尝试次数: %d\n耗时:%s", pos+1, errInfo, acc.Oracle.Region, *adName, *shape.Shape, *shape.Ocpus, *shape.MemoryInGBs, bootVolumeSize, sum, runTimes, duration)
+					notify(tag, text)
 				}
 				SKIP_RETRY = true
 				if AD_NOT_FIXED && !EACH_AD {
@@ -492,13 +1137,13 @@ func LaunchInstances(ads []identity.AvailabilityDomain) (sum, num int32) {
 				if isServErr {
 					errInfo = servErr.GetMessage()
 				}
-				printf("\033[1;31m[%s] 创建失败, Error: \033[0m%s\n", oracleSectionName, errInfo)
+				printf("\033[1;31m[%s] 创建失败, Error: \033[0m%s\n", acc.Name, errInfo)
 				SKIP_RETRY = false
 				if AD_NOT_FIXED && !EACH_AD {
 					SKIP_RETRY_MAP[adIndex-1] = false
 				}
 			}
-			sleepRandomSecond(minTime, maxTime)
+			sleepRandomSecondUntil(minTime, maxTime, stop)
 			if AD_NOT_FIXED {
 				if !EACH_AD {
 					if adIndex < adCount {
@@ -545,46 +1190,100 @@ func LaunchInstances(ads []identity.AvailabilityDomain) (sum, num int32) {
 		startTime = time.Now()
 		pos++
 		if pos < sum && EACH {
-			text := fmt.Sprintf("正在尝试创建第 %d 个实例...⏳\n区域: %s\n实例配置: %s\nOCPU计数: %g\n内存(GB): %g\n引导卷(GB): %g\n创建个数: %d", pos+1, oracle.Region, *shape.Shape, *shape.Ocpus, *shape.MemoryInGBs, bootVolumeSize, sum)
-			sendMessage("", text)
+			text := fmt.Sprintf("正在尝试创建第 %d 个实例...⏳\n区域: %s\n实例配置: %s\nOCPU计数: %g\n内存(GB): %g\n引导卷(GB): %g\n创建个数: %d", pos+1, acc.Oracle.Region, *shape.Shape, *shape.Ocpus, *shape.MemoryInGBs, bootVolumeSize, sum)
+			notify(tag, text)
 		}
 	}
-	return
+	if pos >= sum {
+		clearLaunchState(state.Account, state.Template)
+	}
+	return sum, num, nil
 }
 
-func CreateOrGetNetworkInfrastructure(ctx context.Context, c core.VirtualNetworkClient) (subnet core.Subnet, err error) {
-	var vcn core.Vcn
-	vcn, err = createOrGetVcn(ctx, c)
-	if err != nil {
-		return
-	}
-	var gateway core.InternetGateway
-	gateway, err = createOrGetInternetGateway(c, vcn.Id)
-	if err != nil {
-		return
+// countTemplateInstances 统计由模板创建 (shape 一致, 名称为 name 或 name-<序号>) 且未被终止的实例个数。
+// 已停止的实例同样占用免费额度, 也计入其中
+func countTemplateInstances(instances []core.Instance, shape, name string) int32 {
+	return int32(len(templateInstances(instances, shape, name)))
+}
+
+// templateInstances 返回由模板创建 (shape 一致, 名称为 name 或 name-<序号>) 且未被终止的实例
+func templateInstances(instances []core.Instance, shape, name string) (result []core.Instance) {
+	for _, ins := range instances {
+		if ins.LifecycleState == core.InstanceLifecycleStateTerminating || ins.LifecycleState == core.InstanceLifecycleStateTerminated {
+			continue
+		}
+		if ins.Shape == nil || !strings.EqualFold(*ins.Shape, shape) {
+			continue
+		}
+		if !isTemplateInstanceName(stringValue(ins.DisplayName), name) {
+			continue
+		}
+		result = append(result, ins)
+	}
+	return
+}
+
+// nextInstanceName 返回 name-<序号> 中最小的未被 used 占用的名称
+func nextInstanceName(name string, used map[string]bool) string {
+	for i := 1; ; i++ {
+		n := fmt.Sprintf("%s-%d", name, i)
+		if !used[n] {
+			return n
+		}
+	}
+}
+
+// isTemplateInstanceName 检查实例名称是否是 LaunchInstances 按 name 生成的名称: 只创建一个时为 name, 否则为 name-<序号>
+func isTemplateInstanceName(displayName, name string) bool {
+	if displayName == name {
+		return true
+	}
+	if !strings.HasPrefix(displayName, name+"-") {
+		return false
+	}
+	_, err := strconv.ParseUint(strings.TrimPrefix(displayName, name+"-"), 10, 32)
+	return err == nil
+}
+
+// CreateOrGetNetworkInfrastructure 获取或创建实例模板使用的 VCN、Internet 网关、路由规则和子网
+func CreateOrGetNetworkInfrastructure(acc *Account, spec Instance) (subnet core.Subnet, err error) {
+	var vcn core.Vcn
+	vcn, err = createOrGetVcn(acc, spec.VcnDisplayName)
+	if err != nil {
+		return
 	}
-	_, err = createOrGetRouteTable(c, gateway.Id, vcn.Id)
+	var gateway core.InternetGateway
+	gateway, err = createOrGetInternetGateway(acc, vcn.Id)
+	if err != nil {
+		return
+	}
+	_, err = createOrGetRouteTable(acc, gateway.Id, vcn.Id)
 	if err != nil {
 		return
 	}
 	subnet, err = createOrGetSubnetWithDetails(
-		ctx, c, vcn.Id,
-		common.String(instance.SubnetDisplayName),
+		acc, vcn.Id,
+		common.String(spec.SubnetDisplayName),
 		common.String("10.0.0.0/20"),
 		common.String("subnetdns"),
-		common.String(instance.AvailabilityDomain))
+		common.String(spec.AvailabilityDomain),
+		spec.SecurityPreset)
+	if err == nil && spec.AssignIpv6 {
+		subnet, err = enableIpv6(acc, subnet)
+	}
 	return
 }
 
-func createOrGetSubnetWithDetails(ctx context.Context, c core.VirtualNetworkClient, vcnID *string,
-	displayName *string, cidrBlock *string, dnsLabel *string, availableDomain *string) (subnet core.Subnet, err error) {
+// createOrGetSubnetWithDetails 获取或创建子网, 新建子网时将预设规则 preset 应用到子网的安全列表
+func createOrGetSubnetWithDetails(acc *Account, vcnID *string,
+	displayName *string, cidrBlock *string, dnsLabel *string, availableDomain *string, preset string) (subnet core.Subnet, err error) {
 	var subnets []core.Subnet
-	subnets, err = listSubnets(ctx, c, vcnID)
+	subnets, err = listSubnets(acc, vcnID)
 	if err != nil {
 		return
 	}
 	if displayName == nil {
-		displayName = common.String(instance.SubnetDisplayName)
+		displayName = common.String("")
 	}
 	if len(subnets) > 0 && *displayName == "" {
 		subnet = subnets[0]
@@ -596,19 +1295,19 @@ func createOrGetSubnetWithDetails(ctx context.Context, c core.VirtualNetworkClie
 			return
 		}
 	}
-	fmt.Printf("开始创建Subnet（没有可用的Subnet，或指定的Subnet不存在）\n")
+	printf("[%s] 开始创建Subnet（没有可用的Subnet，或指定的Subnet不存在）\n", acc.Name)
 	if *displayName == "" {
 		displayName = common.String(time.Now().Format("subnet-20060102-1504"))
 	}
 	request := core.CreateSubnetRequest{}
-	request.CompartmentId = &oracle.Tenancy
+	request.CompartmentId = &acc.Oracle.Tenancy
 	request.CidrBlock = cidrBlock
 	request.DisplayName = displayName
 	request.DnsLabel = dnsLabel
 	request.RequestMetadata = getCustomRequestMetadataWithRetryPolicy()
 	request.VcnId = vcnID
 	var r core.CreateSubnetResponse
-	r, err = c.CreateSubnet(ctx, request)
+	r, err = acc.Network.CreateSubnet(ctx, request)
 	if err != nil {
 		return
 	}
@@ -622,45 +1321,27 @@ func createOrGetSubnetWithDetails(ctx context.Context, c core.VirtualNetworkClie
 		SubnetId:        r.Id,
 		RequestMetadata: getCustomRequestMetadataWithCustomizedRetryPolicy(pollUntilAvailable),
 	}
-	_, err = c.GetSubnet(ctx, pollGetRequest)
-	if err != nil {
-		return
-	}
-	getReq := core.GetSecurityListRequest{
-		SecurityListId:  common.String(r.SecurityListIds[0]),
-		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
-	}
-	var getResp core.GetSecurityListResponse
-	getResp, err = c.GetSecurityList(ctx, getReq)
+	_, err = acc.Network.GetSubnet(ctx, pollGetRequest)
 	if err != nil {
 		return
 	}
-	newRules := append(getResp.IngressSecurityRules, core.IngressSecurityRule{
-		Protocol: common.String("all"),
-		Source:   common.String("0.0.0.0/0"),
-	})
-	updateReq := core.UpdateSecurityListRequest{
-		SecurityListId:  common.String(r.SecurityListIds[0]),
-		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
-	}
-	updateReq.IngressSecurityRules = newRules
-	_, err = c.UpdateSecurityList(ctx, updateReq)
+	err = applySecurityPresetToList(acc, common.String(r.SecurityListIds[0]), preset)
 	if err != nil {
 		return
 	}
-	fmt.Printf("Subnet创建成功: %s\n", *r.Subnet.DisplayName)
+	printf("[%s] Subnet创建成功: %s\n", acc.Name, *r.Subnet.DisplayName)
 	subnet = r.Subnet
 	return
 }
 
-func listSubnets(ctx context.Context, c core.VirtualNetworkClient, vcnID *string) (subnets []core.Subnet, err error) {
+func listSubnets(acc *Account, vcnID *string) (subnets []core.Subnet, err error) {
 	request := core.ListSubnetsRequest{
-		CompartmentId:   &oracle.Tenancy,
+		CompartmentId:   &acc.Oracle.Tenancy,
 		VcnId:           vcnID,
 		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
 	}
 	var r core.ListSubnetsResponse
-	r, err = c.ListSubnets(ctx, request)
+	r, err = acc.Network.ListSubnets(ctx, request)
 	if err != nil {
 		return
 	}
@@ -668,96 +1349,96 @@ func listSubnets(ctx context.Context, c core.VirtualNetworkClient, vcnID *string
 	return
 }
 
-func createOrGetVcn(ctx context.Context, c core.VirtualNetworkClient) (core.Vcn, error) {
+func createOrGetVcn(acc *Account, vcnDisplayName string) (core.Vcn, error) {
 	var vcn core.Vcn
-	vcnItems, err := listVcns(ctx, c)
+	vcnItems, err := listVcns(acc)
 	if err != nil {
 		return vcn, err
 	}
-	displayName := common.String(instance.VcnDisplayName)
+	displayName := common.String(vcnDisplayName)
 	if len(vcnItems) > 0 && *displayName == "" {
 		vcn = vcnItems[0]
 		return vcn, err
 	}
 	for _, element := range vcnItems {
-		if *element.DisplayName == instance.VcnDisplayName {
+		if *element.DisplayName == vcnDisplayName {
 			vcn = element
 			return vcn, err
 		}
 	}
-	fmt.Println("开始创建VCN（没有可用的VCN，或指定的VCN不存在）")
+	printf("[%s] 开始创建VCN（没有可用的VCN，或指定的VCN不存在）\n", acc.Name)
 	if *displayName == "" {
 		displayName = common.String(time.Now().Format("vcn-20060102-1504"))
 	}
 	request := core.CreateVcnRequest{}
 	request.RequestMetadata = getCustomRequestMetadataWithRetryPolicy()
 	request.CidrBlock = common.String("10.0.0.0/16")
-	request.CompartmentId = common.String(oracle.Tenancy)
+	request.CompartmentId = common.String(acc.Oracle.Tenancy)
 	request.DisplayName = displayName
 	request.DnsLabel = common.String("vcndns")
-	r, err := c.CreateVcn(ctx, request)
+	r, err := acc.Network.CreateVcn(ctx, request)
 	if err != nil {
 		return vcn, err
 	}
-	fmt.Printf("VCN创建成功: %s\n", *r.Vcn.DisplayName)
+	printf("[%s] VCN创建成功: %s\n", acc.Name, *r.Vcn.DisplayName)
 	vcn = r.Vcn
 	return vcn, err
 }
 
-func listVcns(ctx context.Context, c core.VirtualNetworkClient) ([]core.Vcn, error) {
+func listVcns(acc *Account) ([]core.Vcn, error) {
 	request := core.ListVcnsRequest{
-		CompartmentId:   &oracle.Tenancy,
+		CompartmentId:   &acc.Oracle.Tenancy,
 		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
 	}
-	r, err := c.ListVcns(ctx, request)
+	r, err := acc.Network.ListVcns(ctx, request)
 	if err != nil {
 		return nil, err
 	}
 	return r.Items, err
 }
 
-func createOrGetInternetGateway(c core.VirtualNetworkClient, vcnID *string) (core.InternetGateway, error) {
+func createOrGetInternetGateway(acc *Account, vcnID *string) (core.InternetGateway, error) {
 	var gateway core.InternetGateway
 	listGWRequest := core.ListInternetGatewaysRequest{
-		CompartmentId:   &oracle.Tenancy,
+		CompartmentId:   &acc.Oracle.Tenancy,
 		VcnId:           vcnID,
 		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
 	}
-	listGWRespone, err := c.ListInternetGateways(ctx, listGWRequest)
+	listGWRespone, err := acc.Network.ListInternetGateways(ctx, listGWRequest)
 	if err != nil {
 		return gateway, err
 	}
 	if len(listGWRespone.Items) >= 1 {
 		gateway = listGWRespone.Items[0]
 	} else {
-		fmt.Printf("开始创建Internet网关\n")
+		printf("[%s] 开始创建Internet网关\n", acc.Name)
 		enabled := true
 		createGWDetails := core.CreateInternetGatewayDetails{
-			CompartmentId: &oracle.Tenancy,
+			CompartmentId: &acc.Oracle.Tenancy,
 			IsEnabled:     &enabled,
 			VcnId:         vcnID,
 		}
 		createGWRequest := core.CreateInternetGatewayRequest{
 			CreateInternetGatewayDetails: createGWDetails,
 			RequestMetadata:              getCustomRequestMetadataWithRetryPolicy()}
-		createGWResponse, err := c.CreateInternetGateway(ctx, createGWRequest)
+		createGWResponse, err := acc.Network.CreateInternetGateway(ctx, createGWRequest)
 		if err != nil {
 			return gateway, err
 		}
 		gateway = createGWResponse.InternetGateway
-		fmt.Printf("Internet网关创建成功: %s\n", *gateway.DisplayName)
+		printf("[%s] Internet网关创建成功: %s\n", acc.Name, *gateway.DisplayName)
 	}
 	return gateway, err
 }
 
-func createOrGetRouteTable(c core.VirtualNetworkClient, gatewayID, VcnID *string) (routeTable core.RouteTable, err error) {
+func createOrGetRouteTable(acc *Account, gatewayID, VcnID *string) (routeTable core.RouteTable, err error) {
 	listRTRequest := core.ListRouteTablesRequest{
-		CompartmentId:   &oracle.Tenancy,
+		CompartmentId:   &acc.Oracle.Tenancy,
 		VcnId:           VcnID,
 		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
 	}
 	var listRTResponse core.ListRouteTablesResponse
-	listRTResponse, err = c.ListRouteTables(ctx, listRTRequest)
+	listRTResponse, err = acc.Network.ListRouteTables(ctx, listRTRequest)
 	if err != nil {
 		return
 	}
@@ -771,7 +1452,7 @@ func createOrGetRouteTable(c core.VirtualNetworkClient, gatewayID, VcnID *string
 		if len(listRTResponse.Items[0].RouteRules) >= 1 {
 			routeTable = listRTResponse.Items[0]
 		} else {
-			fmt.Printf("路由表未添加规则，开始添加Internet路由规则\n")
+			printf("[%s] 路由表未添加规则，开始添加Internet路由规则\n", acc.Name)
 			updateRTDetails := core.UpdateRouteTableDetails{
 				RouteRules: []core.RouteRule{rr},
 			}
@@ -781,51 +1462,105 @@ func createOrGetRouteTable(c core.VirtualNetworkClient, gatewayID, VcnID *string
 				RequestMetadata:         getCustomRequestMetadataWithRetryPolicy(),
 			}
 			var updateRTResponse core.UpdateRouteTableResponse
-			updateRTResponse, err = c.UpdateRouteTable(ctx, updateRTRequest)
+			updateRTResponse, err = acc.Network.UpdateRouteTable(ctx, updateRTRequest)
 			if err != nil {
 				return
 			}
-			fmt.Printf("Internet路由规则添加成功\n")
+			printf("[%s] Internet路由规则添加成功\n", acc.Name)
 			routeTable = updateRTResponse.RouteTable
 		}
 	} else {
-		fmt.Printf("错误: 找不到VCN的默认路由表, VCN OCID: %s\n", *VcnID)
+		printf("[%s] 错误: 找不到VCN的默认路由表, VCN OCID: %s\n", acc.Name, *VcnID)
 	}
 	return
 }
 
-func GetImage(ctx context.Context, c core.ComputeClient) (image core.Image, err error) {
+func GetImage(acc *Account, spec Instance) (image core.Image, err error) {
+	// 模板指定了自定义镜像时不再按操作系统查找系统镜像
+	if spec.ImageId != "" {
+		return getImage(acc, common.String(spec.ImageId))
+	}
+	if spec.ImageDisplayName != "" {
+		return findCustomImage(acc, spec.ImageDisplayName)
+	}
 	var images []core.Image
-	images, err = listImages(ctx, c)
+	images, err = listImages(acc, spec)
 	if err != nil {
 		return
 	}
 	if len(images) > 0 {
 		image = images[0]
 	} else {
-		err = fmt.Errorf("未找到[%s %s]的镜像, 或该镜像不支持[%s]", instance.OperatingSystem, instance.OperatingSystemVersion, instance.Shape)
+		err = fmt.Errorf("未找到[%s %s]的镜像, 或该镜像不支持[%s]", spec.OperatingSystem, spec.OperatingSystemVersion, spec.Shape)
 	}
 	return
 }
 
-func listImages(ctx context.Context, c core.ComputeClient) ([]core.Image, error) {
-	if instance.OperatingSystem == "" || instance.OperatingSystemVersion == "" {
+// getLaunchBootVolume 按实例模板中的 bootVolumeId 或 bootVolumeDisplayName 查找用于创建实例的引导卷,
+// 引导卷必须处于可用状态且没有挂载到其他实例。请求失败时返回包装了 errRetryLater 的错误
+func getLaunchBootVolume(acc *Account, spec Instance) (core.BootVolume, error) {
+	var bootVolume core.BootVolume
+	if spec.BootVolumeId != "" {
+		v, err := getBootVolume(acc, common.String(spec.BootVolumeId))
+		if servErr, ok := common.IsServiceError(err); ok && servErr.GetHTTPStatusCode() == http.StatusNotFound {
+			return bootVolume, fmt.Errorf("未找到引导卷 %s", spec.BootVolumeId)
+		}
+		if err != nil {
+			return bootVolume, fmt.Errorf("%w: %v", errRetryLater, err)
+		}
+		bootVolume = v
+	} else {
+		volumes, err := listAllBootVolumes(acc)
+		if err != nil {
+			return bootVolume, fmt.Errorf("%w: %v", errRetryLater, err)
+		}
+		var matched []core.BootVolume
+		for _, v := range volumes {
+			if v.DisplayName != nil && *v.DisplayName == spec.BootVolumeDisplayName && v.LifecycleState != core.BootVolumeLifecycleStateTerminated {
+				matched = append(matched, v)
+			}
+		}
+		if len(matched) == 0 {
+			return bootVolume, fmt.Errorf("未找到名称为 %s 的引导卷", spec.BootVolumeDisplayName)
+		}
+		if len(matched) > 1 {
+			return bootVolume, fmt.Errorf("存在 %d 个名称为 %s 的引导卷, 请使用 bootVolumeId 指定", len(matched), spec.BootVolumeDisplayName)
+		}
+		bootVolume = matched[0]
+	}
+	if bootVolume.LifecycleState != core.BootVolumeLifecycleStateAvailable {
+		return bootVolume, fmt.Errorf("引导卷 %s 当前状态为 %s, 无法用于创建实例", *bootVolume.DisplayName, getBootVolumeState(bootVolume.LifecycleState))
+	}
+	attachments, err := listBootVolumeAttachments(acc, bootVolume.AvailabilityDomain, bootVolume.CompartmentId, bootVolume.Id)
+	if err != nil {
+		return bootVolume, fmt.Errorf("%w: %v", errRetryLater, err)
+	}
+	for _, attachment := range attachments {
+		if attachment.LifecycleState == core.BootVolumeAttachmentLifecycleStateAttached || attachment.LifecycleState == core.BootVolumeAttachmentLifecycleStateAttaching {
+			return bootVolume, fmt.Errorf("引导卷 %s 已挂载到实例, 请先分离", *bootVolume.DisplayName)
+		}
+	}
+	return bootVolume, nil
+}
+
+func listImages(acc *Account, spec Instance) ([]core.Image, error) {
+	if spec.OperatingSystem == "" || spec.OperatingSystemVersion == "" {
 		return nil, errors.New("操作系统类型和版本不能为空, 请检查配置文件")
 	}
 	request := core.ListImagesRequest{
-		CompartmentId:          common.String(oracle.Tenancy),
-		OperatingSystem:        common.String(instance.OperatingSystem),
-		OperatingSystemVersion: common.String(instance.OperatingSystemVersion),
-		Shape:                  common.String(instance.Shape),
+		CompartmentId:          common.String(acc.Oracle.Tenancy),
+		OperatingSystem:        common.String(spec.OperatingSystem),
+		OperatingSystemVersion: common.String(spec.OperatingSystemVersion),
+		Shape:                  common.String(spec.Shape),
 		RequestMetadata:        getCustomRequestMetadataWithRetryPolicy(),
 	}
-	r, err := c.ListImages(ctx, request)
+	r, err := acc.Compute.ListImages(ctx, request)
 	return r.Items, err
 }
 
-func getShape(imageId *string, shapeName string) (core.Shape, error) {
+func getShape(acc *Account, imageId *string, shapeName string) (core.Shape, error) {
 	var shape core.Shape
-	shapes, err := listShapes(ctx, computeClient, imageId)
+	shapes, err := listShapes(acc, imageId)
 	if err != nil {
 		return shape, err
 	}
@@ -838,42 +1573,59 @@ func getShape(imageId *string, shapeName string) (core.Shape, error) {
 	return shape, errors.New("没有符合条件的Shape")
 }
 
-func listShapes(ctx context.Context, c core.ComputeClient, imageID *string) ([]core.Shape, error) {
+func listShapes(acc *Account, imageID *string) ([]core.Shape, error) {
 	request := core.ListShapesRequest{
-		CompartmentId:   common.String(oracle.Tenancy),
+		CompartmentId:   common.String(acc.Oracle.Tenancy),
 		ImageId:         imageID,
 		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
 	}
-	r, err := c.ListShapes(ctx, request)
+	r, err := acc.Compute.ListShapes(ctx, request)
 	if err == nil && (r.Items == nil || len(r.Items) == 0) {
 		err = errors.New("没有符合条件的Shape")
 	}
 	return r.Items, err
 }
 
-func ListAvailabilityDomains() ([]identity.AvailabilityDomain, error) {
+func ListAvailabilityDomains(acc *Account) ([]identity.AvailabilityDomain, error) {
 	req := identity.ListAvailabilityDomainsRequest{
-		CompartmentId:   common.String(oracle.Tenancy),
+		CompartmentId:   common.String(acc.Oracle.Tenancy),
 		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
 	}
-	resp, err := identityClient.ListAvailabilityDomains(ctx, req)
+	resp, err := acc.Identity.ListAvailabilityDomains(ctx, req)
 	return resp.Items, err
 }
 
-func ListInstances(ctx context.Context, c core.ComputeClient, page *string) ([]core.Instance, *string, error) {
+func ListInstances(acc *Account, page *string) ([]core.Instance, *string, error) {
 	req := core.ListInstancesRequest{
-		CompartmentId:   common.String(oracle.Tenancy),
+		CompartmentId:   common.String(acc.Oracle.Tenancy),
 		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
 		Limit:           common.Int(100),
 		Page:            page,
 	}
-	resp, err := c.ListInstances(ctx, req)
+	resp, err := acc.Compute.ListInstances(ctx, req)
 	return resp.Items, resp.OpcNextPage, err
 }
 
-func ListVnicAttachments(ctx context.Context, c core.ComputeClient, instanceId *string, page *string) ([]core.VnicAttachment, *string, error) {
+// listAllInstances 分页获取账号下的全部实例
+func listAllInstances(acc *Account) ([]core.Instance, error) {
+	var instances []core.Instance
+	var page *string
+	for {
+		ins, nextPage, err := ListInstances(acc, page)
+		if err != nil {
+			return instances, err
+		}
+		instances = append(instances, ins...)
+		if nextPage == nil || len(ins) == 0 {
+			return instances, nil
+		}
+		page = nextPage
+	}
+}
+
+func ListVnicAttachments(acc *Account, instanceId *string, page *string) ([]core.VnicAttachment, *string, error) {
 	req := core.ListVnicAttachmentsRequest{
-		CompartmentId:   common.String(oracle.Tenancy),
+		CompartmentId:   common.String(acc.Oracle.Tenancy),
 		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
 		Limit:           common.Int(100),
 		Page:            page,
@@ -881,43 +1633,126 @@ func ListVnicAttachments(ctx context.Context, c core.ComputeClient, instanceId *
 	if instanceId != nil && *instanceId != "" {
 		req.InstanceId = instanceId
 	}
-	resp, err := c.ListVnicAttachments(ctx, req)
+	resp, err := acc.Compute.ListVnicAttachments(ctx, req)
 	return resp.Items, resp.OpcNextPage, err
 }
 
-func GetVnic(ctx context.Context, c core.VirtualNetworkClient, vnicID *string) (core.Vnic, error) {
+func GetVnic(acc *Account, vnicID *string) (core.Vnic, error) {
 	req := core.GetVnicRequest{
 		VnicId:          vnicID,
 		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
 	}
-	resp, err := c.GetVnic(ctx, req)
+	resp, err := acc.Network.GetVnic(ctx, req)
 	if err != nil && resp.RawResponse != nil {
 		err = errors.New(resp.RawResponse.Status)
 	}
 	return resp.Vnic, err
 }
 
-func terminateInstance(id *string) error {
+// --- 辅助网卡与辅助私有IP ---
+
+// attachVnic 为实例添加辅助网卡并等待添加完成
+func attachVnic(acc *Account, instanceId, subnetId *string, displayName string, assignPublicIp bool) (core.VnicAttachment, error) {
+	details := core.AttachVnicDetails{
+		InstanceId: instanceId,
+		CreateVnicDetails: &core.CreateVnicDetails{
+			SubnetId:       subnetId,
+			AssignPublicIp: common.Bool(assignPublicIp),
+		},
+	}
+	if displayName != "" {
+		details.DisplayName = common.String(displayName)
+		details.CreateVnicDetails.DisplayName = common.String(displayName)
+	}
+	req := core.AttachVnicRequest{
+		AttachVnicDetails: details,
+		RequestMetadata:   getCustomRequestMetadataWithRetryPolicy(),
+	}
+	resp, err := acc.Compute.AttachVnic(ctx, req)
+	if err != nil {
+		return resp.VnicAttachment, err
+	}
+	pollUntilAttached := func(r common.OCIOperationResponse) bool {
+		if converted, ok := r.Response.(core.GetVnicAttachmentResponse); ok {
+			return converted.LifecycleState == core.VnicAttachmentLifecycleStateAttaching
+		}
+		return true
+	}
+	getReq := core.GetVnicAttachmentRequest{
+		VnicAttachmentId: resp.Id,
+		RequestMetadata:  getCustomRequestMetadataWithCustomizedRetryPolicy(pollUntilAttached),
+	}
+	getResp, err := acc.Compute.GetVnicAttachment(ctx, getReq)
+	if err != nil {
+		return resp.VnicAttachment, err
+	}
+	if getResp.LifecycleState != core.VnicAttachmentLifecycleStateAttached {
+		return getResp.VnicAttachment, fmt.Errorf("添加网卡失败, 状态: %s", getResp.LifecycleState)
+	}
+	return getResp.VnicAttachment, nil
+}
+
+func detachVnic(acc *Account, vnicAttachmentId *string) error {
+	req := core.DetachVnicRequest{
+		VnicAttachmentId: vnicAttachmentId,
+		RequestMetadata:  getCustomRequestMetadataWithRetryPolicy(),
+	}
+	_, err := acc.Compute.DetachVnic(ctx, req)
+	return err
+}
+
+func createPrivateIp(acc *Account, vnicId *string, displayName string) (core.PrivateIp, error) {
+	details := core.CreatePrivateIpDetails{VnicId: vnicId}
+	if displayName != "" {
+		details.DisplayName = common.String(displayName)
+	}
+	req := core.CreatePrivateIpRequest{
+		CreatePrivateIpDetails: details,
+		RequestMetadata:        getCustomRequestMetadataWithRetryPolicy(),
+	}
+	resp, err := acc.Network.CreatePrivateIp(ctx, req)
+	return resp.PrivateIp, err
+}
+
+func deletePrivateIp(acc *Account, privateIpId *string) error {
+	req := core.DeletePrivateIpRequest{
+		PrivateIpId:     privateIpId,
+		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
+	}
+	_, err := acc.Network.DeletePrivateIp(ctx, req)
+	return err
+}
+
+// assignPublicIpToPrivateIp 为私有IP分配公共IP, reservedPublicIpId 为 nil 时分配临时公共IP, 否则绑定该预留公共IP
+func assignPublicIpToPrivateIp(acc *Account, privateIpId, reservedPublicIpId *string) (core.PublicIp, error) {
+	if reservedPublicIpId == nil {
+		return createPublicIp(acc, privateIpId)
+	}
+	return updatePublicIpAssignment(acc, reservedPublicIpId, *privateIpId)
+}
+
+func terminateInstance(acc *Account, id *string) error {
 	request := core.TerminateInstanceRequest{
 		InstanceId:         id,
 		PreserveBootVolume: common.Bool(false),
 		RequestMetadata:    getCustomRequestMetadataWithRetryPolicy(),
 	}
-	_, err := computeClient.TerminateInstance(ctx, request)
+	_, err := acc.Compute.TerminateInstance(ctx, request)
 	return err
 }
 
-func getInstance(instanceId *string) (core.Instance, error) {
+func getInstance(acc *Account, instanceId *string) (core.Instance, error) {
 	req := core.GetInstanceRequest{
 		InstanceId:      instanceId,
 		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
 	}
-	resp, err := computeClient.GetInstance(ctx, req)
+	resp, err := acc.Compute.GetInstance(ctx, req)
 	return resp.Instance, err
 }
 
-func updateInstance(instanceId *string, displayName *string, ocpus, memoryInGBs *float32,
-	details []core.InstanceAgentPluginConfigDetails, disable *bool) (core.UpdateInstanceResponse, error) {
+// updateInstance 修改实例的名称、OCPU/内存和 Oracle Cloud Agent 配置, 为 nil 的参数保持不变
+func updateInstance(acc *Account, instanceId *string, displayName *string, ocpus, memoryInGBs *float32,
+	agentConfig *core.UpdateInstanceAgentConfigDetails) (core.Instance, error) {
 	updateInstanceDetails := core.UpdateInstanceDetails{}
 	if displayName != nil && *displayName != "" {
 		updateInstanceDetails.DisplayName = displayName
@@ -929,54 +1764,83 @@ func updateInstance(instanceId *string, displayName *string, ocpus, memoryInGBs
 	if memoryInGBs != nil && *memoryInGBs > 0 {
 		shapeConfig.MemoryInGBs = memoryInGBs
 	}
-	updateInstanceDetails.ShapeConfig = &shapeConfig
-	if disable != nil && details != nil {
-		for i := 0; i < len(details); i++ {
-			if *disable {
-				details[i].DesiredState = core.InstanceAgentPluginConfigDetailsDesiredStateDisabled
-			} else {
-				details[i].DesiredState = core.InstanceAgentPluginConfigDetailsDesiredStateEnabled
-			}
-		}
-		agentConfig := core.UpdateInstanceAgentConfigDetails{
-			IsMonitoringDisabled:  disable,
-			IsManagementDisabled:  disable,
-			AreAllPluginsDisabled: disable,
-			PluginsConfig:         details,
-		}
-		updateInstanceDetails.AgentConfig = &agentConfig
+	// 非 flex 实例不能携带 shapeConfig, 只在需要修改配置时设置
+	if shapeConfig.Ocpus != nil || shapeConfig.MemoryInGBs != nil {
+		updateInstanceDetails.ShapeConfig = &shapeConfig
 	}
+	updateInstanceDetails.AgentConfig = agentConfig
 	req := core.UpdateInstanceRequest{
 		InstanceId:            instanceId,
 		UpdateInstanceDetails: updateInstanceDetails,
 		RequestMetadata:       getCustomRequestMetadataWithRetryPolicy(),
 	}
-	return computeClient.UpdateInstance(ctx, req)
+	resp, err := acc.Compute.UpdateInstance(ctx, req)
+	return resp.Instance, err
+}
+
+// resizeInstance 修改 flex 实例的 OCPU 和内存, 为 0 的参数保持不变。增加的部分会按 [LIMIT] 检查免费额度
+func resizeInstance(acc *Account, ins core.Instance, ocpus, memoryInGBs float32) (core.Instance, error) {
+	shape := stringValue(ins.Shape)
+	if !isResizableInstance(ins) {
+		return ins, fmt.Errorf("%s 不是 flex 实例, 无法修改 OCPU 和内存", shape)
+	}
+	if ocpus <= 0 {
+		ocpus = *ins.ShapeConfig.Ocpus
+	}
+	if memoryInGBs <= 0 {
+		memoryInGBs = *ins.ShapeConfig.MemoryInGBs
+	}
+	if ocpus == *ins.ShapeConfig.Ocpus && memoryInGBs == *ins.ShapeConfig.MemoryInGBs {
+		return ins, errors.New("OCPU 和内存与当前配置相同")
+	}
+	err := checkInstanceResizeQuota(acc, shape, ocpus-*ins.ShapeConfig.Ocpus, memoryInGBs-*ins.ShapeConfig.MemoryInGBs)
+	if err != nil {
+		return ins, err
+	}
+	return updateInstance(acc, ins.Id, nil, &ocpus, &memoryInGBs, nil)
+}
+
+// isResizableInstance 检查实例是否是可以修改 OCPU 和内存的 flex 实例
+func isResizableInstance(ins core.Instance) bool {
+	return strings.Contains(strings.ToLower(stringValue(ins.Shape)), "flex") &&
+		ins.ShapeConfig != nil && ins.ShapeConfig.Ocpus != nil && ins.ShapeConfig.MemoryInGBs != nil
+}
+
+// instanceAgentPlugins Oracle Cloud Agent 的常用插件, 实例未单独配置的插件按甲骨文的默认状态运行
+var instanceAgentPlugins = []string{
+	"Compute Instance Monitoring",
+	"Compute Instance Run Command",
+	"Custom Logs Monitoring",
+	"Management Agent",
+	"OS Management Hub Agent",
+	"OS Management Service Agent",
+	"Vulnerability Scanning",
+	"Block Volume Management",
+	"Bastion",
 }
 
-func instanceAction(instanceId *string, action core.InstanceActionActionEnum) (core.Instance, error) {
+func instanceAction(acc *Account, instanceId *string, action core.InstanceActionActionEnum) (core.Instance, error) {
 	req := core.InstanceActionRequest{
 		InstanceId:      instanceId,
 		Action:          action,
 		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
 	}
-	resp, err := computeClient.InstanceAction(ctx, req)
+	resp, err := acc.Compute.InstanceAction(ctx, req)
 	return resp.Instance, err
 }
 
-func changePublicIp(vnics []core.Vnic) (publicIp core.PublicIp, err error) {
+func changePublicIp(acc *Account, vnics []core.Vnic) (publicIp core.PublicIp, err error) {
 	var vnic core.Vnic
 	for _, v := range vnics {
 		if v.IsPrimary != nil && *v.IsPrimary {
 			vnic = v
 		}
 	}
-	fmt.Println("正在获取私有IP...")
+	printf("[%s] 正在获取私有IP...\n", acc.Name)
 	var privateIps []core.PrivateIp
-	privateIps, err = getPrivateIps(vnic.Id)
+	privateIps, err = getPrivateIps(acc, vnic.Id)
 	if err != nil {
-		printlnErr("获取私有IP失败", err.Error())
-		return
+		return publicIp, fmt.Errorf("获取私有IP失败: %v", err)
 	}
 	var privateIp core.PrivateIp
 	for _, p := range privateIps {
@@ -984,31 +1848,65 @@ func changePublicIp(vnics []core.Vnic) (publicIp core.PublicIp, err error) {
 			privateIp = p
 		}
 	}
-	fmt.Println("正在获取公共IP OCID...")
-	publicIp, err = getPublicIp(privateIp.Id)
+	printf("[%s] 正在获取公共IP OCID...\n", acc.Name)
+	publicIp, err = getAssignedPublicIp(acc, privateIp.Id)
 	if err != nil {
-		printlnErr("获取公共IP OCID 失败", err.Error())
+		return publicIp, fmt.Errorf("获取公共IP OCID 失败: %v", err)
 	}
-	fmt.Println("正在删除公共IP...")
-	_, err = deletePublicIp(publicIp.Id)
-	if err != nil {
-		printlnErr("删除公共IP 失败", err.Error())
+	if publicIp.Lifetime == core.PublicIpLifetimeReserved {
+		// 删除预留公共IP会将其释放, 需要先在网络管理中解绑
+		return publicIp, fmt.Errorf("实例绑定的是预留公共IP %s, 请先解绑", stringValue(publicIp.IpAddress))
 	}
-	time.Sleep(3 * time.Second)
-	fmt.Println("正在创建公共IP...")
-	publicIp, err = createPublicIp(privateIp.Id)
+	// 公共IP已被释放时直接创建新的临时公共IP
+	if publicIp.Id != nil {
+		printf("[%s] 正在删除公共IP...\n", acc.Name)
+		_, err = deletePublicIp(acc, publicIp.Id)
+		if err != nil {
+			return publicIp, fmt.Errorf("删除公共IP失败: %v", err)
+		}
+		time.Sleep(3 * time.Second)
+	} else {
+		printf("[%s] 实例未分配公共IP\n", acc.Name)
+	}
+	printf("[%s] 正在创建公共IP...\n", acc.Name)
+	publicIp, err = createPublicIp(acc, privateIp.Id)
 	return
 }
 
-func getInstanceVnics(instanceId *string) (vnics []core.Vnic, err error) {
-	vnicAttachments, _, err := ListVnicAttachments(ctx, computeClient, instanceId, nil)
+// rotatePublicIp 更换实例主网卡的公共IP。新IP落在 blocklist 中时继续更换, 最多更换 maxTries 次
+func rotatePublicIp(acc *Account, instanceId *string, blocklist []*net.IPNet, maxTries int) (ip string, err error) {
+	vnics, err := getInstanceVnics(acc, instanceId)
+	if err != nil {
+		return "", fmt.Errorf("获取实例VNIC失败: %v", err)
+	}
+	if maxTries < 1 {
+		maxTries = 1
+	}
+	for i := 1; i <= maxTries; i++ {
+		var publicIp core.PublicIp
+		publicIp, err = changePublicIp(acc, vnics)
+		if err != nil {
+			return "", err
+		}
+		ip = stringValue(publicIp.IpAddress)
+		if !ipInCIDRs(ip, blocklist) {
+			return ip, nil
+		}
+		printf("\033[1;33m[%s] 新的公共IP %s 在屏蔽的IP段中, 继续更换 (%d/%d)\033[0m\n", acc.Name, ip, i, maxTries)
+	}
+	return ip, fmt.Errorf("已更换 %d 次, 公共IP %s 仍在屏蔽的IP段中", maxTries, ip)
+}
+
+func getInstanceVnics(acc *Account, instanceId *string) (vnics []core.Vnic, err error) {
+	vnicAttachments, _, err := ListVnicAttachments(acc, instanceId, nil)
 	if err != nil {
 		return
 	}
 	for _, vnicAttachment := range vnicAttachments {
-		vnic, vnicErr := GetVnic(ctx, networkClient, vnicAttachment.VnicId)
+		vnic, vnicErr := GetVnic(acc, vnicAttachment.VnicId)
 		if vnicErr != nil {
-			fmt.Printf("GetVnic error: %s\n", vnicErr.Error())
+			// 输出到标准错误, 以免破坏 -o json/yaml 的输出
+			printlnErr(fmt.Sprintf("[%s] 获取VNIC失败", acc.Name), vnicErr.Error())
 			continue
 		}
 		vnics = append(vnics, vnic)
@@ -1016,55 +1914,205 @@ func getInstanceVnics(instanceId *string) (vnics []core.Vnic, err error) {
 	return
 }
 
-func getPrivateIps(vnicId *string) ([]core.PrivateIp, error) {
+func getPrivateIps(acc *Account, vnicId *string) ([]core.PrivateIp, error) {
 	req := core.ListPrivateIpsRequest{
 		VnicId:          vnicId,
 		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
 	}
-	resp, err := networkClient.ListPrivateIps(ctx, req)
+	resp, err := acc.Network.ListPrivateIps(ctx, req)
 	if err == nil && (resp.Items == nil || len(resp.Items) == 0) {
 		err = errors.New("私有IP为空")
 	}
 	return resp.Items, err
 }
 
-func getPublicIp(privateIpId *string) (core.PublicIp, error) {
-	req := core.GetPublicIpByPrivateIpIdRequest{
-		GetPublicIpByPrivateIpIdDetails: core.GetPublicIpByPrivateIpIdDetails{PrivateIpId: privateIpId},
-		RequestMetadata:                 getCustomRequestMetadataWithRetryPolicy(),
-	}
-	resp, err := networkClient.GetPublicIpByPrivateIpId(ctx, req)
-	if err == nil && resp.PublicIp.Id == nil {
-		err = errors.New("未分配公共IP")
-	}
-	return resp.PublicIp, err
-}
-
-func deletePublicIp(publicIpId *string) (core.DeletePublicIpResponse, error) {
+func deletePublicIp(acc *Account, publicIpId *string) (core.DeletePublicIpResponse, error) {
 	req := core.DeletePublicIpRequest{
 		PublicIpId:      publicIpId,
 		RequestMetadata: getCustomRequestMetadataWithRetryPolicy()}
-	return networkClient.DeletePublicIp(ctx, req)
+	return acc.Network.DeletePublicIp(ctx, req)
 }
 
-func createPublicIp(privateIpId *string) (core.PublicIp, error) {
+func createPublicIp(acc *Account, privateIpId *string) (core.PublicIp, error) {
 	req := core.CreatePublicIpRequest{
 		CreatePublicIpDetails: core.CreatePublicIpDetails{
-			CompartmentId: common.String(oracle.Tenancy),
+			CompartmentId: common.String(acc.Oracle.Tenancy),
 			Lifetime:      core.CreatePublicIpDetailsLifetimeEphemeral,
 			PrivateIpId:   privateIpId,
 		},
 		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
 	}
-	resp, err := networkClient.CreatePublicIp(ctx, req)
+	resp, err := acc.Network.CreatePublicIp(ctx, req)
+	return resp.PublicIp, err
+}
+
+// --- 预留公共IP ---
+
+// listReservedPublicIps 列出当前区域的所有预留公共IP
+func listReservedPublicIps(acc *Account) (publicIps []core.PublicIp, err error) {
+	req := core.ListPublicIpsRequest{
+		Scope:           core.ListPublicIpsScopeRegion,
+		CompartmentId:   common.String(acc.Oracle.Tenancy),
+		Lifetime:        core.ListPublicIpsLifetimeReserved,
+		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
+	}
+	for {
+		resp, err := acc.Network.ListPublicIps(ctx, req)
+		if err != nil {
+			return publicIps, err
+		}
+		publicIps = append(publicIps, resp.Items...)
+		if resp.OpcNextPage == nil {
+			return publicIps, nil
+		}
+		req.Page = resp.OpcNextPage
+	}
+}
+
+// createReservedPublicIp 创建预留公共IP, privateIpId 不为 nil 时同时绑定到该私有IP
+func createReservedPublicIp(acc *Account, displayName string, privateIpId *string) (core.PublicIp, error) {
+	details := core.CreatePublicIpDetails{
+		CompartmentId: common.String(acc.Oracle.Tenancy),
+		Lifetime:      core.CreatePublicIpDetailsLifetimeReserved,
+		PrivateIpId:   privateIpId,
+	}
+	if displayName != "" {
+		details.DisplayName = common.String(displayName)
+	}
+	req := core.CreatePublicIpRequest{
+		CreatePublicIpDetails: details,
+		RequestMetadata:       getCustomRequestMetadataWithRetryPolicy(),
+	}
+	resp, err := acc.Network.CreatePublicIp(ctx, req)
+	return resp.PublicIp, err
+}
+
+// updatePublicIpAssignment 将预留公共IP绑定到私有IP, privateIpId 为空字符串时解绑
+func updatePublicIpAssignment(acc *Account, publicIpId *string, privateIpId string) (core.PublicIp, error) {
+	req := core.UpdatePublicIpRequest{
+		PublicIpId:            publicIpId,
+		UpdatePublicIpDetails: core.UpdatePublicIpDetails{PrivateIpId: common.String(privateIpId)},
+		RequestMetadata:       getCustomRequestMetadataWithRetryPolicy(),
+	}
+	resp, err := acc.Network.UpdatePublicIp(ctx, req)
 	return resp.PublicIp, err
 }
 
-func getInstancePublicIps(instanceId *string) (ips []string, err error) {
+// getInstancePrimaryPrivateIp 获取实例主网卡的主私有IP
+func getInstancePrimaryPrivateIp(acc *Account, instanceId *string) (privateIp core.PrivateIp, err error) {
+	vnics, err := getInstanceVnics(acc, instanceId)
+	if err != nil {
+		return
+	}
+	var vnic core.Vnic
+	for _, v := range vnics {
+		if v.IsPrimary != nil && *v.IsPrimary {
+			vnic = v
+		}
+	}
+	if vnic.Id == nil {
+		return privateIp, errors.New("未找到实例的主网卡")
+	}
+	privateIps, err := getPrivateIps(acc, vnic.Id)
+	if err != nil {
+		return
+	}
+	for _, p := range privateIps {
+		if p.IsPrimary != nil && *p.IsPrimary {
+			return p, nil
+		}
+	}
+	return privateIp, errors.New("未找到主网卡的主私有IP")
+}
+
+// getAssignedPublicIp 获取私有IP当前绑定的公共IP, 未绑定时返回的 Id 为 nil
+func getAssignedPublicIp(acc *Account, privateIpId *string) (core.PublicIp, error) {
+	req := core.GetPublicIpByPrivateIpIdRequest{
+		GetPublicIpByPrivateIpIdDetails: core.GetPublicIpByPrivateIpIdDetails{PrivateIpId: privateIpId},
+		RequestMetadata:                 getCustomRequestMetadataWithRetryPolicy(),
+	}
+	resp, err := acc.Network.GetPublicIpByPrivateIpId(ctx, req)
+	if servErr, ok := common.IsServiceError(err); ok && servErr.GetHTTPStatusCode() == http.StatusNotFound {
+		return core.PublicIp{}, nil
+	}
+	return resp.PublicIp, err
+}
+
+// assignReservedPublicIp 将预留公共IP绑定到实例的主私有IP。实例已有临时公共IP时先将其删除
+func assignReservedPublicIp(acc *Account, publicIpId, instanceId *string) (core.PublicIp, error) {
+	privateIp, err := getInstancePrimaryPrivateIp(acc, instanceId)
+	if err != nil {
+		return core.PublicIp{}, fmt.Errorf("获取实例私有IP失败: %v", err)
+	}
+	current, err := getAssignedPublicIp(acc, privateIp.Id)
+	if err != nil {
+		return core.PublicIp{}, fmt.Errorf("获取实例公共IP失败: %v", err)
+	}
+	if current.Id != nil {
+		if *current.Id == *publicIpId {
+			return current, nil
+		}
+		if current.Lifetime == core.PublicIpLifetimeReserved {
+			return core.PublicIp{}, fmt.Errorf("实例已绑定预留公共IP %s, 请先解绑", stringValue(current.IpAddress))
+		}
+		_, err = deletePublicIp(acc, current.Id)
+		if err != nil {
+			return core.PublicIp{}, fmt.Errorf("删除实例的临时公共IP失败: %v", err)
+		}
+		time.Sleep(3 * time.Second)
+	}
+	return updatePublicIpAssignment(acc, publicIpId, *privateIp.Id)
+}
+
+// convertToReservedPublicIp 将实例的临时公共IP替换为预留公共IP, 之后终止实例时该IP会被保留。
+// 甲骨文不支持直接转换, 因此先创建未绑定的预留公共IP, 再删除临时公共IP并绑定预留公共IP, 公共IP地址会改变一次。
+// 绑定失败时会重新分配临时公共IP, 实例不会失去公共IP
+func convertToReservedPublicIp(acc *Account, instanceId *string, displayName string) (core.PublicIp, error) {
+	privateIp, err := getInstancePrimaryPrivateIp(acc, instanceId)
+	if err != nil {
+		return core.PublicIp{}, fmt.Errorf("获取实例私有IP失败: %v", err)
+	}
+	current, err := getAssignedPublicIp(acc, privateIp.Id)
+	if err != nil {
+		return core.PublicIp{}, fmt.Errorf("获取实例公共IP失败: %v", err)
+	}
+	if current.Id == nil {
+		return createReservedPublicIp(acc, displayName, privateIp.Id)
+	}
+	if current.Lifetime == core.PublicIpLifetimeReserved {
+		return current, fmt.Errorf("实例的公共IP %s 已经是预留公共IP", stringValue(current.IpAddress))
+	}
+
+	reserved, err := createReservedPublicIp(acc, displayName, nil)
+	if err != nil {
+		return core.PublicIp{}, fmt.Errorf("创建预留公共IP失败: %v", err)
+	}
+	_, err = deletePublicIp(acc, current.Id)
+	if err != nil {
+		if _, delErr := deletePublicIp(acc, reserved.Id); delErr != nil {
+			printlnErr(fmt.Sprintf("[%s] 释放未使用的预留公共IP %s 失败", acc.Name, stringValue(reserved.IpAddress)), delErr.Error())
+		}
+		return core.PublicIp{}, fmt.Errorf("删除实例的临时公共IP失败: %v", err)
+	}
+	time.Sleep(3 * time.Second)
+	publicIp, err := updatePublicIpAssignment(acc, reserved.Id, *privateIp.Id)
+	if err == nil {
+		return publicIp, nil
+	}
+	ephemeral, createErr := createPublicIp(acc, privateIp.Id)
+	if createErr != nil {
+		return core.PublicIp{}, fmt.Errorf("绑定预留公共IP %s 失败: %v; 重新分配临时公共IP也失败, 实例当前没有公共IP: %v",
+			stringValue(reserved.IpAddress), err, createErr)
+	}
+	return ephemeral, fmt.Errorf("绑定预留公共IP %s 失败: %v; 已重新分配临时公共IP %s, 预留公共IP已保留, 可稍后手动绑定",
+		stringValue(reserved.IpAddress), err, stringValue(ephemeral.IpAddress))
+}
+
+func getInstancePublicIps(acc *Account, instanceId *string) (ips []string, err error) {
 	var ins core.Instance
 	for i := 0; i < 100; i++ {
 		if ins.LifecycleState != core.InstanceLifecycleStateRunning {
-			ins, err = getInstance(instanceId)
+			ins, err = getInstance(acc, instanceId)
 			if err != nil {
 				continue
 			}
@@ -1074,15 +2122,15 @@ func getInstancePublicIps(instanceId *string) (ips []string, err error) {
 			}
 		}
 		var vnicAttachments []core.VnicAttachment
-		vnicAttachments, _, err = ListVnicAttachments(ctx, computeClient, instanceId, nil)
+		vnicAttachments, _, err = ListVnicAttachments(acc, instanceId, nil)
 		if err != nil {
 			continue
 		}
 		if len(vnicAttachments) > 0 {
 			for _, vnicAttachment := range vnicAttachments {
-				vnic, vnicErr := GetVnic(ctx, networkClient, vnicAttachment.VnicId)
+				vnic, vnicErr := GetVnic(acc, vnicAttachment.VnicId)
 				if vnicErr != nil {
-					printf("GetVnic error: %s\n", vnicErr.Error())
+					printf("[%s] GetVnic error: %s\n", acc.Name, vnicErr.Error())
 					continue
 				}
 				if vnic.PublicIp != nil && *vnic.PublicIp != "" {
@@ -1096,26 +2144,75 @@ func getInstancePublicIps(instanceId *string) (ips []string, err error) {
 	return
 }
 
-func getBootVolumes(availabilityDomain *string) ([]core.BootVolume, error) {
+func getBootVolumes(acc *Account, availabilityDomain *string) ([]core.BootVolume, error) {
 	req := core.ListBootVolumesRequest{
 		AvailabilityDomain: availabilityDomain,
-		CompartmentId:      common.String(oracle.Tenancy),
+		CompartmentId:      common.String(acc.Oracle.Tenancy),
 		RequestMetadata:    getCustomRequestMetadataWithRetryPolicy(),
 	}
-	resp, err := storageClient.ListBootVolumes(ctx, req)
+	resp, err := acc.Storage.ListBootVolumes(ctx, req)
 	return resp.Items, err
 }
 
-func getBootVolume(bootVolumeId *string) (core.BootVolume, error) {
+// listAllBootVolumes 并发获取账号所有可用域下的引导卷, 部分可用域失败时返回已获取到的引导卷和错误
+func listAllBootVolumes(acc *Account) ([]core.BootVolume, error) {
+	availabilityDomains, err := ListAvailabilityDomains(acc)
+	if err != nil {
+		return nil, err
+	}
+	volumesByAd := make([][]core.BootVolume, len(availabilityDomains))
+	errs := make([]error, len(availabilityDomains))
+	var wg sync.WaitGroup
+	for i, ad := range availabilityDomains {
+		wg.Add(1)
+		go func(i int, adName *string) {
+			defer wg.Done()
+			volumesByAd[i], errs[i] = getBootVolumes(acc, adName)
+		}(i, ad.Name)
+	}
+	wg.Wait()
+
+	var bootVolumes []core.BootVolume
+	for i, volumes := range volumesByAd {
+		if errs[i] != nil {
+			err = errs[i]
+			continue
+		}
+		bootVolumes = append(bootVolumes, volumes...)
+	}
+	return bootVolumes, err
+}
+
+// getInstanceBootVolume 获取实例当前挂载的引导卷
+func getInstanceBootVolume(acc *Account, ins core.Instance) (core.BootVolume, error) {
+	req := core.ListBootVolumeAttachmentsRequest{
+		AvailabilityDomain: ins.AvailabilityDomain,
+		CompartmentId:      ins.CompartmentId,
+		InstanceId:         ins.Id,
+		RequestMetadata:    getCustomRequestMetadataWithRetryPolicy(),
+	}
+	resp, err := acc.Compute.ListBootVolumeAttachments(ctx, req)
+	if err != nil {
+		return core.BootVolume{}, err
+	}
+	for _, attachment := range resp.Items {
+		if attachment.LifecycleState == core.BootVolumeAttachmentLifecycleStateAttached {
+			return getBootVolume(acc, attachment.BootVolumeId)
+		}
+	}
+	return core.BootVolume{}, errors.New("未找到已挂载的引导卷")
+}
+
+func getBootVolume(acc *Account, bootVolumeId *string) (core.BootVolume, error) {
 	req := core.GetBootVolumeRequest{
 		BootVolumeId:    bootVolumeId,
 		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
 	}
-	resp, err := storageClient.GetBootVolume(ctx, req)
+	resp, err := acc.Storage.GetBootVolume(ctx, req)
 	return resp.BootVolume, err
 }
 
-func updateBootVolume(bootVolumeId *string, sizeInGBs *int64, vpusPerGB *int64) (core.BootVolume, error) {
+func updateBootVolume(acc *Account, bootVolumeId *string, sizeInGBs *int64, vpusPerGB *int64) (core.BootVolume, error) {
 	updateBootVolumeDetails := core.UpdateBootVolumeDetails{}
 	if sizeInGBs != nil {
 		updateBootVolumeDetails.SizeInGBs = sizeInGBs
@@ -1128,35 +2225,883 @@ func updateBootVolume(bootVolumeId *string, sizeInGBs *int64, vpusPerGB *int64)
 		UpdateBootVolumeDetails: updateBootVolumeDetails,
 		RequestMetadata:         getCustomRequestMetadataWithRetryPolicy(),
 	}
-	resp, err := storageClient.UpdateBootVolume(ctx, req)
+	resp, err := acc.Storage.UpdateBootVolume(ctx, req)
 	return resp.BootVolume, err
 }
 
-func deleteBootVolume(bootVolumeId *string) (*http.Response, error) {
+func deleteBootVolume(acc *Account, bootVolumeId *string) (*http.Response, error) {
 	req := core.DeleteBootVolumeRequest{
 		BootVolumeId:    bootVolumeId,
 		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
 	}
-	resp, err := storageClient.DeleteBootVolume(ctx, req)
+	resp, err := acc.Storage.DeleteBootVolume(ctx, req)
 	return resp.RawResponse, err
 }
 
-func detachBootVolume(bootVolumeAttachmentId *string) (*http.Response, error) {
+func detachBootVolume(acc *Account, bootVolumeAttachmentId *string) (*http.Response, error) {
 	req := core.DetachBootVolumeRequest{
 		BootVolumeAttachmentId: bootVolumeAttachmentId,
 		RequestMetadata:        getCustomRequestMetadataWithRetryPolicy(),
 	}
-	resp, err := computeClient.DetachBootVolume(ctx, req)
+	resp, err := acc.Compute.DetachBootVolume(ctx, req)
 	return resp.RawResponse, err
 }
 
-func listBootVolumeAttachments(availabilityDomain, compartmentId, bootVolumeId *string) ([]core.BootVolumeAttachment, error) {
+func listBootVolumeAttachments(acc *Account, availabilityDomain, compartmentId, bootVolumeId *string) ([]core.BootVolumeAttachment, error) {
 	req := core.ListBootVolumeAttachmentsRequest{
 		AvailabilityDomain: availabilityDomain,
 		CompartmentId:      compartmentId,
 		BootVolumeId:       bootVolumeId,
 		RequestMetadata:    getCustomRequestMetadataWithRetryPolicy(),
 	}
-	resp, err := computeClient.ListBootVolumeAttachments(ctx, req)
+	resp, err := acc.Compute.ListBootVolumeAttachments(ctx, req)
 	return resp.Items, err
 }
+
+// --- 引导卷备份 ---
+
+// listBootVolumeBackups 获取账号下的引导卷备份, bootVolumeId 为 nil 时返回所有引导卷的备份, 按创建时间从新到旧排列
+func listBootVolumeBackups(acc *Account, bootVolumeId *string) ([]core.BootVolumeBackup, error) {
+	var backups []core.BootVolumeBackup
+	req := core.ListBootVolumeBackupsRequest{
+		CompartmentId:   common.String(acc.Oracle.Tenancy),
+		BootVolumeId:    bootVolumeId,
+		SortBy:          core.ListBootVolumeBackupsSortByTimecreated,
+		SortOrder:       core.ListBootVolumeBackupsSortOrderDesc,
+		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
+	}
+	for {
+		resp, err := acc.Storage.ListBootVolumeBackups(ctx, req)
+		if err != nil {
+			return backups, err
+		}
+		for _, backup := range resp.Items {
+			if backup.LifecycleState != core.BootVolumeBackupLifecycleStateTerminated {
+				backups = append(backups, backup)
+			}
+		}
+		if resp.OpcNextPage == nil {
+			return backups, nil
+		}
+		req.Page = resp.OpcNextPage
+	}
+}
+
+// createBootVolumeBackup 创建引导卷的手动备份, incremental 为 true 时创建增量备份
+func createBootVolumeBackup(acc *Account, bootVolumeId *string, displayName string, incremental bool) (core.BootVolumeBackup, error) {
+	details := core.CreateBootVolumeBackupDetails{
+		BootVolumeId: bootVolumeId,
+		Type:         core.CreateBootVolumeBackupDetailsTypeFull,
+	}
+	if incremental {
+		details.Type = core.CreateBootVolumeBackupDetailsTypeIncremental
+	}
+	if displayName != "" {
+		details.DisplayName = common.String(displayName)
+	}
+	req := core.CreateBootVolumeBackupRequest{
+		CreateBootVolumeBackupDetails: details,
+		RequestMetadata:               getCustomRequestMetadataWithRetryPolicy(),
+	}
+	resp, err := acc.Storage.CreateBootVolumeBackup(ctx, req)
+	return resp.BootVolumeBackup, err
+}
+
+func getBootVolumeBackup(acc *Account, backupId *string) (core.BootVolumeBackup, error) {
+	req := core.GetBootVolumeBackupRequest{
+		BootVolumeBackupId: backupId,
+		RequestMetadata:    getCustomRequestMetadataWithRetryPolicy(),
+	}
+	resp, err := acc.Storage.GetBootVolumeBackup(ctx, req)
+	return resp.BootVolumeBackup, err
+}
+
+func deleteBootVolumeBackup(acc *Account, backupId *string) error {
+	req := core.DeleteBootVolumeBackupRequest{
+		BootVolumeBackupId: backupId,
+		RequestMetadata:    getCustomRequestMetadataWithRetryPolicy(),
+	}
+	_, err := acc.Storage.DeleteBootVolumeBackup(ctx, req)
+	return err
+}
+
+// restoreBootVolumeBackup 从备份恢复出一个新的引导卷, 新引导卷可以用于创建实例或替换实例的引导卷
+func restoreBootVolumeBackup(acc *Account, backupId, availabilityDomain *string, displayName string) (core.BootVolume, error) {
+	details := core.CreateBootVolumeDetails{
+		AvailabilityDomain: availabilityDomain,
+		CompartmentId:      common.String(acc.Oracle.Tenancy),
+		SourceDetails:      core.BootVolumeSourceFromBootVolumeBackupDetails{Id: backupId},
+	}
+	if displayName != "" {
+		details.DisplayName = common.String(displayName)
+	}
+	req := core.CreateBootVolumeRequest{
+		CreateBootVolumeDetails: details,
+		RequestMetadata:         getCustomRequestMetadataWithRetryPolicy(),
+	}
+	resp, err := acc.Storage.CreateBootVolume(ctx, req)
+	return resp.BootVolume, err
+}
+
+// pruneBootVolumeBackups 每个引导卷只保留最新的 keep 个手动备份, 返回需要删除的备份 (从新到旧)。
+// 由备份策略自动创建的备份由策略管理, 不会被删除; 没有创建时间的备份无法判断新旧, 视为最新的备份
+func pruneBootVolumeBackups(backups []core.BootVolumeBackup, keep int) []core.BootVolumeBackup {
+	var manual []core.BootVolumeBackup
+	for _, backup := range backups {
+		if backup.SourceType == core.BootVolumeBackupSourceTypeManual && backup.LifecycleState == core.BootVolumeBackupLifecycleStateAvailable {
+			manual = append(manual, backup)
+		}
+	}
+	sort.SliceStable(manual, func(i, j int) bool {
+		if manual[i].TimeCreated == nil || manual[j].TimeCreated == nil {
+			return manual[i].TimeCreated == nil && manual[j].TimeCreated != nil
+		}
+		return manual[i].TimeCreated.After(manual[j].TimeCreated.Time)
+	})
+	var pruned []core.BootVolumeBackup
+	count := map[string]int{}
+	for _, backup := range manual {
+		id := stringValue(backup.BootVolumeId)
+		count[id]++
+		if count[id] > keep {
+			pruned = append(pruned, backup)
+		}
+	}
+	return pruned
+}
+
+// --- 备份策略 ---
+
+// listVolumeBackupPolicies 获取甲骨文预定义的备份策略 (bronze/silver/gold) 和账号中自定义的备份策略
+func listVolumeBackupPolicies(acc *Account) ([]core.VolumeBackupPolicy, error) {
+	var policies []core.VolumeBackupPolicy
+	for _, compartmentId := range []*string{nil, common.String(acc.Oracle.Tenancy)} {
+		req := core.ListVolumeBackupPoliciesRequest{
+			CompartmentId:   compartmentId,
+			RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
+		}
+		for {
+			resp, err := acc.Storage.ListVolumeBackupPolicies(ctx, req)
+			if err != nil {
+				return policies, err
+			}
+			policies = append(policies, resp.Items...)
+			if resp.OpcNextPage == nil {
+				break
+			}
+			req.Page = resp.OpcNextPage
+		}
+	}
+	return policies, nil
+}
+
+// findVolumeBackupPolicy 按名称 (如 gold) 或 OCID 查找备份策略
+func findVolumeBackupPolicy(acc *Account, nameOrId string) (core.VolumeBackupPolicy, error) {
+	policies, err := listVolumeBackupPolicies(acc)
+	if err != nil {
+		return core.VolumeBackupPolicy{}, err
+	}
+	for _, policy := range policies {
+		if stringValue(policy.Id) == nameOrId || strings.EqualFold(stringValue(policy.DisplayName), nameOrId) {
+			return policy, nil
+		}
+	}
+	return core.VolumeBackupPolicy{}, fmt.Errorf("未找到备份策略 %s", nameOrId)
+}
+
+// getVolumeBackupPolicyAssignment 获取引导卷或块存储卷当前的备份策略, 没有设置时返回 nil
+func getVolumeBackupPolicyAssignment(acc *Account, assetId *string) (*core.VolumeBackupPolicyAssignment, error) {
+	req := core.GetVolumeBackupPolicyAssetAssignmentRequest{
+		AssetId:         assetId,
+		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
+	}
+	resp, err := acc.Storage.GetVolumeBackupPolicyAssetAssignment(ctx, req)
+	if err != nil || len(resp.Items) == 0 {
+		return nil, err
+	}
+	return &resp.Items[0], nil
+}
+
+// setVolumeBackupPolicy 为引导卷或块存储卷设置备份策略, policyId 为 nil 时取消备份策略
+func setVolumeBackupPolicy(acc *Account, assetId, policyId *string) error {
+	assignment, err := getVolumeBackupPolicyAssignment(acc, assetId)
+	if err != nil {
+		return err
+	}
+	if assignment != nil {
+		if policyId != nil && stringValue(assignment.PolicyId) == *policyId {
+			return nil
+		}
+		_, err = acc.Storage.DeleteVolumeBackupPolicyAssignment(ctx, core.DeleteVolumeBackupPolicyAssignmentRequest{
+			PolicyAssignmentId: assignment.Id,
+			RequestMetadata:    getCustomRequestMetadataWithRetryPolicy(),
+		})
+		if err != nil {
+			return fmt.Errorf("取消原备份策略失败: %v", err)
+		}
+	}
+	if policyId == nil {
+		return nil
+	}
+	_, err = acc.Storage.CreateVolumeBackupPolicyAssignment(ctx, core.CreateVolumeBackupPolicyAssignmentRequest{
+		CreateVolumeBackupPolicyAssignmentDetails: core.CreateVolumeBackupPolicyAssignmentDetails{
+			AssetId:  assetId,
+			PolicyId: policyId,
+		},
+		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
+	})
+	return err
+}
+
+// --- 块存储卷 ---
+
+// listAllVolumes 获取账号所有可用域下未终止的块存储卷
+func listAllVolumes(acc *Account) ([]core.Volume, error) {
+	var volumes []core.Volume
+	req := core.ListVolumesRequest{
+		CompartmentId:   common.String(acc.Oracle.Tenancy),
+		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
+	}
+	for {
+		resp, err := acc.Storage.ListVolumes(ctx, req)
+		if err != nil {
+			return volumes, err
+		}
+		for _, v := range resp.Items {
+			if v.LifecycleState != core.VolumeLifecycleStateTerminated {
+				volumes = append(volumes, v)
+			}
+		}
+		if resp.OpcNextPage == nil {
+			return volumes, nil
+		}
+		req.Page = resp.OpcNextPage
+	}
+}
+
+func getVolume(acc *Account, volumeId *string) (core.Volume, error) {
+	req := core.GetVolumeRequest{
+		VolumeId:        volumeId,
+		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
+	}
+	resp, err := acc.Storage.GetVolume(ctx, req)
+	return resp.Volume, err
+}
+
+// createVolume 在指定可用性域创建块存储卷, vpusPerGB 为 0 表示低成本, 10 为均衡, 20 为高性能
+func createVolume(acc *Account, availabilityDomain *string, displayName string, sizeInGBs, vpusPerGB int64) (core.Volume, error) {
+	details := core.CreateVolumeDetails{
+		CompartmentId:      common.String(acc.Oracle.Tenancy),
+		AvailabilityDomain: availabilityDomain,
+		SizeInGBs:          common.Int64(sizeInGBs),
+		VpusPerGB:          common.Int64(vpusPerGB),
+	}
+	if displayName != "" {
+		details.DisplayName = common.String(displayName)
+	}
+	req := core.CreateVolumeRequest{
+		CreateVolumeDetails: details,
+		RequestMetadata:     getCustomRequestMetadataWithRetryPolicy(),
+	}
+	resp, err := acc.Storage.CreateVolume(ctx, req)
+	return resp.Volume, err
+}
+
+// updateVolume 修改块存储卷的大小或性能, 大小只能增加
+func updateVolume(acc *Account, volumeId *string, sizeInGBs *int64, vpusPerGB *int64) (core.Volume, error) {
+	req := core.UpdateVolumeRequest{
+		VolumeId: volumeId,
+		UpdateVolumeDetails: core.UpdateVolumeDetails{
+			SizeInGBs: sizeInGBs,
+			VpusPerGB: vpusPerGB,
+		},
+		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
+	}
+	resp, err := acc.Storage.UpdateVolume(ctx, req)
+	return resp.Volume, err
+}
+
+func deleteVolume(acc *Account, volumeId *string) error {
+	req := core.DeleteVolumeRequest{
+		VolumeId:        volumeId,
+		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
+	}
+	_, err := acc.Storage.DeleteVolume(ctx, req)
+	return err
+}
+
+// listVolumeAttachments 获取块存储卷或实例的卷挂载, 不包括已分离的挂载
+func listVolumeAttachments(acc *Account, volumeId, instanceId *string) ([]core.VolumeAttachment, error) {
+	var attachments []core.VolumeAttachment
+	req := core.ListVolumeAttachmentsRequest{
+		CompartmentId:   common.String(acc.Oracle.Tenancy),
+		VolumeId:        volumeId,
+		InstanceId:      instanceId,
+		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
+	}
+	for {
+		resp, err := acc.Compute.ListVolumeAttachments(ctx, req)
+		if err != nil {
+			return attachments, err
+		}
+		for _, attachment := range resp.Items {
+			if attachment.GetLifecycleState() != core.VolumeAttachmentLifecycleStateDetached {
+				attachments = append(attachments, attachment)
+			}
+		}
+		if resp.OpcNextPage == nil {
+			return attachments, nil
+		}
+		req.Page = resp.OpcNextPage
+	}
+}
+
+// attachVolume 将块存储卷挂载到实例并等待挂载完成。
+// iscsi 为 true 时使用 iSCSI 挂载, 需要在实例中执行 iscsiadm 命令, 否则使用半虚拟化挂载
+func attachVolume(acc *Account, instanceId, volumeId *string, iscsi, readOnly, shareable bool) (core.VolumeAttachment, error) {
+	var details core.AttachVolumeDetails
+	if iscsi {
+		details = core.AttachIScsiVolumeDetails{
+			InstanceId:  instanceId,
+			VolumeId:    volumeId,
+			IsReadOnly:  common.Bool(readOnly),
+			IsShareable: common.Bool(shareable),
+		}
+	} else {
+		details = core.AttachParavirtualizedVolumeDetails{
+			InstanceId:  instanceId,
+			VolumeId:    volumeId,
+			IsReadOnly:  common.Bool(readOnly),
+			IsShareable: common.Bool(shareable),
+		}
+	}
+	req := core.AttachVolumeRequest{
+		AttachVolumeDetails: details,
+		RequestMetadata:     getCustomRequestMetadataWithRetryPolicy(),
+	}
+	resp, err := acc.Compute.AttachVolume(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	pollUntilAttached := func(r common.OCIOperationResponse) bool {
+		if converted, ok := r.Response.(core.GetVolumeAttachmentResponse); ok {
+			return converted.VolumeAttachment.GetLifecycleState() == core.VolumeAttachmentLifecycleStateAttaching
+		}
+		return true
+	}
+	getReq := core.GetVolumeAttachmentRequest{
+		VolumeAttachmentId: resp.VolumeAttachment.GetId(),
+		RequestMetadata:    getCustomRequestMetadataWithCustomizedRetryPolicy(pollUntilAttached),
+	}
+	getResp, err := acc.Compute.GetVolumeAttachment(ctx, getReq)
+	if err != nil {
+		return resp.VolumeAttachment, err
+	}
+	if state := getResp.VolumeAttachment.GetLifecycleState(); state != core.VolumeAttachmentLifecycleStateAttached {
+		return getResp.VolumeAttachment, fmt.Errorf("挂载块存储卷失败, 状态: %s", state)
+	}
+	return getResp.VolumeAttachment, nil
+}
+
+func detachVolume(acc *Account, volumeAttachmentId *string) error {
+	req := core.DetachVolumeRequest{
+		VolumeAttachmentId: volumeAttachmentId,
+		RequestMetadata:    getCustomRequestMetadataWithRetryPolicy(),
+	}
+	_, err := acc.Compute.DetachVolume(ctx, req)
+	return err
+}
+
+// iscsiCommands 返回在实例中连接或断开 iSCSI 卷需要执行的命令, 非 iSCSI 挂载时返回 nil
+func iscsiCommands(attachment core.VolumeAttachment, attach bool) []string {
+	a, ok := attachment.(core.IScsiVolumeAttachment)
+	if !ok || a.Iqn == nil || a.Ipv4 == nil || a.Port == nil {
+		return nil
+	}
+	target := fmt.Sprintf("-T %s -p %s:%d", *a.Iqn, *a.Ipv4, *a.Port)
+	if !attach {
+		return []string{
+			"sudo iscsiadm -m node " + target + " -u",
+			"sudo iscsiadm -m node -o delete " + target,
+		}
+	}
+	cmds := []string{
+		"sudo iscsiadm -m node -o new " + target,
+		"sudo iscsiadm -m node -o update " + target + " -n node.startup -v automatic",
+	}
+	if a.ChapUsername != nil && a.ChapSecret != nil {
+		cmds = append(cmds,
+			"sudo iscsiadm -m node -o update "+target+" -n node.session.auth.authmethod -v CHAP",
+			"sudo iscsiadm -m node -o update "+target+" -n node.session.auth.username -v "+*a.ChapUsername,
+			"sudo iscsiadm -m node -o update "+target+" -n node.session.auth.password -v "+*a.ChapSecret)
+	}
+	return append(cmds, "sudo iscsiadm -m node "+target+" -l")
+}
+
+// --- 控制台连接 ---
+
+// listConsoleConnections 获取实例未删除的控制台连接
+func listConsoleConnections(acc *Account, instanceId *string) ([]core.InstanceConsoleConnection, error) {
+	req := core.ListInstanceConsoleConnectionsRequest{
+		CompartmentId:   common.String(acc.Oracle.Tenancy),
+		InstanceId:      instanceId,
+		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
+	}
+	resp, err := acc.Compute.ListInstanceConsoleConnections(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	var connections []core.InstanceConsoleConnection
+	for _, c := range resp.Items {
+		if c.LifecycleState != core.InstanceConsoleConnectionLifecycleStateDeleted {
+			connections = append(connections, c)
+		}
+	}
+	return connections, nil
+}
+
+// createConsoleConnection 为实例创建控制台连接, 使用 publicKey 对应的私钥登录, 等待连接可用后返回
+func createConsoleConnection(acc *Account, instanceId *string, publicKey string) (core.InstanceConsoleConnection, error) {
+	req := core.CreateInstanceConsoleConnectionRequest{
+		CreateInstanceConsoleConnectionDetails: core.CreateInstanceConsoleConnectionDetails{
+			InstanceId: instanceId,
+			PublicKey:  common.String(publicKey),
+		},
+		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
+	}
+	resp, err := acc.Compute.CreateInstanceConsoleConnection(ctx, req)
+	if err != nil {
+		return resp.InstanceConsoleConnection, err
+	}
+	pollUntilActive := func(r common.OCIOperationResponse) bool {
+		if converted, ok := r.Response.(core.GetInstanceConsoleConnectionResponse); ok {
+			return converted.LifecycleState == core.InstanceConsoleConnectionLifecycleStateCreating
+		}
+		return true
+	}
+	getReq := core.GetInstanceConsoleConnectionRequest{
+		InstanceConsoleConnectionId: resp.Id,
+		RequestMetadata:             getCustomRequestMetadataWithCustomizedRetryPolicy(pollUntilActive),
+	}
+	getResp, err := acc.Compute.GetInstanceConsoleConnection(ctx, getReq)
+	if err != nil {
+		return resp.InstanceConsoleConnection, err
+	}
+	if getResp.LifecycleState != core.InstanceConsoleConnectionLifecycleStateActive {
+		return getResp.InstanceConsoleConnection, fmt.Errorf("创建控制台连接失败, 状态: %s", getResp.LifecycleState)
+	}
+	return getResp.InstanceConsoleConnection, nil
+}
+
+func deleteConsoleConnection(acc *Account, connectionId *string) error {
+	req := core.DeleteInstanceConsoleConnectionRequest{
+		InstanceConsoleConnectionId: connectionId,
+		RequestMetadata:             getCustomRequestMetadataWithRetryPolicy(),
+	}
+	_, err := acc.Compute.DeleteInstanceConsoleConnection(ctx, req)
+	return err
+}
+
+// captureConsoleHistory 截取实例串口控制台最近的输出, 等待截取完成后返回
+func captureConsoleHistory(acc *Account, instanceId *string) (core.ConsoleHistory, error) {
+	req := core.CaptureConsoleHistoryRequest{
+		CaptureConsoleHistoryDetails: core.CaptureConsoleHistoryDetails{InstanceId: instanceId},
+		RequestMetadata:              getCustomRequestMetadataWithRetryPolicy(),
+	}
+	resp, err := acc.Compute.CaptureConsoleHistory(ctx, req)
+	if err != nil {
+		return resp.ConsoleHistory, err
+	}
+	pollUntilDone := func(r common.OCIOperationResponse) bool {
+		if converted, ok := r.Response.(core.GetConsoleHistoryResponse); ok {
+			return converted.LifecycleState == core.ConsoleHistoryLifecycleStateRequested || converted.LifecycleState == core.ConsoleHistoryLifecycleStateGettingHistory
+		}
+		return true
+	}
+	getReq := core.GetConsoleHistoryRequest{
+		InstanceConsoleHistoryId: resp.Id,
+		RequestMetadata:          getCustomRequestMetadataWithCustomizedRetryPolicy(pollUntilDone),
+	}
+	getResp, err := acc.Compute.GetConsoleHistory(ctx, getReq)
+	if err != nil {
+		return resp.ConsoleHistory, err
+	}
+	if getResp.LifecycleState != core.ConsoleHistoryLifecycleStateSucceeded {
+		return getResp.ConsoleHistory, fmt.Errorf("截取控制台历史失败, 状态: %s", getResp.LifecycleState)
+	}
+	return getResp.ConsoleHistory, nil
+}
+
+// getConsoleHistoryContent 分段读取截取到的控制台历史的全部内容
+func getConsoleHistoryContent(acc *Account, historyId *string) (string, error) {
+	const length = 1024 * 1024 // 每次最多读取 1MB
+	var sb strings.Builder
+	for offset := 0; ; {
+		req := core.GetConsoleHistoryContentRequest{
+			InstanceConsoleHistoryId: historyId,
+			Offset:                   common.Int(offset),
+			Length:                   common.Int(length),
+			RequestMetadata:          getCustomRequestMetadataWithRetryPolicy(),
+		}
+		resp, err := acc.Compute.GetConsoleHistoryContent(ctx, req)
+		if err != nil {
+			return sb.String(), err
+		}
+		value := stringValue(resp.Value)
+		sb.WriteString(value)
+		offset += len(value)
+		if value == "" || resp.OpcBytesRemaining == nil || *resp.OpcBytesRemaining <= 0 {
+			return sb.String(), nil
+		}
+	}
+}
+
+func deleteConsoleHistory(acc *Account, historyId *string) error {
+	req := core.DeleteConsoleHistoryRequest{
+		InstanceConsoleHistoryId: historyId,
+		RequestMetadata:          getCustomRequestMetadataWithRetryPolicy(),
+	}
+	_, err := acc.Compute.DeleteConsoleHistory(ctx, req)
+	return err
+}
+
+// --- 自定义镜像 ---
+
+// listCustomImages 获取账号中的自定义镜像 (不包括甲骨文提供的系统镜像), 按创建时间从新到旧排列
+func listCustomImages(acc *Account) ([]core.Image, error) {
+	var images []core.Image
+	req := core.ListImagesRequest{
+		CompartmentId:   common.String(acc.Oracle.Tenancy),
+		SortBy:          core.ListImagesSortByTimecreated,
+		SortOrder:       core.ListImagesSortOrderDesc,
+		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
+	}
+	for {
+		resp, err := acc.Compute.ListImages(ctx, req)
+		if err != nil {
+			return images, err
+		}
+		for _, image := range resp.Items {
+			// 系统镜像不属于任何区间
+			if image.CompartmentId != nil && image.LifecycleState != core.ImageLifecycleStateDeleted {
+				images = append(images, image)
+			}
+		}
+		if resp.OpcNextPage == nil {
+			return images, nil
+		}
+		req.Page = resp.OpcNextPage
+	}
+}
+
+// findCustomImage 按名称查找可用的自定义镜像, 有多个同名镜像时返回最新的
+func findCustomImage(acc *Account, displayName string) (core.Image, error) {
+	images, err := listCustomImages(acc)
+	if err != nil {
+		return core.Image{}, err
+	}
+	for _, image := range images {
+		if stringValue(image.DisplayName) == displayName && image.LifecycleState == core.ImageLifecycleStateAvailable {
+			return image, nil
+		}
+	}
+	return core.Image{}, fmt.Errorf("未找到名称为 %s 的可用自定义镜像", displayName)
+}
+
+func getImage(acc *Account, imageId *string) (core.Image, error) {
+	req := core.GetImageRequest{
+		ImageId:         imageId,
+		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
+	}
+	resp, err := acc.Compute.GetImage(ctx, req)
+	return resp.Image, err
+}
+
+// createImage 从实例创建自定义镜像, 创建期间实例会被关机, 镜像创建需要几分钟到几十分钟, 不等待完成
+func createImage(acc *Account, instanceId *string, displayName string) (core.Image, error) {
+	details := core.CreateImageDetails{
+		CompartmentId: common.String(acc.Oracle.Tenancy),
+		InstanceId:    instanceId,
+	}
+	if displayName != "" {
+		details.DisplayName = common.String(displayName)
+	}
+	req := core.CreateImageRequest{
+		CreateImageDetails: details,
+		RequestMetadata:    getCustomRequestMetadataWithRetryPolicy(),
+	}
+	resp, err := acc.Compute.CreateImage(ctx, req)
+	return resp.Image, err
+}
+
+func deleteImage(acc *Account, imageId *string) error {
+	req := core.DeleteImageRequest{
+		ImageId:         imageId,
+		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
+	}
+	_, err := acc.Compute.DeleteImage(ctx, req)
+	return err
+}
+
+// exportImage 将自定义镜像导出到对象存储桶, 导出需要较长时间, 不等待完成
+func exportImage(acc *Account, imageId *string, namespace, bucket, objectName string) error {
+	req := core.ExportImageRequest{
+		ImageId: imageId,
+		ExportImageDetails: core.ExportImageViaObjectStorageTupleDetails{
+			NamespaceName: common.String(namespace),
+			BucketName:    common.String(bucket),
+			ObjectName:    common.String(objectName),
+			ExportFormat:  core.ExportImageDetailsExportFormatOci,
+		},
+		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
+	}
+	_, err := acc.Compute.ExportImage(ctx, req)
+	return err
+}
+
+// getRegionImage 获取 region 区域中的镜像
+func getRegionImage(acc *Account, region string, imageId *string) (core.Image, error) {
+	compute := acc.Compute
+	compute.SetRegion(region)
+	req := core.GetImageRequest{
+		ImageId:         imageId,
+		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
+	}
+	resp, err := compute.GetImage(ctx, req)
+	return resp.Image, err
+}
+
+// importImage 在 region 区域从 sourceUri (对象存储的预验证请求地址) 导入自定义镜像, 不等待完成
+func importImage(acc *Account, region, sourceUri, displayName string) (core.Image, error) {
+	compute := acc.Compute
+	compute.SetRegion(region)
+	req := core.CreateImageRequest{
+		CreateImageDetails: core.CreateImageDetails{
+			CompartmentId:      common.String(acc.Oracle.Tenancy),
+			DisplayName:        common.String(displayName),
+			ImageSourceDetails: core.ImageSourceViaObjectStorageUriDetails{SourceUri: common.String(sourceUri)},
+		},
+		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
+	}
+	resp, err := compute.CreateImage(ctx, req)
+	return resp.Image, err
+}
+
+// imageExportTimeout 等待镜像导出完成的最长时间
+const imageExportTimeout = 6 * time.Hour
+
+// imageImportTimeout 等待镜像导入完成的最长时间, 也是预验证请求的有效期
+const imageImportTimeout = 6 * time.Hour
+
+// defaultImageBucket 复制镜像时默认使用的存储桶
+const defaultImageBucket = "oci-help-images"
+
+// copyImageToRegion 将自定义镜像复制到同一租户的另一个区域: 先导出到当前区域的存储桶 bucket (不存在时自动创建),
+// 再通过预验证请求在目标区域导入, 等待导入完成后删除预验证请求和导出的镜像文件, 返回导入的镜像。
+// 导出和导入都可能需要几十分钟, 等待导入超时时提示手动清理
+func copyImageToRegion(acc *Account, imageId *string, region, bucket string) (core.Image, error) {
+	image, err := getImage(acc, imageId)
+	if err != nil {
+		return image, err
+	}
+	if image.CompartmentId == nil {
+		return image, errors.New("只能复制自定义镜像")
+	}
+	if image.LifecycleState != core.ImageLifecycleStateAvailable {
+		return image, fmt.Errorf("镜像当前状态为 %s, 无法复制", getImageState(image.LifecycleState))
+	}
+	namespace, err := getObjectStorageNamespace(acc)
+	if err != nil {
+		return image, fmt.Errorf("获取对象存储命名空间失败: %v", err)
+	}
+	err = getOrCreateBucket(acc, namespace, bucket)
+	if err != nil {
+		return image, fmt.Errorf("创建存储桶失败: %v", err)
+	}
+
+	name := stringValue(image.DisplayName)
+	objectName := fmt.Sprintf("%s-%s.oci", strings.ReplaceAll(name, "/", "_"), time.Now().Format("20060102-150405"))
+	printf("[%s] 正在将镜像 %s 导出到存储桶 %s/%s...\n", acc.Name, name, bucket, objectName)
+	err = exportImage(acc, imageId, namespace, bucket, objectName)
+	if err != nil {
+		return image, fmt.Errorf("导出镜像失败: %v", err)
+	}
+	// 导出完成后存储桶中才会出现镜像文件
+	deadline := time.Now().Add(imageExportTimeout)
+	for {
+		time.Sleep(30 * time.Second)
+		exists, err := objectExists(acc, namespace, bucket, objectName)
+		if err != nil {
+			printlnErr(fmt.Sprintf("[%s] 获取导出进度失败", acc.Name), fmt.Sprintf("导出完成后请手动删除存储桶 %s 中的镜像文件 %s", bucket, objectName))
+			return image, fmt.Errorf("检查导出进度失败: %v", err)
+		}
+		if exists {
+			break
+		}
+		image, err = getImage(acc, imageId)
+		if err == nil && image.LifecycleState != core.ImageLifecycleStateExporting && image.LifecycleState != core.ImageLifecycleStateAvailable {
+			// 导出失败时可能已经写入了部分文件
+			cleanupImageExport(acc, namespace, bucket, objectName, "")
+			return image, fmt.Errorf("导出镜像失败, 镜像状态: %s", image.LifecycleState)
+		}
+		if time.Now().After(deadline) {
+			// 导出可能仍在进行, 此时删除文件没有意义
+			printlnErr(fmt.Sprintf("[%s] 等待镜像导出超时", acc.Name), fmt.Sprintf("导出完成后请手动删除存储桶 %s 中的镜像文件 %s", bucket, objectName))
+			return image, errors.New("等待镜像导出超时")
+		}
+		printf("[%s] 镜像导出中, 请稍候...\n", acc.Name)
+	}
+
+	// 预验证请求只需在导入期间有效, 导入结束后连同镜像文件一起删除
+	parName := "oci-help-" + objectName
+	sourceUri, parId, err := createObjectReadUrl(acc, namespace, bucket, objectName, parName, time.Now().Add(imageImportTimeout))
+	if err != nil {
+		cleanupImageExport(acc, namespace, bucket, objectName, "")
+		return image, fmt.Errorf("创建预验证请求失败: %v", err)
+	}
+	printf("[%s] 导出完成, 正在导入到区域 %s...\n", acc.Name, region)
+	newImage, err := importImage(acc, region, sourceUri, name)
+	if err != nil {
+		cleanupImageExport(acc, namespace, bucket, objectName, parId)
+		return image, fmt.Errorf("在区域 %s 导入镜像失败: %v", region, err)
+	}
+
+	deadline = time.Now().Add(imageImportTimeout)
+	for newImage.LifecycleState == core.ImageLifecycleStateImporting || newImage.LifecycleState == core.ImageLifecycleStateProvisioning {
+		if time.Now().After(deadline) {
+			printlnErr(fmt.Sprintf("[%s] 等待镜像导入超时", acc.Name), fmt.Sprintf("导入完成后请手动删除存储桶 %s 中的镜像文件 %s 和预验证请求 %s", bucket, objectName, parName))
+			return newImage, fmt.Errorf("等待镜像在区域 %s 导入超时", region)
+		}
+		time.Sleep(30 * time.Second)
+		printf("[%s] 镜像导入中, 请稍候...\n", acc.Name)
+		img, err := getRegionImage(acc, region, newImage.Id)
+		if err != nil {
+			printlnErr(fmt.Sprintf("[%s] 获取导入进度失败", acc.Name), fmt.Sprintf("导入完成后请手动删除存储桶 %s 中的镜像文件 %s 和预验证请求 %s", bucket, objectName, parName))
+			return newImage, fmt.Errorf("检查导入进度失败: %v", err)
+		}
+		newImage = img
+	}
+	cleanupImageExport(acc, namespace, bucket, objectName, parId)
+	if newImage.LifecycleState != core.ImageLifecycleStateAvailable {
+		return newImage, fmt.Errorf("在区域 %s 导入镜像失败, 镜像状态: %s", region, newImage.LifecycleState)
+	}
+	return newImage, nil
+}
+
+// cleanupImageExport 删除复制镜像时创建的预验证请求和导出的镜像文件, 删除失败时提示手动删除。
+// 导出失败时镜像文件可能不存在, 此时不提示
+func cleanupImageExport(acc *Account, namespace, bucket, objectName, parId string) {
+	if parId != "" {
+		if err := deletePreauthenticatedRequest(acc, namespace, bucket, parId); err != nil {
+			printlnErr(fmt.Sprintf("[%s] 删除预验证请求失败", acc.Name), fmt.Sprintf("请在存储桶 %s 中手动删除预验证请求 oci-help-%s: %v", bucket, objectName, err))
+		}
+	}
+	err := deleteObject(acc, namespace, bucket, objectName)
+	if servErr, ok := common.IsServiceError(err); ok && servErr.GetHTTPStatusCode() == http.StatusNotFound {
+		return
+	}
+	if err != nil {
+		printlnErr(fmt.Sprintf("[%s] 删除镜像文件失败", acc.Name), fmt.Sprintf("请在存储桶 %s 中手动删除镜像文件 %s: %v", bucket, objectName, err))
+	}
+}
+
+// listRegionSubscriptions 获取租户订阅的区域
+func listRegionSubscriptions(acc *Account) ([]identity.RegionSubscription, error) {
+	req := identity.ListRegionSubscriptionsRequest{
+		TenancyId:       common.String(acc.Oracle.Tenancy),
+		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
+	}
+	resp, err := acc.Identity.ListRegionSubscriptions(ctx, req)
+	return resp.Items, err
+}
+
+// --- 对象存储 ---
+
+func getObjectStorageNamespace(acc *Account) (string, error) {
+	req := objectstorage.GetNamespaceRequest{
+		CompartmentId:   common.String(acc.Oracle.Tenancy),
+		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
+	}
+	resp, err := acc.Object.GetNamespace(ctx, req)
+	return stringValue(resp.Value), err
+}
+
+// getOrCreateBucket 确保存储桶存在, 不存在时创建一个私有的存储桶
+func getOrCreateBucket(acc *Account, namespace, bucket string) error {
+	getReq := objectstorage.GetBucketRequest{
+		NamespaceName:   common.String(namespace),
+		BucketName:      common.String(bucket),
+		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
+	}
+	_, err := acc.Object.GetBucket(ctx, getReq)
+	if servErr, ok := common.IsServiceError(err); !ok || servErr.GetHTTPStatusCode() != 404 {
+		return err
+	}
+	req := objectstorage.CreateBucketRequest{
+		NamespaceName: common.String(namespace),
+		CreateBucketDetails: objectstorage.CreateBucketDetails{
+			Name:          common.String(bucket),
+			CompartmentId: common.String(acc.Oracle.Tenancy),
+		},
+		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
+	}
+	_, err = acc.Object.CreateBucket(ctx, req)
+	return err
+}
+
+// createObjectReadUrl 为对象创建名为 name 的只读预验证请求, 返回可以直接下载对象的完整地址和预验证请求的 ID
+func createObjectReadUrl(acc *Account, namespace, bucket, objectName, name string, expires time.Time) (string, string, error) {
+	req := objectstorage.CreatePreauthenticatedRequestRequest{
+		NamespaceName: common.String(namespace),
+		BucketName:    common.String(bucket),
+		CreatePreauthenticatedRequestDetails: objectstorage.CreatePreauthenticatedRequestDetails{
+			Name:        common.String(name),
+			ObjectName:  common.String(objectName),
+			AccessType:  objectstorage.CreatePreauthenticatedRequestDetailsAccessTypeObjectread,
+			TimeExpires: &common.SDKTime{Time: expires},
+		},
+		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
+	}
+	resp, err := acc.Object.CreatePreauthenticatedRequest(ctx, req)
+	if err != nil {
+		return "", "", err
+	}
+	return acc.Object.Host + stringValue(resp.AccessUri), stringValue(resp.Id), nil
+}
+
+func deletePreauthenticatedRequest(acc *Account, namespace, bucket, parId string) error {
+	req := objectstorage.DeletePreauthenticatedRequestRequest{
+		NamespaceName:   common.String(namespace),
+		BucketName:      common.String(bucket),
+		ParId:           common.String(parId),
+		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
+	}
+	_, err := acc.Object.DeletePreauthenticatedRequest(ctx, req)
+	return err
+}
+
+func deleteObject(acc *Account, namespace, bucket, objectName string) error {
+	req := objectstorage.DeleteObjectRequest{
+		NamespaceName:   common.String(namespace),
+		BucketName:      common.String(bucket),
+		ObjectName:      common.String(objectName),
+		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
+	}
+	_, err := acc.Object.DeleteObject(ctx, req)
+	return err
+}
+
+// objectExists 检查存储桶中是否存在指定的对象
+func objectExists(acc *Account, namespace, bucket, objectName string) (bool, error) {
+	req := objectstorage.HeadObjectRequest{
+		NamespaceName:   common.String(namespace),
+		BucketName:      common.String(bucket),
+		ObjectName:      common.String(objectName),
+		RequestMetadata: getCustomRequestMetadataWithRetryPolicy(),
+	}
+	_, err := acc.Object.HeadObject(ctx, req)
+	if servErr, ok := common.IsServiceError(err); ok && servErr.GetHTTPStatusCode() == 404 {
+		return false, nil
+	}
+	return err == nil, err
+}
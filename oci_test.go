@@ -0,0 +1,585 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/core"
+	"github.com/oracle/oci-go-sdk/v65/identity"
+)
+
+// fakeOCI 模拟创建实例用到的 OCI 接口, 实例保存在内存中
+type fakeOCI struct {
+	mu         sync.Mutex
+	instances  []core.Instance
+	failures   map[string]int              // 各实例名称前缀剩余的创建失败次数, 小于 0 时一直失败
+	launches   int                         // 收到的创建请求数
+	privateIps map[string][]core.PrivateIp // 各网卡的私有IP, 未配置的网卡没有私有IP
+	publicIps  map[string]core.PublicIp    // 各私有IP绑定的公共IP
+}
+
+func newFakeOCI() *fakeOCI {
+	return &fakeOCI{failures: map[string]int{}, privateIps: map[string][]core.PrivateIp{}, publicIps: map[string]core.PublicIp{}}
+}
+
+func (f *fakeOCI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	path := strings.TrimPrefix(r.URL.Path, "/20160918")
+	switch {
+	case r.Method == http.MethodPost && path == "/instances":
+		var details struct {
+			DisplayName        string `json:"displayName"`
+			AvailabilityDomain string `json:"availabilityDomain"`
+			Shape              string `json:"shape"`
+			ShapeConfig        struct {
+				Ocpus       float32 `json:"ocpus"`
+				MemoryInGBs float32 `json:"memoryInGBs"`
+			} `json:"shapeConfig"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&details); err != nil {
+			writeFakeError(w, http.StatusBadRequest, "InvalidParameter", err.Error())
+			return
+		}
+		f.launches++
+		for prefix, n := range f.failures {
+			if n != 0 && strings.HasPrefix(details.DisplayName, prefix) {
+				f.failures[prefix] = n - 1
+				writeFakeError(w, http.StatusInternalServerError, "InternalError", "Out of host capacity.")
+				return
+			}
+		}
+		ins := core.Instance{
+			Id:                 common.String(fmt.Sprintf("ocid1.instance.%d", len(f.instances)+1)),
+			DisplayName:        common.String(details.DisplayName),
+			AvailabilityDomain: common.String(details.AvailabilityDomain),
+			Shape:              common.String(details.Shape),
+			ShapeConfig: &core.InstanceShapeConfig{
+				Ocpus:       common.Float32(details.ShapeConfig.Ocpus),
+				MemoryInGBs: common.Float32(details.ShapeConfig.MemoryInGBs),
+			},
+			LifecycleState: core.InstanceLifecycleStateRunning,
+		}
+		f.instances = append(f.instances, ins)
+		writeFakeJSON(w, ins)
+	case path == "/instances":
+		writeFakeJSON(w, append([]core.Instance{}, f.instances...))
+	case strings.HasPrefix(path, "/instances/"):
+		id := strings.TrimPrefix(path, "/instances/")
+		for _, ins := range f.instances {
+			if *ins.Id == id {
+				writeFakeJSON(w, ins)
+				return
+			}
+		}
+		writeFakeError(w, http.StatusNotFound, "NotAuthorizedOrNotFound", "instance not found")
+	case path == "/vnicAttachments":
+		// 每个实例一个网卡, 未指定实例时返回所有实例的网卡
+		ids := []string{r.URL.Query().Get("instanceId")}
+		if ids[0] == "" {
+			ids = nil
+			for _, ins := range f.instances {
+				ids = append(ids, *ins.Id)
+			}
+		}
+		attachments := []core.VnicAttachment{}
+		for _, id := range ids {
+			attachments = append(attachments, core.VnicAttachment{
+				InstanceId:     common.String(id),
+				VnicId:         common.String("ocid1.vnic." + id),
+				LifecycleState: core.VnicAttachmentLifecycleStateAttached,
+			})
+		}
+		writeFakeJSON(w, attachments)
+	case path == "/privateIps":
+		writeFakeJSON(w, append([]core.PrivateIp{}, f.privateIps[r.URL.Query().Get("vnicId")]...))
+	case strings.HasPrefix(path, "/vnics/"):
+		writeFakeJSON(w, core.Vnic{Id: common.String(strings.TrimPrefix(path, "/vnics/")), PublicIp: common.String("192.0.2.1")})
+	case r.Method == http.MethodPost && path == "/publicIps/actions/getByPrivateIpId":
+		var details struct {
+			PrivateIpId string `json:"privateIpId"`
+		}
+		json.NewDecoder(r.Body).Decode(&details)
+		if ip, ok := f.publicIps[details.PrivateIpId]; ok {
+			writeFakeJSON(w, ip)
+			return
+		}
+		writeFakeError(w, http.StatusNotFound, "NotAuthorizedOrNotFound", "public ip not found")
+	case r.Method == http.MethodPost && path == "/publicIps":
+		var details struct {
+			PrivateIpId string `json:"privateIpId"`
+		}
+		json.NewDecoder(r.Body).Decode(&details)
+		n := len(f.publicIps) + 10
+		ip := core.PublicIp{
+			Id:          common.String(fmt.Sprintf("ocid1.publicip.%d", n)),
+			IpAddress:   common.String(fmt.Sprintf("192.0.2.%d", n)),
+			Lifetime:    core.PublicIpLifetimeEphemeral,
+			PrivateIpId: common.String(details.PrivateIpId),
+		}
+		f.publicIps[details.PrivateIpId] = ip
+		writeFakeJSON(w, ip)
+	case r.Method == http.MethodDelete && strings.HasPrefix(path, "/publicIps/"):
+		id := strings.TrimPrefix(path, "/publicIps/")
+		for privateIpId, ip := range f.publicIps {
+			if *ip.Id == id {
+				delete(f.publicIps, privateIpId)
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case path == "/images":
+		writeFakeJSON(w, []core.Image{})
+	case strings.HasPrefix(path, "/images/"):
+		writeFakeJSON(w, core.Image{Id: common.String(strings.TrimPrefix(path, "/images/")), DisplayName: common.String("image"), SizeInMBs: common.Int64(47694)})
+	case path == "/vcns":
+		writeFakeJSON(w, []core.Vcn{{Id: common.String("ocid1.vcn.1"), DisplayName: common.String("vcn")}})
+	case path == "/internetGateways":
+		writeFakeJSON(w, []core.InternetGateway{{Id: common.String("ocid1.internetgateway.1"), DisplayName: common.String("gateway")}})
+	case path == "/routeTables":
+		writeFakeJSON(w, []core.RouteTable{{Id: common.String("ocid1.routetable.1"), RouteRules: []core.RouteRule{{NetworkEntityId: common.String("ocid1.internetgateway.1")}}}})
+	case path == "/subnets":
+		writeFakeJSON(w, []core.Subnet{{Id: common.String("ocid1.subnet.1"), DisplayName: common.String("subnet"), VcnId: common.String("ocid1.vcn.1")}})
+	case path == "/availabilityDomains":
+		writeFakeJSON(w, []identity.AvailabilityDomain{{Name: common.String("AD-1")}})
+	case path == "/bootVolumes", path == "/volumes":
+		writeFakeJSON(w, []struct{}{})
+	default:
+		writeFakeError(w, http.StatusNotFound, "NotFound", r.Method+" "+r.URL.Path)
+	}
+}
+
+func writeFakeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeFakeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"code": code, "message": message})
+}
+
+// instanceNames 返回已创建的实例名称
+func (f *fakeOCI) instanceNames() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var names []string
+	for _, ins := range f.instances {
+		names = append(names, *ins.DisplayName)
+	}
+	return names
+}
+
+// newTestAccount 创建一个所有请求都发送到 fake 的账号
+func newTestAccount(t *testing.T, name string, fake *fakeOCI) *Account {
+	t.Helper()
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	provider := common.NewRawConfigurationProvider("ocid1.tenancy.test", "ocid1.user.test", "us-ashburn-1", "00:00", string(privateKey), nil)
+
+	acc := &Account{Name: name, Oracle: Oracle{Tenancy: "ocid1.tenancy.test", Region: "us-ashburn-1"}}
+	if acc.Compute, err = core.NewComputeClientWithConfigurationProvider(provider); err != nil {
+		t.Fatal(err)
+	}
+	if acc.Network, err = core.NewVirtualNetworkClientWithConfigurationProvider(provider); err != nil {
+		t.Fatal(err)
+	}
+	if acc.Storage, err = core.NewBlockstorageClientWithConfigurationProvider(provider); err != nil {
+		t.Fatal(err)
+	}
+	if acc.Identity, err = identity.NewIdentityClientWithConfigurationProvider(provider); err != nil {
+		t.Fatal(err)
+	}
+	acc.Compute.Host = server.URL
+	acc.Network.Host = server.URL
+	acc.Storage.Host = server.URL
+	acc.Identity.Host = server.URL
+	return acc
+}
+
+// setupLaunchTest 将进度文件、通知后端和免费额度替换为测试使用的配置, 测试结束后恢复
+func setupLaunchTest(t *testing.T) *fakeNotifier {
+	t.Helper()
+	oldStateFilePath, oldNotifiers, oldLimit, oldEACH := stateFilePath, notifiers, limit, EACH
+	t.Cleanup(func() {
+		stateFilePath, notifiers, limit, EACH = oldStateFilePath, oldNotifiers, oldLimit, oldEACH
+	})
+	stateFilePath = filepath.Join(t.TempDir(), "state.json")
+	n := &fakeNotifier{}
+	notifiers = []Notifier{n}
+	limit = defaultLimit()
+	limit.Mode = limitModeWarn
+	EACH = true
+	return n
+}
+
+func testSpec(template, name string, sum int32) Instance {
+	return Instance{
+		Template:            template,
+		InstanceDisplayName: name,
+		ImageId:             "ocid1.image.test",
+		Shape:               shapeA1Flex,
+		Ocpus:               1,
+		MemoryInGBs:         6,
+		Sum:                 sum,
+		Retry:               -1,
+	}
+}
+
+func testAds() []identity.AvailabilityDomain {
+	return []identity.AvailabilityDomain{{Name: common.String("AD-1")}}
+}
+
+// TestLaunchInstancesConcurrent 在多个 goroutine 中为多个账号和模板同时创建实例, 配合 go test -race 检查数据竞争
+func TestLaunchInstancesConcurrent(t *testing.T) {
+	n := setupLaunchTest(t)
+	fakes := []*fakeOCI{newFakeOCI(), newFakeOCI()}
+	var accounts []*Account
+	for i, fake := range fakes {
+		fake.failures["web"] = 1
+		accounts = append(accounts, newTestAccount(t, fmt.Sprintf("acc%d", i), fake))
+	}
+
+	templates := []string{"web", "db", "cache"}
+	var wg sync.WaitGroup
+	for _, acc := range accounts {
+		for _, name := range templates {
+			wg.Add(1)
+			go func(acc *Account, name string) {
+				defer wg.Done()
+				sum, num, err := LaunchInstances(acc, testSpec("INSTANCE."+name, name, 2), testAds(), nil)
+				if err != nil || sum != 2 || num != 2 {
+					t.Errorf("[%s] %s: sum=%d num=%d err=%v", acc.Name, name, sum, num, err)
+				}
+			}(acc, name)
+		}
+	}
+	wg.Wait()
+
+	for i, fake := range fakes {
+		names := fake.instanceNames()
+		for _, name := range templates {
+			for _, want := range []string{name + "-1", name + "-2"} {
+				if !containsString(names, want) {
+					t.Errorf("acc%d: 未创建实例 %s, 已创建: %v", i, want, names)
+				}
+			}
+		}
+		if len(names) != len(templates)*2 {
+			t.Errorf("acc%d: 创建了 %d 个实例, 期望 %d 个", i, len(names), len(templates)*2)
+		}
+	}
+	states, err := loadLaunchStates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(states) != 0 {
+		t.Errorf("全部创建成功后应删除创建进度, 剩余 %d 个", len(states))
+	}
+	if n.count() == 0 {
+		t.Error("未发送任何通知")
+	}
+}
+
+// TestLaunchInstancesResume 停止创建后保存进度, 再次执行时从进度继续且不重复创建
+func TestLaunchInstancesResume(t *testing.T) {
+	setupLaunchTest(t)
+	fake := newFakeOCI()
+	acc := newTestAccount(t, "acc", fake)
+	spec := testSpec("INSTANCE.web", "web", 2)
+
+	// 第 1 个实例创建成功, 第 2 个一直失败, 直到收到停止信号
+	fake.failures["web-2"] = -1
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sum, num, err := LaunchInstances(acc, spec, testAds(), stop)
+		if err != nil || sum != 2 || num != 1 {
+			t.Errorf("sum=%d num=%d err=%v", sum, num, err)
+		}
+	}()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		state, err := loadLaunchState(acc.Name, spec.Template)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state != nil && state.Pos == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("等待第 1 个实例创建成功超时")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	close(stop)
+	<-done
+
+	state, err := loadLaunchState(acc.Name, spec.Template)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state == nil || len(state.Created) != 1 || state.Pos != 1 {
+		t.Fatalf("停止后的创建进度不正确: %+v", state)
+	}
+
+	fake.mu.Lock()
+	fake.failures["web-2"] = 0
+	fake.mu.Unlock()
+	sum, num, err := LaunchInstances(acc, spec, testAds(), nil)
+	if err != nil || sum != 2 || num != 2 {
+		t.Errorf("sum=%d num=%d err=%v", sum, num, err)
+	}
+	names := fake.instanceNames()
+	if len(names) != 2 || names[0] != "web-1" || names[1] != "web-2" {
+		t.Errorf("已创建的实例: %v, 期望 [web-1 web-2]", names)
+	}
+	if state, _ := loadLaunchState(acc.Name, spec.Template); state != nil {
+		t.Errorf("全部创建成功后应删除创建进度: %+v", state)
+	}
+}
+
+// TestLaunchInstancesStaleState 进度与当前模板不符或可用性域序号越界时不会 panic, 而是重新开始创建
+func TestLaunchInstancesStaleState(t *testing.T) {
+	ads := []identity.AvailabilityDomain{{Name: common.String("AD-1")}, {Name: common.String("AD-2")}}
+	spec := testSpec("INSTANCE.web", "web", 1)
+	spec.Each = 1
+	changed := spec
+	changed.Each = 3
+
+	tests := []struct {
+		name  string
+		state LaunchState
+	}{
+		{"可用性域序号越界", LaunchState{Fingerprint: launchFingerprint(spec), AdCount: 2, AdIndex: 5}},
+		{"模板已修改", LaunchState{Fingerprint: launchFingerprint(changed), AdCount: 2, AdIndex: 5, Pos: 1,
+			Created: []CreatedInstance{{Id: "ocid1.instance.old", DisplayName: "web-1"}}}},
+		{"可用性域个数变化", LaunchState{Fingerprint: launchFingerprint(spec), AdCount: 3, AdIndex: 2, Pos: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupLaunchTest(t)
+			fake := newFakeOCI()
+			acc := newTestAccount(t, "acc", fake)
+			state := tt.state
+			state.Account, state.Template, state.Name = acc.Name, spec.Template, "web"
+			if err := saveLaunchState(&state); err != nil {
+				t.Fatal(err)
+			}
+			sum, num, err := LaunchInstances(acc, spec, ads, nil)
+			if err != nil || sum != 2 || num != 2 {
+				t.Errorf("sum=%d num=%d err=%v", sum, num, err)
+			}
+			if names := fake.instanceNames(); len(names) != 2 {
+				t.Errorf("已创建的实例: %v, 期望 2 个", names)
+			}
+		})
+	}
+}
+
+// TestLaunchInstancesBootVolume 引导卷配置错误时不返回 errRetryLater; 上次已创建完成的模板不再查找引导卷
+func TestLaunchInstancesBootVolume(t *testing.T) {
+	setupLaunchTest(t)
+	fake := newFakeOCI()
+	acc := newTestAccount(t, "acc", fake)
+	spec := testSpec("INSTANCE.web", "web", 1)
+	spec.ImageId, spec.BootVolumeDisplayName = "", "missing"
+
+	_, _, err := LaunchInstances(acc, spec, testAds(), nil)
+	if err == nil || errors.Is(err, errRetryLater) {
+		t.Errorf("err=%v, 期望不可重试的错误", err)
+	}
+
+	fake.instances = []core.Instance{{Id: common.String("ocid1.instance.1"), DisplayName: common.String("web"), Shape: common.String(shapeA1Flex), LifecycleState: core.InstanceLifecycleStateRunning}}
+	state := &LaunchState{Account: acc.Name, Template: spec.Template, Name: "web", Fingerprint: launchFingerprint(spec), AdCount: 1,
+		Sum: 1, Pos: 1, Created: []CreatedInstance{{Id: "ocid1.instance.1", DisplayName: "web"}}}
+	if err := saveLaunchState(state); err != nil {
+		t.Fatal(err)
+	}
+	sum, num, err := LaunchInstances(acc, spec, testAds(), nil)
+	if err != nil || sum != 1 || num != 1 || fake.launches != 0 {
+		t.Errorf("sum=%d num=%d err=%v launches=%d", sum, num, err, fake.launches)
+	}
+	if state, _ := loadLaunchState(acc.Name, spec.Template); state != nil {
+		t.Errorf("应删除已完成的创建进度: %+v", state)
+	}
+}
+
+// TestLaunchInstancesRetryLater 获取镜像失败时不发起创建请求, 返回 errRetryLater
+func TestLaunchInstancesRetryLater(t *testing.T) {
+	setupLaunchTest(t)
+	fake := newFakeOCI()
+	acc := newTestAccount(t, "acc", fake)
+	spec := testSpec("INSTANCE.web", "web", 1)
+	spec.ImageId, spec.ImageDisplayName = "", "missing"
+	_, num, err := LaunchInstances(acc, spec, testAds(), nil)
+	if !errors.Is(err, errRetryLater) {
+		t.Errorf("err=%v, 期望 errRetryLater", err)
+	}
+	if num != 0 || fake.launches != 0 {
+		t.Errorf("num=%d launches=%d, 期望未发起创建请求", num, fake.launches)
+	}
+}
+
+func TestCountTemplateInstances(t *testing.T) {
+	instance := func(name, shape string, state core.InstanceLifecycleStateEnum) core.Instance {
+		return core.Instance{DisplayName: common.String(name), Shape: common.String(shape), LifecycleState: state}
+	}
+	instances := []core.Instance{
+		instance("web", shapeA1Flex, core.InstanceLifecycleStateRunning),
+		instance("web-1", shapeA1Flex, core.InstanceLifecycleStateProvisioning),
+		instance("web-2", shapeA1Flex, core.InstanceLifecycleStateStarting),
+		instance("web-3", shapeA1Flex, core.InstanceLifecycleStateStopped),
+		instance("web-4", shapeA1Flex, core.InstanceLifecycleStateTerminated),
+		instance("web-5", shapeE2Micro, core.InstanceLifecycleStateRunning),
+		instance("webserver-1", shapeA1Flex, core.InstanceLifecycleStateRunning),
+		instance("web-backup", shapeA1Flex, core.InstanceLifecycleStateRunning),
+		instance("web-", shapeA1Flex, core.InstanceLifecycleStateRunning),
+	}
+	if got := countTemplateInstances(instances, shapeA1Flex, "web"); got != 4 {
+		t.Errorf("countTemplateInstances = %d, 期望 4", got)
+	}
+}
+
+// TestChangePublicIpWithoutPublicIp 实例的公共IP已被释放时直接创建新的临时公共IP
+func TestChangePublicIpWithoutPublicIp(t *testing.T) {
+	fake := newFakeOCI()
+	acc := newTestAccount(t, "acc", fake)
+	fake.privateIps["ocid1.vnic.1"] = []core.PrivateIp{{Id: common.String("ocid1.privateip.1"), IsPrimary: common.Bool(true)}}
+	vnics := []core.Vnic{{Id: common.String("ocid1.vnic.1"), IsPrimary: common.Bool(true)}}
+
+	publicIp, err := changePublicIp(acc, vnics)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if publicIp.IpAddress == nil || fake.publicIps["ocid1.privateip.1"].IpAddress == nil {
+		t.Errorf("未创建公共IP: %+v", publicIp)
+	}
+}
+
+// TestLaunchInstancesDesiredStateNames 期望状态模式下已停止的实例计入现有实例, 新实例使用最小的未被占用的序号
+func TestLaunchInstancesDesiredStateNames(t *testing.T) {
+	setupLaunchTest(t)
+	fake := newFakeOCI()
+	acc := newTestAccount(t, "acc", fake)
+	for i, name := range []string{"web-1", "web-3", "web-4"} {
+		state := core.InstanceLifecycleStateRunning
+		if name == "web-4" {
+			state = core.InstanceLifecycleStateStopped
+		}
+		fake.instances = append(fake.instances, core.Instance{
+			Id:             common.String(fmt.Sprintf("ocid1.instance.existing%d", i)),
+			DisplayName:    common.String(name),
+			Shape:          common.String(shapeA1Flex),
+			LifecycleState: state,
+		})
+	}
+	spec := testSpec("INSTANCE.web", "web", 4)
+	spec.DesiredState = true
+
+	sum, num, err := LaunchInstances(acc, spec, testAds(), nil)
+	if err != nil || sum != 1 || num != 1 {
+		t.Errorf("sum=%d num=%d err=%v", sum, num, err)
+	}
+	names := fake.instanceNames()
+	if len(names) != 4 || names[3] != "web-2" {
+		t.Errorf("已创建的实例: %v, 期望新实例为 web-2", names)
+	}
+}
+
+func TestPruneBootVolumeBackups(t *testing.T) {
+	base := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	backup := func(id, volume string, days int, source core.BootVolumeBackupSourceTypeEnum) core.BootVolumeBackup {
+		b := core.BootVolumeBackup{
+			Id:             common.String(id),
+			BootVolumeId:   common.String(volume),
+			SourceType:     source,
+			LifecycleState: core.BootVolumeBackupLifecycleStateAvailable,
+		}
+		if days >= 0 {
+			b.TimeCreated = &common.SDKTime{Time: base.AddDate(0, 0, days)}
+		}
+		return b
+	}
+	manual, scheduled := core.BootVolumeBackupSourceTypeManual, core.BootVolumeBackupSourceTypeScheduled
+	backups := []core.BootVolumeBackup{
+		backup("a1", "a", 1, manual),
+		backup("a3", "a", 3, manual),
+		backup("a2", "a", 2, manual),
+		backup("a-nil", "a", -1, manual), // 没有创建时间, 视为最新
+		backup("a0", "a", 0, scheduled),  // 备份策略创建的备份不会被删除
+		backup("b1", "b", 1, manual),
+	}
+	creating := backup("b0", "b", 0, manual)
+	creating.LifecycleState = core.BootVolumeBackupLifecycleStateCreating
+	backups = append(backups, creating)
+
+	tests := []struct {
+		keep int
+		want []string
+	}{
+		{0, []string{"a-nil", "a3", "a2", "a1", "b1"}},
+		{1, []string{"a3", "a2", "a1"}},
+		{2, []string{"a2", "a1"}},
+		{4, nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, b := range pruneBootVolumeBackups(backups, tt.keep) {
+			got = append(got, *b.Id)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("keep=%d: 删除 %v, 期望 %v", tt.keep, got, tt.want)
+		}
+	}
+}
+
+func TestNextIpv6SubnetCidr(t *testing.T) {
+	tests := []struct {
+		vcnCidr string
+		used    []string
+		want    string
+		wantErr bool
+	}{
+		{"2001:db8:1:2200::/56", nil, "2001:db8:1:2200::/64", false},
+		{"2001:db8:1:2200::/56", []string{"2001:db8:1:2200::/64", "2001:db8:1:2202::/64"}, "2001:db8:1:2201::/64", false},
+		{"2001:db8:1:2200::/64", nil, "2001:db8:1:2200::/64", false},
+		{"2001:db8:1:2200::/64", []string{"2001:db8:1:2200::/64"}, "", true},
+		{"2001:db8:1:2200::/72", nil, "", true},
+		{"not-a-cidr", nil, "", true},
+	}
+	for _, tt := range tests {
+		got, err := nextIpv6SubnetCidr(tt.vcnCidr, tt.used)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("nextIpv6SubnetCidr(%s, %v) = %q, %v, 期望 %q", tt.vcnCidr, tt.used, got, err, tt.want)
+		}
+	}
+	// 前缀中的 /64 全部被使用
+	var used []string
+	for i := 0; i < 256; i++ {
+		used = append(used, fmt.Sprintf("2001:db8:1:22%02x::/64", i))
+	}
+	if got, err := nextIpv6SubnetCidr("2001:db8:1:2200::/56", used); err == nil {
+		t.Errorf("前缀已用完时应返回错误, 得到 %s", got)
+	}
+}
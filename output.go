@@ -0,0 +1,570 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/core"
+	"github.com/oracle/oci-go-sdk/v65/identity"
+	"gopkg.in/yaml.v3"
+)
+
+// 支持的输出格式
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+	outputCSV   = "csv"
+)
+
+// tableRecord 是可以按表格或 CSV 输出的记录, JSON/YAML 输出时直接序列化记录本身
+type tableRecord interface {
+	tableHeader() []string
+	tableRow() []string
+}
+
+// checkOutputFormat 检查输出格式是否受支持
+func checkOutputFormat(format string) error {
+	switch format {
+	case outputTable, outputJSON, outputYAML, outputCSV:
+		return nil
+	}
+	return fmt.Errorf("%w: 不支持的输出格式 %s, 可选 json|yaml|table|csv", errUsage, format)
+}
+
+// writeRecords 按指定格式输出记录
+func writeRecords(w io.Writer, format string, records []tableRecord) error {
+	switch format {
+	case outputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if records == nil {
+			records = []tableRecord{}
+		}
+		return enc.Encode(records)
+	case outputYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if records == nil {
+			records = []tableRecord{}
+		}
+		err := enc.Encode(records)
+		if err != nil {
+			return err
+		}
+		return enc.Close()
+	case outputCSV:
+		cw := csv.NewWriter(w)
+		if len(records) > 0 {
+			cw.Write(records[0].tableHeader())
+		}
+		for _, r := range records {
+			cw.Write(r.tableRow())
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		tw := new(tabwriter.Writer)
+		tw.Init(w, 0, 8, 2, '\t', 0)
+		if len(records) > 0 {
+			fmt.Fprintln(tw, strings.Join(records[0].tableHeader(), "\t"))
+		}
+		for _, r := range records {
+			fmt.Fprintln(tw, strings.Join(r.tableRow(), "\t"))
+		}
+		return tw.Flush()
+	}
+}
+
+func formatFloat(f float32) string {
+	return strconv.FormatFloat(float64(f), 'g', -1, 32)
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// --- 实例 ---
+
+// InstanceRecord 实例的完整信息
+type InstanceRecord struct {
+	Account            string            `json:"account" yaml:"account"`
+	Id                 string            `json:"id" yaml:"id"`
+	DisplayName        string            `json:"displayName" yaml:"displayName"`
+	State              string            `json:"state" yaml:"state"`
+	Region             string            `json:"region" yaml:"region"`
+	AvailabilityDomain string            `json:"availabilityDomain" yaml:"availabilityDomain"`
+	Shape              string            `json:"shape" yaml:"shape"`
+	Ocpus              float32           `json:"ocpus" yaml:"ocpus"`
+	MemoryInGBs        float32           `json:"memoryInGBs" yaml:"memoryInGBs"`
+	PublicIPv4         []string          `json:"publicIPv4" yaml:"publicIPv4"`
+	PrivateIPv4        []string          `json:"privateIPv4" yaml:"privateIPv4"`
+	IPv6               []string          `json:"ipv6" yaml:"ipv6"`
+	BootVolume         *BootVolumeRecord `json:"bootVolume,omitempty" yaml:"bootVolume,omitempty"`
+	TimeCreated        time.Time         `json:"timeCreated" yaml:"timeCreated"`
+}
+
+func (r InstanceRecord) tableHeader() []string {
+	return []string{"账号", "名称", "状态", "配置", "OCPU", "内存(GB)", "可用区", "公共IPv4", "IPv6", "引导卷(GB)", "VPU", "OCID"}
+}
+
+func (r InstanceRecord) tableRow() []string {
+	var size, vpus string
+	if r.BootVolume != nil {
+		size = strconv.FormatInt(r.BootVolume.SizeInGBs, 10)
+		vpus = strconv.FormatInt(r.BootVolume.VpusPerGB, 10)
+	}
+	return []string{r.Account, r.DisplayName, r.State, r.Shape, formatFloat(r.Ocpus), formatFloat(r.MemoryInGBs),
+		r.AvailabilityDomain, strings.Join(r.PublicIPv4, ","), strings.Join(r.IPv6, ","), size, vpus, r.Id}
+}
+
+// collectInstanceRecords 获取当前账号下全部实例的完整信息, 包括网卡地址和引导卷。
+// 部分实例的网卡地址获取失败时仍返回全部实例, 同时返回错误
+func collectInstanceRecords(acc *Account) ([]tableRecord, error) {
+	instances, err := listAllInstances(acc)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]tableRecord, len(instances))
+	errs := make([]error, len(instances))
+	var wg sync.WaitGroup
+	for i, ins := range instances {
+		wg.Add(1)
+		go func(i int, ins core.Instance) {
+			defer wg.Done()
+			records[i], errs[i] = newInstanceRecord(acc, ins)
+		}(i, ins)
+	}
+	wg.Wait()
+	var failed []string
+	for i, err := range errs {
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", stringValue(instances[i].DisplayName), err))
+		}
+	}
+	if len(failed) > 0 {
+		return records, fmt.Errorf("以下实例获取网卡地址失败: %s", strings.Join(failed, "; "))
+	}
+	return records, nil
+}
+
+// newInstanceRecord 返回实例的完整信息, 网卡地址获取失败时返回已获取到的部分和错误
+func newInstanceRecord(acc *Account, ins core.Instance) (InstanceRecord, error) {
+	r := InstanceRecord{
+		Account:            acc.Name,
+		Id:                 stringValue(ins.Id),
+		DisplayName:        stringValue(ins.DisplayName),
+		State:              string(ins.LifecycleState),
+		Region:             stringValue(ins.Region),
+		AvailabilityDomain: stringValue(ins.AvailabilityDomain),
+		Shape:              stringValue(ins.Shape),
+		PublicIPv4:         []string{},
+		PrivateIPv4:        []string{},
+		IPv6:               []string{},
+	}
+	if ins.TimeCreated != nil {
+		r.TimeCreated = ins.TimeCreated.Time
+	}
+	if ins.ShapeConfig != nil {
+		if ins.ShapeConfig.Ocpus != nil {
+			r.Ocpus = *ins.ShapeConfig.Ocpus
+		}
+		if ins.ShapeConfig.MemoryInGBs != nil {
+			r.MemoryInGBs = *ins.ShapeConfig.MemoryInGBs
+		}
+	}
+	if ins.LifecycleState == core.InstanceLifecycleStateTerminated {
+		return r, nil
+	}
+
+	vnics, vnicErr := getInstanceVnics(acc, ins.Id)
+	for _, vnic := range vnics {
+		if vnic.PublicIp != nil && *vnic.PublicIp != "" {
+			r.PublicIPv4 = append(r.PublicIPv4, *vnic.PublicIp)
+		}
+		if vnic.PrivateIp != nil && *vnic.PrivateIp != "" {
+			r.PrivateIPv4 = append(r.PrivateIPv4, *vnic.PrivateIp)
+		}
+		ipv6s, err := ListIpv6s(acc, vnic.Id)
+		if err != nil && vnicErr == nil {
+			vnicErr = fmt.Errorf("获取 IPv6 地址失败: %v", err)
+		}
+		for _, ipv6 := range ipv6s {
+			r.IPv6 = append(r.IPv6, *ipv6.IpAddress)
+		}
+	}
+
+	bootVolume, err := getInstanceBootVolume(acc, ins)
+	if err == nil {
+		record := newBootVolumeRecord(acc.Name, bootVolume)
+		r.BootVolume = &record
+	}
+	return r, vnicErr
+}
+
+// --- 引导卷 ---
+
+// BootVolumeRecord 引导卷信息
+type BootVolumeRecord struct {
+	Account            string    `json:"account,omitempty" yaml:"account,omitempty"`
+	Id                 string    `json:"id" yaml:"id"`
+	DisplayName        string    `json:"displayName" yaml:"displayName"`
+	State              string    `json:"state" yaml:"state"`
+	AvailabilityDomain string    `json:"availabilityDomain" yaml:"availabilityDomain"`
+	SizeInGBs          int64     `json:"sizeInGBs" yaml:"sizeInGBs"`
+	VpusPerGB          int64     `json:"vpusPerGB" yaml:"vpusPerGB"`
+	TimeCreated        time.Time `json:"timeCreated" yaml:"timeCreated"`
+}
+
+func (r BootVolumeRecord) tableHeader() []string {
+	return []string{"账号", "名称", "状态", "大小(GB)", "VPU", "可用区", "OCID"}
+}
+
+func (r BootVolumeRecord) tableRow() []string {
+	return []string{r.Account, r.DisplayName, r.State, strconv.FormatInt(r.SizeInGBs, 10),
+		strconv.FormatInt(r.VpusPerGB, 10), r.AvailabilityDomain, r.Id}
+}
+
+func newBootVolumeRecord(accountName string, v core.BootVolume) BootVolumeRecord {
+	r := BootVolumeRecord{
+		Account:            accountName,
+		Id:                 stringValue(v.Id),
+		DisplayName:        stringValue(v.DisplayName),
+		State:              string(v.LifecycleState),
+		AvailabilityDomain: stringValue(v.AvailabilityDomain),
+	}
+	if v.SizeInGBs != nil {
+		r.SizeInGBs = *v.SizeInGBs
+	}
+	if v.VpusPerGB != nil {
+		r.VpusPerGB = *v.VpusPerGB
+	}
+	if v.TimeCreated != nil {
+		r.TimeCreated = v.TimeCreated.Time
+	}
+	return r
+}
+
+// collectBootVolumeRecords 获取当前账号所有可用域下的引导卷
+func collectBootVolumeRecords(acc *Account) ([]tableRecord, error) {
+	bootVolumes, err := listAllBootVolumes(acc)
+	if err != nil {
+		return nil, err
+	}
+	var records []tableRecord
+	for _, v := range bootVolumes {
+		records = append(records, newBootVolumeRecord(acc.Name, v))
+	}
+	return records, nil
+}
+
+// BootVolumeBackupRecord 引导卷备份信息
+type BootVolumeBackupRecord struct {
+	Account      string    `json:"account,omitempty" yaml:"account,omitempty"`
+	Id           string    `json:"id" yaml:"id"`
+	DisplayName  string    `json:"displayName" yaml:"displayName"`
+	BootVolumeId string    `json:"bootVolumeId" yaml:"bootVolumeId"`
+	State        string    `json:"state" yaml:"state"`
+	Type         string    `json:"type" yaml:"type"`
+	SourceType   string    `json:"sourceType" yaml:"sourceType"`
+	SizeInGBs    int64     `json:"sizeInGBs" yaml:"sizeInGBs"`
+	TimeCreated  time.Time `json:"timeCreated" yaml:"timeCreated"`
+}
+
+func (r BootVolumeBackupRecord) tableHeader() []string {
+	return []string{"账号", "名称", "状态", "类型", "来源", "大小(GB)", "创建时间", "OCID"}
+}
+
+func (r BootVolumeBackupRecord) tableRow() []string {
+	return []string{r.Account, r.DisplayName, r.State, r.Type, r.SourceType, strconv.FormatInt(r.SizeInGBs, 10),
+		r.TimeCreated.Local().Format("2006-01-02 15:04"), r.Id}
+}
+
+func newBootVolumeBackupRecord(accountName string, b core.BootVolumeBackup) BootVolumeBackupRecord {
+	r := BootVolumeBackupRecord{
+		Account:      accountName,
+		Id:           stringValue(b.Id),
+		DisplayName:  stringValue(b.DisplayName),
+		BootVolumeId: stringValue(b.BootVolumeId),
+		State:        string(b.LifecycleState),
+		Type:         string(b.Type),
+		SourceType:   string(b.SourceType),
+	}
+	if b.SizeInGBs != nil {
+		r.SizeInGBs = *b.SizeInGBs
+	}
+	if b.TimeCreated != nil {
+		r.TimeCreated = b.TimeCreated.Time
+	}
+	return r
+}
+
+// --- 块存储卷 ---
+
+// VolumeRecord 块存储卷信息
+type VolumeRecord struct {
+	Account            string    `json:"account,omitempty" yaml:"account,omitempty"`
+	Id                 string    `json:"id" yaml:"id"`
+	DisplayName        string    `json:"displayName" yaml:"displayName"`
+	State              string    `json:"state" yaml:"state"`
+	AvailabilityDomain string    `json:"availabilityDomain" yaml:"availabilityDomain"`
+	SizeInGBs          int64     `json:"sizeInGBs" yaml:"sizeInGBs"`
+	VpusPerGB          int64     `json:"vpusPerGB" yaml:"vpusPerGB"`
+	TimeCreated        time.Time `json:"timeCreated" yaml:"timeCreated"`
+}
+
+func (r VolumeRecord) tableHeader() []string {
+	return []string{"账号", "名称", "状态", "大小(GB)", "VPU", "可用区", "OCID"}
+}
+
+func (r VolumeRecord) tableRow() []string {
+	return []string{r.Account, r.DisplayName, r.State, strconv.FormatInt(r.SizeInGBs, 10),
+		strconv.FormatInt(r.VpusPerGB, 10), r.AvailabilityDomain, r.Id}
+}
+
+func newVolumeRecord(accountName string, v core.Volume) VolumeRecord {
+	r := VolumeRecord{
+		Account:            accountName,
+		Id:                 stringValue(v.Id),
+		DisplayName:        stringValue(v.DisplayName),
+		State:              string(v.LifecycleState),
+		AvailabilityDomain: stringValue(v.AvailabilityDomain),
+	}
+	if v.SizeInGBs != nil {
+		r.SizeInGBs = *v.SizeInGBs
+	}
+	if v.VpusPerGB != nil {
+		r.VpusPerGB = *v.VpusPerGB
+	}
+	if v.TimeCreated != nil {
+		r.TimeCreated = v.TimeCreated.Time
+	}
+	return r
+}
+
+// collectVolumeRecords 获取当前账号所有可用域下的块存储卷
+func collectVolumeRecords(acc *Account) ([]tableRecord, error) {
+	volumes, err := listAllVolumes(acc)
+	if err != nil {
+		return nil, err
+	}
+	var records []tableRecord
+	for _, v := range volumes {
+		records = append(records, newVolumeRecord(acc.Name, v))
+	}
+	return records, nil
+}
+
+// --- 管理员 ---
+
+// UserRecord IAM 用户信息
+type UserRecord struct {
+	Account        string    `json:"account" yaml:"account"`
+	Id             string    `json:"id" yaml:"id"`
+	Name           string    `json:"name" yaml:"name"`
+	Email          string    `json:"email" yaml:"email"`
+	Description    string    `json:"description" yaml:"description"`
+	State          string    `json:"state" yaml:"state"`
+	IsMfaActivated bool      `json:"isMfaActivated" yaml:"isMfaActivated"`
+	TimeCreated    time.Time `json:"timeCreated" yaml:"timeCreated"`
+}
+
+func (r UserRecord) tableHeader() []string {
+	return []string{"账号", "名称", "邮箱", "状态", "MFA", "创建时间", "OCID"}
+}
+
+func (r UserRecord) tableRow() []string {
+	return []string{r.Account, r.Name, r.Email, r.State, strconv.FormatBool(r.IsMfaActivated),
+		r.TimeCreated.Format("2006-01-02"), r.Id}
+}
+
+func newUserRecord(accountName string, u identity.User) UserRecord {
+	r := UserRecord{
+		Account:     accountName,
+		Id:          stringValue(u.Id),
+		Name:        stringValue(u.Name),
+		Email:       stringValue(u.Email),
+		Description: stringValue(u.Description),
+		State:       string(u.LifecycleState),
+	}
+	if u.IsMfaActivated != nil {
+		r.IsMfaActivated = *u.IsMfaActivated
+	}
+	if u.TimeCreated != nil {
+		r.TimeCreated = u.TimeCreated.Time
+	}
+	return r
+}
+
+// --- 网络 ---
+
+// VcnRecord 虚拟云网络信息
+type VcnRecord struct {
+	Account               string   `json:"account" yaml:"account"`
+	Id                    string   `json:"id" yaml:"id"`
+	DisplayName           string   `json:"displayName" yaml:"displayName"`
+	State                 string   `json:"state" yaml:"state"`
+	CidrBlocks            []string `json:"cidrBlocks" yaml:"cidrBlocks"`
+	Ipv6CidrBlocks        []string `json:"ipv6CidrBlocks" yaml:"ipv6CidrBlocks"`
+	DefaultSecurityListId string   `json:"defaultSecurityListId" yaml:"defaultSecurityListId"`
+	DefaultRouteTableId   string   `json:"defaultRouteTableId" yaml:"defaultRouteTableId"`
+}
+
+func (r VcnRecord) tableHeader() []string {
+	return []string{"账号", "VCN 名称", "CIDR Block", "IPv6 CIDR", "状态", "OCID"}
+}
+
+func (r VcnRecord) tableRow() []string {
+	return []string{r.Account, r.DisplayName, strings.Join(r.CidrBlocks, ","), strings.Join(r.Ipv6CidrBlocks, ","), r.State, r.Id}
+}
+
+func newVcnRecord(accountName string, v core.Vcn) VcnRecord {
+	r := VcnRecord{
+		Account:               accountName,
+		Id:                    stringValue(v.Id),
+		DisplayName:           stringValue(v.DisplayName),
+		State:                 string(v.LifecycleState),
+		CidrBlocks:            v.CidrBlocks,
+		Ipv6CidrBlocks:        v.Ipv6CidrBlocks,
+		DefaultSecurityListId: stringValue(v.DefaultSecurityListId),
+		DefaultRouteTableId:   stringValue(v.DefaultRouteTableId),
+	}
+	if len(r.CidrBlocks) == 0 && v.CidrBlock != nil {
+		r.CidrBlocks = []string{*v.CidrBlock}
+	}
+	if r.Ipv6CidrBlocks == nil {
+		r.Ipv6CidrBlocks = []string{}
+	}
+	return r
+}
+
+// --- 公共 IP ---
+
+// IPRecord 实例网卡的公共 IP 信息
+type IPRecord struct {
+	Account    string `json:"account" yaml:"account"`
+	InstanceId string `json:"instanceId" yaml:"instanceId"`
+	VnicName   string `json:"vnicName" yaml:"vnicName"`
+	VnicId     string `json:"vnicId" yaml:"vnicId"`
+	PublicIp   string `json:"publicIp" yaml:"publicIp"`
+	PrivateIp  string `json:"privateIp" yaml:"privateIp"`
+}
+
+func (r IPRecord) tableHeader() []string {
+	return []string{"账号", "实例", "公共IP", "私有IP", "实例 OCID"}
+}
+
+func (r IPRecord) tableRow() []string {
+	return []string{r.Account, r.VnicName, r.PublicIp, r.PrivateIp, r.InstanceId}
+}
+
+// ReservedIpRecord 预留公共 IP 信息
+type ReservedIpRecord struct {
+	Account          string    `json:"account" yaml:"account"`
+	Id               string    `json:"id" yaml:"id"`
+	DisplayName      string    `json:"displayName" yaml:"displayName"`
+	IpAddress        string    `json:"ipAddress" yaml:"ipAddress"`
+	State            string    `json:"state" yaml:"state"`
+	AssignedEntityId string    `json:"assignedEntityId" yaml:"assignedEntityId"` // 绑定的私有IP OCID
+	TimeCreated      time.Time `json:"timeCreated" yaml:"timeCreated"`
+}
+
+func (r ReservedIpRecord) tableHeader() []string {
+	return []string{"账号", "名称", "IP", "状态", "OCID"}
+}
+
+func (r ReservedIpRecord) tableRow() []string {
+	return []string{r.Account, r.DisplayName, r.IpAddress, r.State, r.Id}
+}
+
+func newReservedIpRecord(accountName string, p core.PublicIp) ReservedIpRecord {
+	r := ReservedIpRecord{
+		Account:          accountName,
+		Id:               stringValue(p.Id),
+		DisplayName:      stringValue(p.DisplayName),
+		IpAddress:        stringValue(p.IpAddress),
+		State:            string(p.LifecycleState),
+		AssignedEntityId: stringValue(p.AssignedEntityId),
+	}
+	if p.TimeCreated != nil {
+		r.TimeCreated = p.TimeCreated.Time
+	}
+	return r
+}
+
+// collectReservedIpRecords 获取当前账号的预留公共IP
+func collectReservedIpRecords(acc *Account) ([]tableRecord, error) {
+	publicIps, err := listReservedPublicIps(acc)
+	if err != nil {
+		return nil, err
+	}
+	var records []tableRecord
+	for _, p := range publicIps {
+		records = append(records, newReservedIpRecord(acc.Name, p))
+	}
+	return records, nil
+}
+
+// --- 自定义镜像 ---
+
+// ImageRecord 自定义镜像信息
+type ImageRecord struct {
+	Account                string    `json:"account,omitempty" yaml:"account,omitempty"`
+	Id                     string    `json:"id" yaml:"id"`
+	DisplayName            string    `json:"displayName" yaml:"displayName"`
+	State                  string    `json:"state" yaml:"state"`
+	OperatingSystem        string    `json:"operatingSystem" yaml:"operatingSystem"`
+	OperatingSystemVersion string    `json:"operatingSystemVersion" yaml:"operatingSystemVersion"`
+	SizeInGBs              float64   `json:"sizeInGBs" yaml:"sizeInGBs"`
+	TimeCreated            time.Time `json:"timeCreated" yaml:"timeCreated"`
+}
+
+func (r ImageRecord) tableHeader() []string {
+	return []string{"账号", "名称", "状态", "操作系统", "大小(GB)", "创建时间", "OCID"}
+}
+
+func (r ImageRecord) tableRow() []string {
+	return []string{r.Account, r.DisplayName, r.State, strings.TrimSpace(r.OperatingSystem + " " + r.OperatingSystemVersion),
+		fmt.Sprintf("%.1f", r.SizeInGBs), r.TimeCreated.Local().Format("2006-01-02 15:04"), r.Id}
+}
+
+func newImageRecord(accountName string, image core.Image) ImageRecord {
+	r := ImageRecord{
+		Account:                accountName,
+		Id:                     stringValue(image.Id),
+		DisplayName:            stringValue(image.DisplayName),
+		State:                  string(image.LifecycleState),
+		OperatingSystem:        stringValue(image.OperatingSystem),
+		OperatingSystemVersion: stringValue(image.OperatingSystemVersion),
+	}
+	if image.SizeInMBs != nil {
+		r.SizeInGBs = float64(*image.SizeInMBs) / 1024
+	}
+	if image.TimeCreated != nil {
+		r.TimeCreated = image.TimeCreated.Time
+	}
+	return r
+}
+
+// collectImageRecords 获取当前账号的自定义镜像
+func collectImageRecords(acc *Account) ([]tableRecord, error) {
+	images, err := listCustomImages(acc)
+	if err != nil {
+		return nil, err
+	}
+	var records []tableRecord
+	for _, image := range images {
+		records = append(records, newImageRecord(acc.Name, image))
+	}
+	return records, nil
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/oracle/oci-go-sdk/v65/core"
+)
+
+// Always Free 资源对应的 shape
+const (
+	shapeA1Flex  = "VM.Standard.A1.Flex"
+	shapeE2Micro = "VM.Standard.E2.1.Micro"
+)
+
+// 免费额度检查模式
+const (
+	limitModeRefuse = "refuse" // 超出时拒绝创建
+	limitModeWarn   = "warn"   // 超出时仅警告
+	limitModeOff    = "off"    // 不检查
+)
+
+// Limit 免费额度配置, 对应配置文件中的 [LIMIT]
+type Limit struct {
+	Mode              string  `ini:"mode"`
+	A1Ocpus           float32 `ini:"a1Ocpus"`
+	A1MemoryInGBs     float32 `ini:"a1MemoryInGBs"`
+	MicroInstances    int32   `ini:"microInstances"`
+	BlockStorageInGBs int64   `ini:"blockStorageInGBs"`
+}
+
+// defaultLimit 返回甲骨文 Always Free 的默认额度。
+// 默认只警告, 未配置 [LIMIT] 的付费账号不会因为使用非 Always Free 的 shape 而被拒绝创建
+func defaultLimit() Limit {
+	return Limit{
+		Mode:              limitModeWarn,
+		A1Ocpus:           4,
+		A1MemoryInGBs:     24,
+		MicroInstances:    2,
+		BlockStorageInGBs: 200,
+	}
+}
+
+// FreeTierUsage 账号当前占用的免费资源
+type FreeTierUsage struct {
+	A1Ocpus           float32
+	A1MemoryInGBs     float32
+	MicroInstances    int32
+	BlockStorageInGBs int64
+}
+
+// add 返回 u 加上 n 份 o 后的用量, n 为负数时减去
+func (u FreeTierUsage) add(o FreeTierUsage, n int32) FreeTierUsage {
+	u.A1Ocpus += o.A1Ocpus * float32(n)
+	u.A1MemoryInGBs += o.A1MemoryInGBs * float32(n)
+	u.MicroInstances += o.MicroInstances * n
+	u.BlockStorageInGBs += o.BlockStorageInGBs * int64(n)
+	return u
+}
+
+var (
+	quotaMutex    sync.Mutex                   // 保护 quotaLocks 和 quotaReserved
+	quotaLocks    = map[string]*sync.Mutex{}   // 各账号检查并预留额度时使用的锁
+	quotaReserved = map[string]FreeTierUsage{} // 各账号已通过检查但尚未创建的实例预留的额度
+)
+
+// quotaReservation 一个模板通过免费额度检查后预留的额度。
+// 每创建成功一个实例调用 take, 创建结束后调用 release 释放剩余部分。nil 表示未预留
+type quotaReservation struct {
+	account string
+	each    FreeTierUsage // 单个实例占用的资源
+	count   int32         // 剩余预留的实例个数
+}
+
+// accountQuotaLock 返回账号检查并预留额度时使用的锁
+func accountQuotaLock(account string) *sync.Mutex {
+	quotaMutex.Lock()
+	defer quotaMutex.Unlock()
+	lock, ok := quotaLocks[account]
+	if !ok {
+		lock = &sync.Mutex{}
+		quotaLocks[account] = lock
+	}
+	return lock
+}
+
+// reservedQuota 返回账号已预留的额度
+func reservedQuota(account string) FreeTierUsage {
+	quotaMutex.Lock()
+	defer quotaMutex.Unlock()
+	return quotaReserved[account]
+}
+
+// take 一个实例已创建成功, 之后统计用量时会计入该实例, 不再需要为它预留额度
+func (r *quotaReservation) take() {
+	if r == nil || r.count <= 0 {
+		return
+	}
+	quotaMutex.Lock()
+	defer quotaMutex.Unlock()
+	quotaReserved[r.account] = quotaReserved[r.account].add(r.each, -1)
+	r.count--
+}
+
+// release 释放剩余预留的额度
+func (r *quotaReservation) release() {
+	if r == nil || r.count <= 0 {
+		return
+	}
+	quotaMutex.Lock()
+	defer quotaMutex.Unlock()
+	quotaReserved[r.account] = quotaReserved[r.account].add(r.each, -r.count)
+	r.count = 0
+}
+
+// getFreeTierUsage 统计当前账号未终止的 A1/Micro 实例和全部引导卷、块存储卷占用的资源
+func getFreeTierUsage(acc *Account) (usage FreeTierUsage, err error) {
+	instances, err := listAllInstances(acc)
+	if err != nil {
+		return usage, fmt.Errorf("获取实例失败: %v", err)
+	}
+	for _, ins := range instances {
+		if ins.LifecycleState == core.InstanceLifecycleStateTerminating || ins.LifecycleState == core.InstanceLifecycleStateTerminated {
+			continue
+		}
+		switch {
+		case strings.EqualFold(stringValue(ins.Shape), shapeA1Flex):
+			if ins.ShapeConfig != nil && ins.ShapeConfig.Ocpus != nil && ins.ShapeConfig.MemoryInGBs != nil {
+				usage.A1Ocpus += *ins.ShapeConfig.Ocpus
+				usage.A1MemoryInGBs += *ins.ShapeConfig.MemoryInGBs
+			}
+		case strings.EqualFold(stringValue(ins.Shape), shapeE2Micro):
+			usage.MicroInstances++
+		}
+	}
+
+	bootVolumes, err := listAllBootVolumes(acc)
+	if err != nil {
+		return usage, fmt.Errorf("获取引导卷失败: %v", err)
+	}
+	for _, v := range bootVolumes {
+		if v.LifecycleState == core.BootVolumeLifecycleStateTerminating || v.LifecycleState == core.BootVolumeLifecycleStateTerminated {
+			continue
+		}
+		if v.SizeInGBs != nil {
+			usage.BlockStorageInGBs += *v.SizeInGBs
+		}
+	}
+
+	volumes, err := listAllVolumes(acc)
+	if err != nil {
+		return usage, fmt.Errorf("获取块存储卷失败: %v", err)
+	}
+	for _, v := range volumes {
+		if v.LifecycleState == core.VolumeLifecycleStateTerminating {
+			continue
+		}
+		if v.SizeInGBs != nil {
+			usage.BlockStorageInGBs += *v.SizeInGBs
+		}
+	}
+	return usage, nil
+}
+
+// checkBlockStorageQuota 检查再增加 sizeInGBs 的块存储 (新建或扩容块存储卷) 后是否超出免费额度。
+// refuse 模式下超出额度时返回错误, warn 模式下只打印警告
+func checkBlockStorageQuota(acc *Account, sizeInGBs int64) error {
+	if limit.Mode == limitModeOff || sizeInGBs <= 0 {
+		return nil
+	}
+	usage, err := getFreeTierUsage(acc)
+	if err != nil {
+		printlnErr(fmt.Sprintf("[%s] 检查免费额度失败", acc.Name), err.Error())
+		return nil
+	}
+	usage = usage.add(reservedQuota(acc.Name), 1)
+	if usage.BlockStorageInGBs+sizeInGBs <= limit.BlockStorageInGBs {
+		return nil
+	}
+	text := fmt.Sprintf("将超出免费额度, 块存储(GB): 已用 %d + 新增 %d > 额度 %d", usage.BlockStorageInGBs, sizeInGBs, limit.BlockStorageInGBs)
+	if limit.Mode == limitModeRefuse {
+		return fmt.Errorf("%s, 可在配置文件 [LIMIT] 中调整额度或将 mode 设置为 warn/off", text)
+	}
+	printf("\033[1;33m[%s] %s\033[0m\n", acc.Name, text)
+	return nil
+}
+
+// checkInstanceResizeQuota 检查 flex 实例增加 ocpus 个 OCPU 和 memoryInGBs 内存后是否超出免费额度, 减少配置时不检查。
+// refuse 模式下超出额度时返回错误, warn 模式下只打印警告
+func checkInstanceResizeQuota(acc *Account, shape string, ocpus, memoryInGBs float32) error {
+	if limit.Mode == limitModeOff || (ocpus <= 0 && memoryInGBs <= 0) {
+		return nil
+	}
+	var violations []string
+	if strings.EqualFold(shape, shapeA1Flex) {
+		usage, err := getFreeTierUsage(acc)
+		if err != nil {
+			printlnErr(fmt.Sprintf("[%s] 检查免费额度失败", acc.Name), err.Error())
+			return nil
+		}
+		usage = usage.add(reservedQuota(acc.Name), 1)
+		if ocpus > 0 && usage.A1Ocpus+ocpus > limit.A1Ocpus {
+			violations = append(violations, fmt.Sprintf("A1 OCPU: 已用 %g + 新增 %g > 额度 %g", usage.A1Ocpus, ocpus, limit.A1Ocpus))
+		}
+		if memoryInGBs > 0 && usage.A1MemoryInGBs+memoryInGBs > limit.A1MemoryInGBs {
+			violations = append(violations, fmt.Sprintf("A1 内存(GB): 已用 %g + 新增 %g > 额度 %g", usage.A1MemoryInGBs, memoryInGBs, limit.A1MemoryInGBs))
+		}
+	} else {
+		violations = append(violations, fmt.Sprintf("%s 不是 Always Free 的 shape", shape))
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	text := "将超出免费额度, " + strings.Join(violations, "; ")
+	if limit.Mode == limitModeRefuse {
+		return fmt.Errorf("%s, 可在配置文件 [LIMIT] 中调整额度或将 mode 设置为 warn/off", text)
+	}
+	printf("\033[1;33m[%s] %s\033[0m\n", acc.Name, text)
+	return nil
+}
+
+// checkFreeTierQuota 检查再创建 count 个指定配置的实例后是否超出免费额度, 返回超出的项目说明。
+// 同一账号的检查依次进行, 并计入其他模板已预留的额度; 未被拒绝时为这 count 个实例预留额度,
+// 调用方需要在创建结束后调用返回的 reservation 的 release
+func checkFreeTierQuota(acc *Account, shape string, ocpus, memoryInGBs float32, bootVolumeSizeInGBs int64, count int32) ([]string, *quotaReservation, error) {
+	if limit.Mode == limitModeOff || count <= 0 {
+		return nil, nil, nil
+	}
+	lock := accountQuotaLock(acc.Name)
+	lock.Lock()
+	defer lock.Unlock()
+	usage, err := getFreeTierUsage(acc)
+	if err != nil {
+		return nil, nil, err
+	}
+	usage = usage.add(reservedQuota(acc.Name), 1)
+	each := FreeTierUsage{BlockStorageInGBs: bootVolumeSizeInGBs}
+
+	var violations []string
+	switch {
+	case strings.EqualFold(shape, shapeA1Flex):
+		each.A1Ocpus, each.A1MemoryInGBs = ocpus, memoryInGBs
+		needOcpus := usage.A1Ocpus + ocpus*float32(count)
+		if needOcpus > limit.A1Ocpus {
+			violations = append(violations, fmt.Sprintf("A1 OCPU: 已用 %g + 新增 %g > 额度 %g", usage.A1Ocpus, ocpus*float32(count), limit.A1Ocpus))
+		}
+		needMemory := usage.A1MemoryInGBs + memoryInGBs*float32(count)
+		if needMemory > limit.A1MemoryInGBs {
+			violations = append(violations, fmt.Sprintf("A1 内存(GB): 已用 %g + 新增 %g > 额度 %g", usage.A1MemoryInGBs, memoryInGBs*float32(count), limit.A1MemoryInGBs))
+		}
+	case strings.EqualFold(shape, shapeE2Micro):
+		each.MicroInstances = 1
+		if usage.MicroInstances+count > limit.MicroInstances {
+			violations = append(violations, fmt.Sprintf("E2.1.Micro 实例: 已有 %d + 新增 %d > 额度 %d", usage.MicroInstances, count, limit.MicroInstances))
+		}
+	default:
+		violations = append(violations, fmt.Sprintf("%s 不是 Always Free 的 shape", shape))
+	}
+	needStorage := bootVolumeSizeInGBs * int64(count)
+	if usage.BlockStorageInGBs+needStorage > limit.BlockStorageInGBs {
+		violations = append(violations, fmt.Sprintf("块存储(GB): 已用 %d + 新增 %d > 额度 %d", usage.BlockStorageInGBs, needStorage, limit.BlockStorageInGBs))
+	}
+	if len(violations) > 0 && limit.Mode == limitModeRefuse {
+		return violations, nil, nil
+	}
+	quotaMutex.Lock()
+	quotaReserved[acc.Name] = quotaReserved[acc.Name].add(each, count)
+	quotaMutex.Unlock()
+	return violations, &quotaReservation{account: acc.Name, each: each, count: count}, nil
+}
+
+// QuotaRecord 免费额度使用情况
+type QuotaRecord struct {
+	Account  string  `json:"account" yaml:"account"`
+	Resource string  `json:"resource" yaml:"resource"`
+	Used     float64 `json:"used" yaml:"used"`
+	Limit    float64 `json:"limit" yaml:"limit"`
+}
+
+func (r QuotaRecord) tableHeader() []string {
+	return []string{"账号", "资源", "已用", "额度"}
+}
+
+func (r QuotaRecord) tableRow() []string {
+	return []string{r.Account, r.Resource, fmt.Sprintf("%g", r.Used), fmt.Sprintf("%g", r.Limit)}
+}
+
+// collectQuotaRecords 获取当前账号的免费额度使用情况
+func collectQuotaRecords(acc *Account) ([]tableRecord, error) {
+	usage, err := getFreeTierUsage(acc)
+	if err != nil {
+		return nil, err
+	}
+	return []tableRecord{
+		QuotaRecord{acc.Name, "A1 OCPU", float64(usage.A1Ocpus), float64(limit.A1Ocpus)},
+		QuotaRecord{acc.Name, "A1 内存(GB)", float64(usage.A1MemoryInGBs), float64(limit.A1MemoryInGBs)},
+		QuotaRecord{acc.Name, "E2.1.Micro 实例", float64(usage.MicroInstances), float64(limit.MicroInstances)},
+		QuotaRecord{acc.Name, "块存储(GB)", float64(usage.BlockStorageInGBs), float64(limit.BlockStorageInGBs)},
+	}, nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"sync"
+	"testing"
+)
+
+// TestCheckFreeTierQuotaReserve 同一账号的多个模板同时检查额度时, 已通过检查的模板预留的额度计入用量
+func TestCheckFreeTierQuotaReserve(t *testing.T) {
+	setupLaunchTest(t)
+	limit.Mode = limitModeRefuse
+	acc := newTestAccount(t, "acc", newFakeOCI())
+
+	// A1 额度为 4 OCPU, 3 个模板各需要 2 OCPU, 只能有 2 个通过检查
+	const templates = 3
+	reservations := make([]*quotaReservation, templates)
+	var refused int
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < templates; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			violations, reservation, err := checkFreeTierQuota(acc, shapeA1Flex, 1, 6, 50, 2)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			reservations[i] = reservation
+			if len(violations) > 0 {
+				refused++
+			}
+		}(i)
+	}
+	wg.Wait()
+	if refused != 1 {
+		t.Fatalf("%d 个模板被拒绝, 期望 1 个", refused)
+	}
+	if got := reservedQuota(acc.Name); got.A1Ocpus != 4 || got.BlockStorageInGBs != 200 {
+		t.Errorf("预留的额度: %+v, 期望 4 OCPU 和 200GB", got)
+	}
+
+	// 创建成功的实例不再预留, 创建结束后释放剩余部分
+	for _, r := range reservations {
+		r.take()
+		r.release()
+	}
+	if got := reservedQuota(acc.Name); got != (FreeTierUsage{}) {
+		t.Errorf("释放后仍有预留的额度: %+v", got)
+	}
+	if _, reservation, err := checkFreeTierQuota(acc, shapeA1Flex, 1, 6, 50, 2); err != nil || reservation == nil {
+		t.Errorf("释放后应通过检查: err=%v", err)
+	} else {
+		reservation.release()
+	}
+}
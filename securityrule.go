@@ -0,0 +1,478 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/core"
+)
+
+// 安全规则的协议编号
+const (
+	protocolAll    = "all"
+	protocolICMP   = "1"
+	protocolTCP    = "6"
+	protocolUDP    = "17"
+	protocolICMPv6 = "58"
+)
+
+// protocolNames 协议编号对应的名称
+var protocolNames = map[string]string{
+	protocolAll:    "全部",
+	protocolICMP:   "ICMP",
+	protocolTCP:    "TCP",
+	protocolUDP:    "UDP",
+	protocolICMPv6: "ICMPv6",
+}
+
+// parseProtocol 将 all/tcp/udp/icmp/icmpv6 或协议编号转换为安全规则使用的协议编号
+func parseProtocol(s string) (string, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for number, name := range protocolNames {
+		if s == number || s == strings.ToLower(name) {
+			return number, nil
+		}
+	}
+	if n, err := strconv.Atoi(s); err == nil && 0 <= n && n <= 255 {
+		return s, nil
+	}
+	return "", fmt.Errorf("不支持的协议: %s", s)
+}
+
+// ruleOptions 安全规则的协议与端口, 与方向无关, 可以转换为安全列表或网络安全组的规则
+type ruleOptions struct {
+	Protocol    string // 协议编号, 见 protocolAll 等
+	PortMin     int    // TCP/UDP 目标端口范围, 0 表示所有端口
+	PortMax     int
+	IcmpType    *int // ICMP 类型, nil 表示所有类型
+	IcmpCode    *int
+	Stateless   bool
+	Description string
+}
+
+// parsePortRange 解析 "80"、"8000-9000" 形式的端口范围, 空字符串或 "all" 表示所有端口
+func parsePortRange(s string) (min, max int, err error) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.EqualFold(s, "all") {
+		return 0, 0, nil
+	}
+	parts := strings.SplitN(s, "-", 2)
+	min, err = strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return 0, 0, fmt.Errorf("无效的端口: %s", s)
+	}
+	max = min
+	if len(parts) == 2 {
+		max, err = strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return 0, 0, fmt.Errorf("无效的端口: %s", s)
+		}
+	}
+	if min < 1 || max > 65535 || min > max {
+		return 0, 0, fmt.Errorf("无效的端口范围: %s", s)
+	}
+	return min, max, nil
+}
+
+// parseIcmpType 解析 "3"、"3,4" (类型,代码) 形式的 ICMP 类型, 空字符串或 "all" 表示所有类型
+func parseIcmpType(s string) (icmpType, icmpCode *int, err error) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.EqualFold(s, "all") {
+		return nil, nil, nil
+	}
+	parts := strings.SplitN(s, ",", 2)
+	t, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil || t < 0 || t > 255 {
+		return nil, nil, fmt.Errorf("无效的 ICMP 类型: %s", s)
+	}
+	icmpType = common.Int(t)
+	if len(parts) == 2 {
+		c, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil || c < 0 || c > 255 {
+			return nil, nil, fmt.Errorf("无效的 ICMP 代码: %s", s)
+		}
+		icmpCode = common.Int(c)
+	}
+	return icmpType, icmpCode, nil
+}
+
+// checkCIDR 校验 CIDR, 不带前缀长度的 IP 视为单个地址
+func checkCIDR(s string) (string, error) {
+	cidrs, err := parseCIDRs(s)
+	if err != nil {
+		return "", err
+	}
+	if len(cidrs) != 1 {
+		return "", fmt.Errorf("请输入一个 CIDR")
+	}
+	return cidrs[0].String(), nil
+}
+
+func (o ruleOptions) portRange() *core.PortRange {
+	if o.PortMin == 0 {
+		return nil
+	}
+	return &core.PortRange{Min: common.Int(o.PortMin), Max: common.Int(o.PortMax)}
+}
+
+func (o ruleOptions) tcpOptions() *core.TcpOptions {
+	if o.Protocol != protocolTCP || o.PortMin == 0 {
+		return nil
+	}
+	return &core.TcpOptions{DestinationPortRange: o.portRange()}
+}
+
+func (o ruleOptions) udpOptions() *core.UdpOptions {
+	if o.Protocol != protocolUDP || o.PortMin == 0 {
+		return nil
+	}
+	return &core.UdpOptions{DestinationPortRange: o.portRange()}
+}
+
+func (o ruleOptions) icmpOptions() *core.IcmpOptions {
+	if (o.Protocol != protocolICMP && o.Protocol != protocolICMPv6) || o.IcmpType == nil {
+		return nil
+	}
+	return &core.IcmpOptions{Type: o.IcmpType, Code: o.IcmpCode}
+}
+
+func (o ruleOptions) description() *string {
+	if o.Description == "" {
+		return nil
+	}
+	return common.String(o.Description)
+}
+
+// ingress 转换为安全列表的入站规则
+func (o ruleOptions) ingress(source string) core.IngressSecurityRule {
+	return core.IngressSecurityRule{
+		Protocol:    common.String(o.Protocol),
+		Source:      common.String(source),
+		SourceType:  core.IngressSecurityRuleSourceTypeCidrBlock,
+		IsStateless: common.Bool(o.Stateless),
+		TcpOptions:  o.tcpOptions(),
+		UdpOptions:  o.udpOptions(),
+		IcmpOptions: o.icmpOptions(),
+		Description: o.description(),
+	}
+}
+
+// egress 转换为安全列表的出站规则
+func (o ruleOptions) egress(destination string) core.EgressSecurityRule {
+	return core.EgressSecurityRule{
+		Protocol:        common.String(o.Protocol),
+		Destination:     common.String(destination),
+		DestinationType: core.EgressSecurityRuleDestinationTypeCidrBlock,
+		IsStateless:     common.Bool(o.Stateless),
+		TcpOptions:      o.tcpOptions(),
+		UdpOptions:      o.udpOptions(),
+		IcmpOptions:     o.icmpOptions(),
+		Description:     o.description(),
+	}
+}
+
+// nsgRule 转换为网络安全组的规则, ingress 为 true 时 cidr 为源地址, 否则为目标地址
+func (o ruleOptions) nsgRule(ingress bool, cidr string) core.AddSecurityRuleDetails {
+	r := core.AddSecurityRuleDetails{
+		Protocol:    common.String(o.Protocol),
+		IsStateless: common.Bool(o.Stateless),
+		TcpOptions:  o.tcpOptions(),
+		UdpOptions:  o.udpOptions(),
+		IcmpOptions: o.icmpOptions(),
+		Description: o.description(),
+	}
+	if ingress {
+		r.Direction = core.AddSecurityRuleDetailsDirectionIngress
+		r.Source = common.String(cidr)
+		r.SourceType = core.AddSecurityRuleDetailsSourceTypeCidrBlock
+	} else {
+		r.Direction = core.AddSecurityRuleDetailsDirectionEgress
+		r.Destination = common.String(cidr)
+		r.DestinationType = core.AddSecurityRuleDetailsDestinationTypeCidrBlock
+	}
+	return r
+}
+
+// formatRule 将规则格式化为一行文字, 用于显示和比较差异
+func formatRule(cidr, protocol *string, tcp *core.TcpOptions, udp *core.UdpOptions, icmp *core.IcmpOptions, stateless *bool, desc *string) string {
+	name, ok := protocolNames[stringValue(protocol)]
+	if !ok {
+		name = "协议 " + stringValue(protocol)
+	}
+	s := fmt.Sprintf("%-18s %s", stringValue(cidr), name)
+	var dst, src *core.PortRange
+	if tcp != nil {
+		dst, src = tcp.DestinationPortRange, tcp.SourcePortRange
+	}
+	if udp != nil {
+		dst, src = udp.DestinationPortRange, udp.SourcePortRange
+	}
+	if dst != nil {
+		s += " 端口 " + formatPortRange(dst)
+	} else if tcp != nil || udp != nil || stringValue(protocol) == protocolTCP || stringValue(protocol) == protocolUDP {
+		s += " 端口 全部"
+	}
+	if src != nil {
+		s += " 源端口 " + formatPortRange(src)
+	}
+	if icmp != nil && icmp.Type != nil {
+		s += fmt.Sprintf(" 类型 %d", *icmp.Type)
+		if icmp.Code != nil {
+			s += fmt.Sprintf(" 代码 %d", *icmp.Code)
+		}
+	}
+	if stateless != nil && *stateless {
+		s += " (无状态)"
+	}
+	if desc != nil && *desc != "" {
+		s += " " + *desc
+	}
+	return s
+}
+
+func formatPortRange(r *core.PortRange) string {
+	if r.Min == nil || r.Max == nil {
+		return "全部"
+	}
+	if *r.Min == *r.Max {
+		return strconv.Itoa(*r.Min)
+	}
+	return fmt.Sprintf("%d-%d", *r.Min, *r.Max)
+}
+
+func formatIngressRule(r core.IngressSecurityRule) string {
+	return formatRule(r.Source, r.Protocol, r.TcpOptions, r.UdpOptions, r.IcmpOptions, r.IsStateless, r.Description)
+}
+
+func formatEgressRule(r core.EgressSecurityRule) string {
+	return formatRule(r.Destination, r.Protocol, r.TcpOptions, r.UdpOptions, r.IcmpOptions, r.IsStateless, r.Description)
+}
+
+// formatNsgRule 格式化网络安全组的规则
+func formatNsgRule(r core.SecurityRule) string {
+	if r.Direction == core.SecurityRuleDirectionIngress {
+		return "入站 源: " + formatRule(r.Source, r.Protocol, r.TcpOptions, r.UdpOptions, r.IcmpOptions, r.IsStateless, r.Description)
+	}
+	return "出站 目标: " + formatRule(r.Destination, r.Protocol, r.TcpOptions, r.UdpOptions, r.IcmpOptions, r.IsStateless, r.Description)
+}
+
+// formatAddNsgRule 格式化待添加的网络安全组规则
+func formatAddNsgRule(r core.AddSecurityRuleDetails) string {
+	return formatNsgRule(core.SecurityRule{
+		Direction:   core.SecurityRuleDirectionEnum(r.Direction),
+		Protocol:    r.Protocol,
+		Source:      r.Source,
+		Destination: r.Destination,
+		IsStateless: r.IsStateless,
+		TcpOptions:  r.TcpOptions,
+		UdpOptions:  r.UdpOptions,
+		IcmpOptions: r.IcmpOptions,
+		Description: r.Description,
+	})
+}
+
+// diffRules 按格式化后的文字比较规则, 返回被删除和新增的规则
+func diffRules(before, after []string) (removed, added []string) {
+	count := map[string]int{}
+	for _, r := range before {
+		count[r]++
+	}
+	for _, r := range after {
+		if count[r] > 0 {
+			count[r]--
+		} else {
+			added = append(added, r)
+		}
+	}
+	for _, r := range before {
+		if count[r] > 0 {
+			count[r]--
+			removed = append(removed, r)
+		}
+	}
+	return
+}
+
+// printRuleDiff 打印规则的变化, 没有变化时返回 false
+func printRuleDiff(title string, before, after []string) bool {
+	removed, added := diffRules(before, after)
+	if len(removed) == 0 && len(added) == 0 {
+		return false
+	}
+	fmt.Printf("--- %s ---\n", title)
+	for _, r := range removed {
+		fmt.Printf("\033[1;31m- %s\033[0m\n", r)
+	}
+	for _, r := range added {
+		fmt.Printf("\033[1;32m+ %s\033[0m\n", r)
+	}
+	return true
+}
+
+func formatIngressRules(rules []core.IngressSecurityRule) (lines []string) {
+	for _, r := range rules {
+		lines = append(lines, formatIngressRule(r))
+	}
+	return
+}
+
+func formatEgressRules(rules []core.EgressSecurityRule) (lines []string) {
+	for _, r := range rules {
+		lines = append(lines, formatEgressRule(r))
+	}
+	return
+}
+
+// --- 预设规则 ---
+
+// 预设的入站规则
+const (
+	presetAllowAll = "allow-all" // 允许所有入站流量
+	presetWeb      = "web"       // 开放 80/443
+	presetSSHMyIp  = "ssh-my-ip" // 仅允许本机IP访问 SSH
+	presetDefault  = "default"   // 甲骨文的默认规则: 开放 SSH 和必要的 ICMP
+	presetNone     = "none"      // 不修改
+)
+
+// securityPresets 预设规则的名称和说明, 按显示顺序排列
+var securityPresets = [][2]string{
+	{presetWeb, "开放 80/443 端口"},
+	{presetSSHMyIp, "仅允许指定IP访问 SSH (22), 删除其他来自公网 IPv4 地址的允许 SSH 的入站规则"},
+	{presetAllowAll, "允许所有入站流量"},
+	{presetDefault, "恢复甲骨文的默认规则: 仅开放 SSH (22) 和必要的 ICMP, 保留来自 VCN 内部和 IPv6 地址的规则"},
+}
+
+// isSecurityPreset 检查是否是有效的预设规则名称
+func isSecurityPreset(name string) bool {
+	if name == presetNone {
+		return true
+	}
+	for _, p := range securityPresets {
+		if p[0] == name {
+			return true
+		}
+	}
+	return false
+}
+
+// applySecurityPreset 将预设规则应用到入站规则, 返回新的入站规则。myIp 仅 ssh-my-ip 使用,
+// vcnCidrs 为安全列表所在 VCN 的 CIDR, 来源在 VCN 内部或为 IPv6 地址的规则不会被删除
+func applySecurityPreset(name string, rules []core.IngressSecurityRule, myIp string, vcnCidrs []string) ([]core.IngressSecurityRule, error) {
+	anywhere := "0.0.0.0/0"
+	tcp := func(port int, desc string) ruleOptions {
+		return ruleOptions{Protocol: protocolTCP, PortMin: port, PortMax: port, Description: desc}
+	}
+	result := append([]core.IngressSecurityRule{}, rules...)
+	switch name {
+	case presetNone:
+	case presetAllowAll:
+		result = appendIngressRule(result, ruleOptions{Protocol: protocolAll}.ingress(anywhere))
+	case presetWeb:
+		result = appendIngressRule(result, tcp(80, "HTTP").ingress(anywhere))
+		result = appendIngressRule(result, tcp(443, "HTTPS").ingress(anywhere))
+	case presetSSHMyIp:
+		cidr, err := checkCIDR(myIp)
+		if err != nil {
+			return nil, err
+		}
+		result = result[:0]
+		for _, r := range rules {
+			if !ingressAllowsTcpPort(r, 22) || isKeptIngressSource(stringValue(r.Source), vcnCidrs) {
+				result = append(result, r)
+			}
+		}
+		result = appendIngressRule(result, tcp(22, "SSH").ingress(cidr))
+	case presetDefault:
+		result = []core.IngressSecurityRule{
+			tcp(22, "").ingress(anywhere),
+			ruleOptions{Protocol: protocolICMP, IcmpType: common.Int(3), IcmpCode: common.Int(4)}.ingress(anywhere),
+		}
+		for _, cidr := range vcnCidrs {
+			result = appendIngressRule(result, ruleOptions{Protocol: protocolICMP, IcmpType: common.Int(3)}.ingress(cidr))
+		}
+		for _, r := range rules {
+			if isKeptIngressSource(stringValue(r.Source), vcnCidrs) {
+				result = appendIngressRule(result, r)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("未知的预设规则: %s", name)
+	}
+	return result, nil
+}
+
+// isKeptIngressSource 检查入站规则的来源是否在 VCN 内部或为 IPv6 地址, 应用预设规则时保留这些规则
+func isKeptIngressSource(source string, vcnCidrs []string) bool {
+	ip, ipNet, err := net.ParseCIDR(source)
+	if err != nil {
+		return false
+	}
+	if ip.To4() == nil {
+		return true
+	}
+	ones, _ := ipNet.Mask.Size()
+	for _, c := range vcnCidrs {
+		_, vcnNet, err := net.ParseCIDR(c)
+		if err != nil {
+			continue
+		}
+		vcnOnes, _ := vcnNet.Mask.Size()
+		if vcnNet.Contains(ip) && ones >= vcnOnes {
+			return true
+		}
+	}
+	return false
+}
+
+// appendIngressRule 添加入站规则, 已存在相同的规则时不重复添加
+func appendIngressRule(rules []core.IngressSecurityRule, rule core.IngressSecurityRule) []core.IngressSecurityRule {
+	s := formatIngressRule(rule)
+	for _, r := range rules {
+		if formatIngressRule(r) == s {
+			return rules
+		}
+	}
+	return append(rules, rule)
+}
+
+// ingressAllowsTcpPort 检查入站规则是否允许访问指定的 TCP 端口
+func ingressAllowsTcpPort(r core.IngressSecurityRule, port int) bool {
+	switch stringValue(r.Protocol) {
+	case protocolAll:
+		return true
+	case protocolTCP:
+		if r.TcpOptions == nil || r.TcpOptions.DestinationPortRange == nil {
+			return true
+		}
+		pr := r.TcpOptions.DestinationPortRange
+		return pr.Min != nil && pr.Max != nil && *pr.Min <= port && port <= *pr.Max
+	}
+	return false
+}
+
+// getMyPublicIp 获取本机访问互联网使用的公共IP, 配置了代理时获取的是代理的IP
+func getMyPublicIp() (string, error) {
+	resp, err := newHTTPClient().Get("https://api.ipify.org")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.New(resp.Status)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	ip := strings.TrimSpace(string(body))
+	if net.ParseIP(ip) == nil {
+		return "", fmt.Errorf("无效的IP地址: %s", ip)
+	}
+	return ip, nil
+}
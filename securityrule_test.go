@@ -0,0 +1,104 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/core"
+)
+
+func TestApplySecurityPreset(t *testing.T) {
+	vcnCidrs := []string{"172.16.0.0/16"}
+	ssh := ruleOptions{Protocol: protocolTCP, PortMin: 22, PortMax: 22}
+	all := ruleOptions{Protocol: protocolAll}
+	rules := []core.IngressSecurityRule{
+		ssh.ingress("0.0.0.0/0"),
+		ssh.ingress("::/0"),
+		all.ingress("172.16.0.0/16"),
+		all.ingress("172.16.1.0/24"),
+		all.ingress("10.0.0.0/16"),
+		ruleOptions{Protocol: protocolTCP, PortMin: 80, PortMax: 80}.ingress("0.0.0.0/0"),
+	}
+
+	tests := []struct {
+		preset string
+		myIp   string
+		want   []core.IngressSecurityRule
+	}{
+		{presetSSHMyIp, "192.0.2.1", []core.IngressSecurityRule{
+			ssh.ingress("::/0"),
+			all.ingress("172.16.0.0/16"),
+			all.ingress("172.16.1.0/24"),
+			ruleOptions{Protocol: protocolTCP, PortMin: 80, PortMax: 80}.ingress("0.0.0.0/0"),
+			ruleOptions{Protocol: protocolTCP, PortMin: 22, PortMax: 22, Description: "SSH"}.ingress("192.0.2.1/32"),
+		}},
+		{presetDefault, "", []core.IngressSecurityRule{
+			ssh.ingress("0.0.0.0/0"),
+			ruleOptions{Protocol: protocolICMP, IcmpType: common.Int(3), IcmpCode: common.Int(4)}.ingress("0.0.0.0/0"),
+			ruleOptions{Protocol: protocolICMP, IcmpType: common.Int(3)}.ingress("172.16.0.0/16"),
+			ssh.ingress("::/0"),
+			all.ingress("172.16.0.0/16"),
+			all.ingress("172.16.1.0/24"),
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.preset, func(t *testing.T) {
+			got, err := applySecurityPreset(tt.preset, rules, tt.myIp, vcnCidrs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(formatIngressRules(got), formatIngressRules(tt.want)) {
+				t.Errorf("applySecurityPreset(%s) =\n%v\n期望\n%v", tt.preset, formatIngressRules(got), formatIngressRules(tt.want))
+			}
+		})
+	}
+}
+
+func TestParsePortRange(t *testing.T) {
+	tests := []struct {
+		in       string
+		min, max int
+		wantErr  bool
+	}{
+		{"", 0, 0, false},
+		{"ALL", 0, 0, false},
+		{"22", 22, 22, false},
+		{" 8000 - 9000 ", 8000, 9000, false},
+		{"1-65535", 1, 65535, false},
+		{"65535", 65535, 65535, false},
+		{"443-443", 443, 443, false},
+		{"0", 0, 0, true},
+		{"65536", 0, 0, true},
+		{"9000-8000", 0, 0, true},
+		{"80-", 0, 0, true},
+		{"-80", 0, 0, true},
+		{"http", 0, 0, true},
+	}
+	for _, tt := range tests {
+		min, max, err := parsePortRange(tt.in)
+		if (err != nil) != tt.wantErr || min != tt.min || max != tt.max {
+			t.Errorf("parsePortRange(%q) = %d, %d, %v, 期望 %d, %d, 出错: %v", tt.in, min, max, err, tt.min, tt.max, tt.wantErr)
+		}
+	}
+}
+
+func TestDiffRules(t *testing.T) {
+	tests := []struct {
+		before, after  []string
+		removed, added []string
+	}{
+		{[]string{"a", "b"}, []string{"a", "b"}, nil, nil},
+		{[]string{"a", "b"}, []string{"b", "c"}, []string{"a"}, []string{"c"}},
+		// 重复的规则按个数比较
+		{[]string{"a", "a", "b"}, []string{"a", "b", "b"}, []string{"a"}, []string{"b"}},
+		{nil, []string{"a"}, nil, []string{"a"}},
+		{[]string{"a"}, nil, []string{"a"}, nil},
+	}
+	for _, tt := range tests {
+		removed, added := diffRules(tt.before, tt.after)
+		if !reflect.DeepEqual(removed, tt.removed) || !reflect.DeepEqual(added, tt.added) {
+			t.Errorf("diffRules(%v, %v) = %v, %v, 期望 %v, %v", tt.before, tt.after, removed, added, tt.removed, tt.added)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/core"
+)
+
+// 默认的创建进度文件
+const defStateFilePath = "./oci-help-state.json"
+
+// stateFilePath 创建进度文件路径, 可在配置文件中通过 stateFile 修改
+var stateFilePath = defStateFilePath
+
+// launchStateSaveInterval 连续创建失败时, 每尝试多少次保存一次创建进度
+const launchStateSaveInterval = 10
+
+// stateMutex 保护进度文件的读-改-写, 多个模板并发创建时共用同一个文件
+var stateMutex sync.Mutex
+
+// CreatedInstance 已成功创建的实例
+type CreatedInstance struct {
+	Id                 string    `json:"id"`
+	DisplayName        string    `json:"displayName"`
+	AvailabilityDomain string    `json:"availabilityDomain"`
+	TimeCreated        time.Time `json:"timeCreated"`
+}
+
+// LaunchState 单个账号下单个实例模板的创建进度, 进程重启后据此继续创建
+type LaunchState struct {
+	Account     string            `json:"account"`
+	Template    string            `json:"template"`
+	Fingerprint string            `json:"fingerprint"` // 保存进度时模板的配置, 见 launchFingerprint
+	AdCount     int32             `json:"adCount"`     // 保存进度时区域的可用性域个数
+	Name        string            `json:"name"`        // 实例名称前缀
+	Sum         int32             `json:"sum"`         // 计划创建个数
+	Pos         int32             `json:"pos"`         // 当前正在创建第几个实例 (从 0 开始)
+	Created     []CreatedInstance `json:"created"`     // 已创建的实例
+	RunTimes    int32             `json:"runTimes"`    // 当前实例的尝试次数
+	FailTimes   int32             `json:"failTimes"`   // 当前实例的失败轮数
+	StartTime   time.Time         `json:"startTime"`   // 当前实例的开始时间
+	AdIndex     int32             `json:"adIndex"`     // 下一个尝试的可用性域序号
+	AdName      string            `json:"adName"`      // 当前尝试的可用性域
+	ExcludedAds []string          `json:"excludedAds"` // 因不可重试的错误被排除的可用性域
+	SkipAds     map[string]bool   `json:"skipAds"`     // 本轮各可用性域是否出现不可重试的错误
+	UpdatedAt   time.Time         `json:"updatedAt"`
+}
+
+func launchStateKey(account, template string) string {
+	return account + "/" + template
+}
+
+// launchFingerprint 返回模板中决定创建顺序的配置, 这些配置修改后原有的进度 (如可用性域序号) 不再适用
+func launchFingerprint(spec Instance) string {
+	return fmt.Sprintf("shape=%s sum=%d each=%d ad=%s name=%s", spec.Shape, spec.Sum, spec.Each, spec.AvailabilityDomain, spec.InstanceDisplayName)
+}
+
+func readLaunchStates() (map[string]*LaunchState, error) {
+	states := map[string]*LaunchState{}
+	content, err := ioutil.ReadFile(stateFilePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return states, nil
+		}
+		return nil, err
+	}
+	if len(content) == 0 {
+		return states, nil
+	}
+	err = json.Unmarshal(content, &states)
+	return states, err
+}
+
+// writeLaunchStates 先写入临时文件再重命名, 避免进程中途退出时损坏进度文件
+func writeLaunchStates(states map[string]*LaunchState) error {
+	content, err := json.MarshalIndent(states, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(stateFilePath), ".oci-help-state-*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(content)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), stateFilePath)
+}
+
+// loadLaunchStates 读取所有账号和模板的创建进度
+func loadLaunchStates() (map[string]*LaunchState, error) {
+	stateMutex.Lock()
+	defer stateMutex.Unlock()
+	return readLaunchStates()
+}
+
+// loadLaunchState 读取指定账号和模板的创建进度, 不存在时返回 nil
+func loadLaunchState(account, template string) (*LaunchState, error) {
+	stateMutex.Lock()
+	defer stateMutex.Unlock()
+	states, err := readLaunchStates()
+	if err != nil {
+		return nil, err
+	}
+	return states[launchStateKey(account, template)], nil
+}
+
+// saveLaunchState 保存创建进度
+func saveLaunchState(state *LaunchState) error {
+	stateMutex.Lock()
+	defer stateMutex.Unlock()
+	states, err := readLaunchStates()
+	if err != nil {
+		return err
+	}
+	state.UpdatedAt = time.Now()
+	states[launchStateKey(state.Account, state.Template)] = state
+	return writeLaunchStates(states)
+}
+
+// deleteLaunchState 删除创建进度, 模板全部执行完毕后调用
+func deleteLaunchState(account, template string) error {
+	stateMutex.Lock()
+	defer stateMutex.Unlock()
+	states, err := readLaunchStates()
+	if err != nil {
+		return err
+	}
+	key := launchStateKey(account, template)
+	if _, ok := states[key]; !ok {
+		return nil
+	}
+	delete(states, key)
+	return writeLaunchStates(states)
+}
+
+// reconcileLaunchState 将进度中记录的实例与账号下实际存在的实例比对:
+// 已被终止的实例会从进度中移除, 以便重新创建; 创建成功但未来得及保存进度 (如进程在此期间退出) 的实例,
+// 即在当前实例开始创建后创建、shape 一致且名称为 name 或 name-<序号> 的实例, 会补记到进度中, 避免重复创建
+func reconcileLaunchState(state *LaunchState, instances []core.Instance, shape string) {
+	alive := map[string]bool{}
+	for _, ins := range instances {
+		if ins.LifecycleState != core.InstanceLifecycleStateTerminating && ins.LifecycleState != core.InstanceLifecycleStateTerminated {
+			alive[*ins.Id] = true
+		}
+	}
+	var created []CreatedInstance
+	recorded := map[string]bool{}
+	for _, c := range state.Created {
+		recorded[c.Id] = true
+		if alive[c.Id] {
+			created = append(created, c)
+		} else {
+			printf("\033[1;33m[%s] 实例 %s 已不存在, 将重新创建\033[0m\n", state.Account, c.DisplayName)
+		}
+	}
+	removed := int32(len(state.Created) - len(created))
+	var adopted int32
+	for _, ins := range templateInstances(instances, shape, state.Name) {
+		if recorded[*ins.Id] || ins.TimeCreated == nil || ins.TimeCreated.Time.Before(state.StartTime) {
+			continue
+		}
+		printf("\033[1;33m[%s] 实例 %s 已创建但未记录在进度中, 计入已创建的实例\033[0m\n", state.Account, stringValue(ins.DisplayName))
+		created = append(created, CreatedInstance{
+			Id:                 *ins.Id,
+			DisplayName:        stringValue(ins.DisplayName),
+			AvailabilityDomain: stringValue(ins.AvailabilityDomain),
+			TimeCreated:        ins.TimeCreated.Time,
+		})
+		adopted++
+	}
+	state.Pos += adopted - removed
+	if state.Pos < int32(len(created)) {
+		state.Pos = int32(len(created))
+	}
+	state.Created = created
+}
+
+// restoreLaunchState 读取并核对模板的创建进度, 没有进度或读取失败时返回 nil。
+// 模板配置或可用性域个数与保存进度时不同时丢弃原有的进度, 重新开始创建
+func restoreLaunchState(acc *Account, spec Instance, adCount int32) *LaunchState {
+	template := spec.Template
+	if template == "" {
+		return nil
+	}
+	state, err := loadLaunchState(acc.Name, template)
+	if err != nil {
+		printlnErr(fmt.Sprintf("[%s] 读取创建进度失败", acc.Name), err.Error())
+		return nil
+	}
+	if state == nil {
+		return nil
+	}
+	if state.Fingerprint != launchFingerprint(spec) || state.AdCount != adCount {
+		printf("\033[1;33m[%s] 模板 %s 的配置或区域的可用性域已变更, 丢弃原有的创建进度并重新开始\033[0m\n", acc.Name, template)
+		clearLaunchState(acc.Name, template)
+		return nil
+	}
+	instances, err := listAllInstances(acc)
+	if err != nil {
+		printlnErr(fmt.Sprintf("[%s] 核对创建进度失败, 将按进度文件继续", acc.Name), err.Error())
+	} else {
+		reconcileLaunchState(state, instances, spec.Shape)
+	}
+	printf("\033[1;36m[%s] 恢复模板 %s 的创建进度: 已创建 %d 个, 从第 %d 个继续 (上次更新: %s)\033[0m\n",
+		acc.Name, template, len(state.Created), state.Pos+1, state.UpdatedAt.Format("2006-01-02 15:04:05"))
+	return state
+}
+
+// clearLaunchState 删除创建进度并打印结果
+func clearLaunchState(account, template string) {
+	err := deleteLaunchState(account, template)
+	if err != nil {
+		printlnErr(fmt.Sprintf("[%s] 删除模板 %s 的创建进度失败", account, template), err.Error())
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/core"
+)
+
+// TestLaunchStateConcurrent 多个模板同时保存和删除进度时, 各自的进度互不覆盖
+func TestLaunchStateConcurrent(t *testing.T) {
+	oldStateFilePath := stateFilePath
+	defer func() { stateFilePath = oldStateFilePath }()
+	stateFilePath = filepath.Join(t.TempDir(), "state.json")
+
+	const templates = 10
+	var wg sync.WaitGroup
+	for i := 0; i < templates; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			template := fmt.Sprintf("INSTANCE.T%d", i)
+			for pos := int32(0); pos < 5; pos++ {
+				state := &LaunchState{Account: "acc", Template: template, Sum: 5, Pos: pos}
+				if err := saveLaunchState(state); err != nil {
+					t.Error(err)
+					return
+				}
+				saved, err := loadLaunchState("acc", template)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if saved == nil || saved.Pos != pos {
+					t.Errorf("%s: 读取的进度为 %+v, 期望 pos=%d", template, saved, pos)
+					return
+				}
+			}
+			// 奇数模板执行完毕后删除进度
+			if i%2 == 1 {
+				if err := deleteLaunchState("acc", template); err != nil {
+					t.Error(err)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	states, err := loadLaunchStates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(states) != templates/2 {
+		t.Errorf("剩余 %d 个进度, 期望 %d 个", len(states), templates/2)
+	}
+	for i := 0; i < templates; i += 2 {
+		state := states[launchStateKey("acc", fmt.Sprintf("INSTANCE.T%d", i))]
+		if state == nil || state.Pos != 4 {
+			t.Errorf("INSTANCE.T%d 的进度不正确: %+v", i, state)
+		}
+	}
+}
+
+// TestReconcileLaunchState 已被终止的实例从进度中移除, 创建后未记录在进度中的实例补记到进度中
+func TestReconcileLaunchState(t *testing.T) {
+	start := time.Now()
+	state := &LaunchState{
+		Account:   "acc",
+		Name:      "web",
+		Pos:       3,
+		StartTime: start,
+		Created:   []CreatedInstance{{Id: "a", DisplayName: "web-1"}, {Id: "b", DisplayName: "web-2"}, {Id: "c", DisplayName: "web-3"}},
+	}
+	instance := func(id, name string, lifecycle core.InstanceLifecycleStateEnum, created time.Time) core.Instance {
+		return core.Instance{
+			Id:             common.String(id),
+			DisplayName:    common.String(name),
+			Shape:          common.String(shapeA1Flex),
+			LifecycleState: lifecycle,
+			TimeCreated:    &common.SDKTime{Time: created},
+		}
+	}
+	instances := []core.Instance{
+		instance("a", "web-1", core.InstanceLifecycleStateRunning, start.Add(-time.Hour)),
+		instance("b", "web-2", core.InstanceLifecycleStateTerminated, start.Add(-time.Hour)),
+		// 上次保存进度后创建, 但进程未来得及保存进度
+		instance("d", "web-4", core.InstanceLifecycleStateProvisioning, start.Add(time.Minute)),
+		// 之前执行模板时创建的实例不属于本次进度
+		instance("e", "web-5", core.InstanceLifecycleStateRunning, start.Add(-time.Hour)),
+		instance("f", "other-1", core.InstanceLifecycleStateRunning, start.Add(time.Minute)),
+	}
+	reconcileLaunchState(state, instances, shapeA1Flex)
+	if state.Pos != 2 || len(state.Created) != 2 || state.Created[0].Id != "a" || state.Created[1].Id != "d" {
+		t.Errorf("核对后的进度不正确: pos=%d created=%+v", state.Pos, state.Created)
+	}
+}
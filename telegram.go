@@ -8,93 +8,80 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
-
-	"github.com/oracle/oci-go-sdk/v65/common"
 )
 
-// Message 是 Telegram Bot API 响应的结构体
-type Message struct {
-	OK          bool `json:"ok"`
-	Result      `json:"result"`
-	ErrorCode   int    `json:"error_code"`
-	Description string `json:"description"`
+// telegramResponse 是 Telegram Bot API 响应的结构体
+type telegramResponse struct {
+	OK          bool            `json:"ok"`
+	Result      json.RawMessage `json:"result"`
+	ErrorCode   int             `json:"error_code"`
+	Description string          `json:"description"`
 }
 
-// Result 包含在成功的 API 响应中的具体结果
-type Result struct {
-	MessageId int `json:"message_id"`
+// Message 是 Telegram 消息的结构体
+type Message struct {
+	MessageId int    `json:"message_id"`
+	Text      string `json:"text"`
+	Chat      struct {
+		Id int64 `json:"id"`
+	} `json:"chat"`
 }
 
-// sendMessage 发送一条新的 Telegram 消息
-func sendMessage(name, text string) (msg Message, err error) {
-	if token == "" || chat_id == "" {
-		return msg, nil
-	}
-
-	data := url.Values{
-		"parse_mode": {"Markdown"},
-		"chat_id":    {chat_id},
-		"text":       {"🔰*甲骨文通知* " + name + "\n" + text},
-	}
-
-	req, err := http.NewRequest(http.MethodPost, sendMessageUrl, strings.NewReader(data.Encode()))
-	if err != nil {
-		return
-	}
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+// TelegramNotifier 通过 Telegram Bot 发送消息, 支持编辑已发送的消息
+type TelegramNotifier struct {
+	Token  string `ini:"token"`
+	ChatId string `ini:"chat_id"`
 
-	client := common.BaseClient{HTTPClient: &http.Client{}}
-	setProxyOrNot(&client)
+	client *http.Client // 不为 nil 时使用该客户端发送请求, 否则按当前代理配置新建
+}
 
-	var resp *http.Response
-	resp, err = client.HTTPClient.Do(req)
-	if err != nil {
-		return
-	}
-	defer resp.Body.Close()
+func (t *TelegramNotifier) Name() string { return "Telegram" }
 
-	var body []byte
-	body, err = ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return
+func (t *TelegramNotifier) check() error {
+	if t.Token == "" || t.ChatId == "" {
+		return errors.New("token 和 chat_id 不能为空")
 	}
+	return nil
+}
 
-	err = json.Unmarshal(body, &msg)
+// Send 发送一条新的 Telegram 消息
+func (t *TelegramNotifier) Send(title, text string) (string, error) {
+	var msg Message
+	err := t.call("sendMessage", url.Values{
+		"parse_mode": {"Markdown"},
+		"chat_id":    {t.ChatId},
+		"text":       {"🔰*甲骨文通知* " + title + "\n" + text},
+	}, &msg)
 	if err != nil {
-		return
-	}
-
-	if !msg.OK {
-		err = errors.New(msg.Description)
+		return "", err
 	}
-
-	return
+	return strconv.Itoa(msg.MessageId), nil
 }
 
-// editMessage 编辑一条已发送的 Telegram 消息
-func editMessage(messageId int, name, text string) (msg Message, err error) {
-	if token == "" || chat_id == "" {
-		return msg, nil
-	}
-
-	data := url.Values{
+// Edit 编辑一条已发送的 Telegram 消息
+func (t *TelegramNotifier) Edit(id, title, text string) error {
+	return t.call("editMessageText", url.Values{
 		"parse_mode": {"Markdown"},
-		"chat_id":    {chat_id},
-		"message_id": {strconv.Itoa(messageId)},
-		"text":       {"🔰*甲骨文通知* " + name + "\n" + text},
-	}
+		"chat_id":    {t.ChatId},
+		"message_id": {id},
+		"text":       {"🔰*甲骨文通知* " + title + "\n" + text},
+	}, nil)
+}
 
-	req, err := http.NewRequest(http.MethodPost, editMessageUrl, strings.NewReader(data.Encode()))
+// call 调用 Telegram Bot API, result 不为 nil 时将响应中的 result 解析到 result
+func (t *TelegramNotifier) call(method string, data url.Values, result interface{}) (err error) {
+	req, err := http.NewRequest(http.MethodPost, "https://api.telegram.org/bot"+t.Token+"/"+method, strings.NewReader(data.Encode()))
 	if err != nil {
 		return
 	}
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	client := common.BaseClient{HTTPClient: &http.Client{}}
-	setProxyOrNot(&client)
-
+	client := t.client
+	if client == nil {
+		client = newHTTPClient()
+	}
 	var resp *http.Response
-	resp, err = client.HTTPClient.Do(req)
+	resp, err = client.Do(req)
 	if err != nil {
 		return
 	}
@@ -106,13 +93,17 @@ func editMessage(messageId int, name, text string) (msg Message, err error) {
 		return
 	}
 
-	err = json.Unmarshal(body, &msg)
+	var r telegramResponse
+	err = json.Unmarshal(body, &r)
 	if err != nil {
 		return
 	}
 
-	if !msg.OK {
-		err = errors.New(msg.Description)
+	if !r.OK {
+		return errors.New(r.Description)
+	}
+	if result != nil {
+		err = json.Unmarshal(r.Result, result)
 	}
 
 	return
@@ -61,7 +61,7 @@ func listOracleAccounts() {
 		w.Flush()
 		fmt.Println()
 
-		fmt.Print("请输入账号序号, 或 'q' 退出, 'oci' 批量创建, 'ip' 批量导出IP: ")
+		fmt.Print("请输入账号序号, 或 'q' 退出, 'oci' 批量创建, 'ip' 批量导出IP, 'cip' 批量更换IP: ")
 		input := readInput()
 
 		if strings.EqualFold(input, "q") {
@@ -77,6 +77,10 @@ func listOracleAccounts() {
 			multiBatchListInstancesIp()
 			promptToContinue()
 			continue
+		} else if strings.EqualFold(input, "cip") {
+			multiBatchChangePublicIp()
+			promptToContinue()
+			continue
 		}
 
 		index, err := strconv.Atoi(input)
@@ -103,6 +107,8 @@ func showMainMenu() {
 		fmt.Println("2. 引导卷管理")
 		fmt.Println("3. 管理员 (IAM)")
 		fmt.Println("4. 网络管理 (VCN与防火墙)")
+		fmt.Println("5. 块存储卷管理")
+		fmt.Println("6. 自定义镜像管理")
 		fmt.Println("\nb. 返回账号选择")
 		fmt.Print("\n请输入操作序号: ")
 
@@ -115,6 +121,10 @@ func showMainMenu() {
 			listAdmins()
 		case "4":
 			showNetworkMenu()
+		case "5":
+			listBlockVolumes()
+		case "6":
+			listCustomImagesMenu()
 		case "b":
 			return
 		default:
@@ -131,6 +141,7 @@ func showInstanceMenu() {
 		printMenuTitle("实例管理")
 		fmt.Println("1. 查看所有实例")
 		fmt.Println("2. 创建实例")
+		fmt.Println("3. 空闲回收检查")
 		fmt.Println("\nb. 返回主菜单")
 		fmt.Print("\n请输入操作序号: ")
 
@@ -139,6 +150,8 @@ func showInstanceMenu() {
 			listInstances()
 		case "2":
 			listLaunchInstanceTemplates()
+		case "3":
+			showIdleReport()
 		case "b":
 			return
 		default:
@@ -148,24 +161,29 @@ func showInstanceMenu() {
 	}
 }
 
+// showIdleReport 检查当前账号运行中的 Always Free 实例是否有因空闲被回收的风险
+func showIdleReport() {
+	printMenuTitle("空闲回收检查")
+	fmt.Println("正在统计最近 7 天的使用率，请稍候...")
+	records, err := collectIdleRecords(account)
+	if err != nil {
+		printlnErr("检查空闲实例失败", err.Error())
+	}
+	if len(records) > 0 {
+		fmt.Println()
+		writeRecords(os.Stdout, outputTable, records)
+	} else if err == nil {
+		fmt.Println("没有运行中的 Always Free 实例。")
+	}
+	fmt.Println("\n甲骨文会回收最近 7 天 CPU、网络 (A1 实例还包括内存) 的 95 百分位使用率均低于 20% 的 Always Free 实例。")
+	promptToContinue()
+}
+
 func listInstances() {
 	for {
 		printMenuTitle("实例列表")
 		fmt.Println("正在获取实例数据...")
-		var instances []core.Instance
-		var ins []core.Instance
-		var nextPage *string
-		var err error
-		for {
-			ins, nextPage, err = ListInstances(ctx, computeClient, nextPage)
-			if err == nil {
-				instances = append(instances, ins...)
-			}
-			if nextPage == nil || len(ins) == 0 {
-				break
-			}
-		}
-
+		instances, err := listAllInstances(account)
 		if err != nil {
 			printlnErr("获取实例失败", err.Error())
 			promptToContinue()
@@ -207,14 +225,14 @@ func instanceDetails(instanceId *string) {
 	for {
 		printMenuTitle("实例详细信息")
 		fmt.Println("正在获取实例详细信息...")
-		instance, err := getInstance(instanceId)
+		instance, err := getInstance(account, instanceId)
 		if err != nil {
 			printlnErr("获取实例详细信息失败", err.Error())
 			promptToContinue()
 			return
 		}
 
-		vnics, _ := getInstanceVnics(instance.Id)
+		vnics, _ := getInstanceVnics(account, instance.Id)
 		var primaryVnic core.Vnic
 		var publicIPs, ipv6Addresses []string
 		var subnetId string
@@ -229,7 +247,7 @@ func instanceDetails(instanceId *string) {
 				if primaryVnic.PublicIp != nil {
 					publicIPs = append(publicIPs, *primaryVnic.PublicIp)
 				}
-				ipv6s, _ := ListIpv6s(primaryVnic.Id)
+				ipv6s, _ := ListIpv6s(account, primaryVnic.Id)
 				for _, ipv6 := range ipv6s {
 					ipv6Addresses = append(ipv6Addresses, *ipv6.IpAddress)
 				}
@@ -256,24 +274,33 @@ func instanceDetails(instanceId *string) {
 		fmt.Println("1. 启动   2. 停止   3. 重启   4. 终止")
 		fmt.Println("5. 管理 IPv6 地址")
 		fmt.Println("6. 查看实例流量")
+		fmt.Println("7. 更换公共 IPv4")
+		fmt.Println("8. 管理网络安全组")
+		fmt.Println("9. 管理网卡和私有IP")
+		fmt.Println("10. 查看监控指标 (CPU/内存/磁盘/网络)")
+		fmt.Println("11. 修改名称")
+		fmt.Println("12. 修改配置 (OCPU/内存)")
+		fmt.Println("13. 管理 Oracle Cloud Agent 插件")
+		fmt.Println("14. 控制台连接与控制台历史")
+		fmt.Println("15. 创建自定义镜像")
 		fmt.Println("\nb. 返回实例列表")
 		fmt.Print("\n请输入操作序号: ")
 
 		input := readInput()
 		switch input {
 		case "1":
-			_, err := instanceAction(instance.Id, core.InstanceActionActionStart)
+			_, err := instanceAction(account, instance.Id, core.InstanceActionActionStart)
 			handleActionError(err, "启动")
 		case "2":
-			_, err := instanceAction(instance.Id, core.InstanceActionActionSoftstop)
+			_, err := instanceAction(account, instance.Id, core.InstanceActionActionSoftstop)
 			handleActionError(err, "停止")
 		case "3":
-			_, err := instanceAction(instance.Id, core.InstanceActionActionSoftreset)
+			_, err := instanceAction(account, instance.Id, core.InstanceActionActionSoftreset)
 			handleActionError(err, "重启")
 		case "4":
 			fmt.Print("确定终止实例？(输入 y 确认): ")
 			if readInput() == "y" {
-				err := terminateInstance(instance.Id)
+				err := terminateInstance(account, instance.Id)
 				if handleActionError(err, "终止") {
 					return
 				}
@@ -286,6 +313,51 @@ func instanceDetails(instanceId *string) {
 			}
 		case "6":
 			showTrafficMenu(instance.Id)
+		case "7":
+			fmt.Print("确定更换公共IP？原IP将被释放 (输入 y 确认): ")
+			if readInput() == "y" {
+				ip, err := rotatePublicIp(account, instance.Id, nil, 1)
+				if handleActionError(err, "更换公共IP") {
+					fmt.Printf("新的公共IP: %s\n", ip)
+				}
+			}
+		case "8":
+			if primaryVnic.Id != nil {
+				manageVnicNsgs(primaryVnic.Id)
+				continue
+			}
+			fmt.Println("未找到主网卡，无法管理网络安全组。")
+		case "9":
+			manageInstanceVnics(instance.Id)
+			continue
+		case "10":
+			showMetricsMenu(instance.Id)
+			continue
+		case "11":
+			fmt.Print("请输入新的实例名称 (直接回车取消): ")
+			name := readInput()
+			if name != "" {
+				_, err := updateInstance(account, instance.Id, &name, nil, nil, nil)
+				handleActionError(err, "修改名称")
+			}
+		case "12":
+			resizeInstanceMenu(instance)
+		case "13":
+			manageAgentPlugins(instance.Id)
+			continue
+		case "14":
+			manageConsoleConnections(instance)
+			continue
+		case "15":
+			fmt.Print("请输入镜像名称 (可选): ")
+			name := readInput()
+			fmt.Print("创建镜像期间实例将处于关机状态, 完成后自动恢复, 确定创建？(输入 y 确认): ")
+			if readInput() == "y" {
+				image, err := createImage(account, instance.Id, name)
+				if handleActionError(err, "创建自定义镜像") {
+					fmt.Printf("镜像名称: %s, 可以在 自定义镜像管理 中查看创建进度。\n", stringValue(image.DisplayName))
+				}
+			}
 		case "b":
 			return
 		default:
@@ -297,10 +369,271 @@ func instanceDetails(instanceId *string) {
 	}
 }
 
+// resizeInstanceMenu 修改 flex 实例的 OCPU 和内存
+func resizeInstanceMenu(instance core.Instance) {
+	if !isResizableInstance(instance) {
+		fmt.Printf("%s 不是 flex 实例，无法修改 OCPU 和内存。\n", stringValue(instance.Shape))
+		return
+	}
+	fmt.Printf("当前配置: OCPU %g, 内存 %g GB\n", *instance.ShapeConfig.Ocpus, *instance.ShapeConfig.MemoryInGBs)
+	fmt.Print("请输入新的 OCPU 个数 (直接回车保持不变): ")
+	ocpus, ok := readOptionalFloat32()
+	if !ok {
+		fmt.Println("输入无效。")
+		return
+	}
+	fmt.Print("请输入新的内存大小(GB) (直接回车保持不变): ")
+	memoryInGBs, ok := readOptionalFloat32()
+	if !ok {
+		fmt.Println("输入无效。")
+		return
+	}
+	if instance.LifecycleState == core.InstanceLifecycleStateRunning {
+		fmt.Print("运行中的实例修改配置时会自动重启，确定修改？(输入 y 确认): ")
+	} else {
+		fmt.Print("确定修改？(输入 y 确认): ")
+	}
+	if readInput() != "y" {
+		return
+	}
+	_, err := resizeInstance(account, instance, ocpus, memoryInGBs)
+	handleActionError(err, "修改配置")
+}
+
+// readOptionalFloat32 读取一个正数, 直接回车时返回 0
+func readOptionalFloat32() (float32, bool) {
+	input := readInput()
+	if input == "" {
+		return 0, true
+	}
+	v, err := strconv.ParseFloat(input, 32)
+	if err != nil || v <= 0 {
+		return 0, false
+	}
+	return float32(v), true
+}
+
+// manageAgentPlugins 查看和修改实例的 Oracle Cloud Agent 配置。监控插件关闭后无法查看流量和监控指标
+func manageAgentPlugins(instanceId *string) {
+	for {
+		printMenuTitle("管理 Oracle Cloud Agent 插件")
+		instance, err := getInstance(account, instanceId)
+		if err != nil {
+			printlnErr("获取实例详细信息失败", err.Error())
+			promptToContinue()
+			return
+		}
+		agentConfig := instance.AgentConfig
+		if agentConfig == nil {
+			agentConfig = &core.InstanceAgentConfig{}
+		}
+		enabled := func(disabled *bool) string {
+			if disabled != nil && *disabled {
+				return "已禁用"
+			}
+			return "已启用"
+		}
+		fmt.Printf("%-12s: %s\n", "监控插件", enabled(agentConfig.IsMonitoringDisabled))
+		fmt.Printf("%-12s: %s\n", "管理插件", enabled(agentConfig.IsManagementDisabled))
+		fmt.Printf("%-12s: %s\n", "全部插件", enabled(agentConfig.AreAllPluginsDisabled))
+		fmt.Println()
+
+		// 实例已配置的插件在前, 其余常用插件按默认状态显示
+		plugins := append([]core.InstanceAgentPluginConfigDetails(nil), agentConfig.PluginsConfig...)
+		for _, name := range instanceAgentPlugins {
+			found := false
+			for _, p := range plugins {
+				if strings.EqualFold(stringValue(p.Name), name) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				name := name
+				plugins = append(plugins, core.InstanceAgentPluginConfigDetails{Name: &name})
+			}
+		}
+		w := new(tabwriter.Writer)
+		w.Init(os.Stdout, 0, 8, 2, '\t', 0)
+		fmt.Fprintln(w, "序号\t插件\t配置")
+		fmt.Fprintln(w, "--\t--\t--")
+		for i, p := range plugins {
+			state := "默认"
+			switch p.DesiredState {
+			case core.InstanceAgentPluginConfigDetailsDesiredStateEnabled:
+				state = "启用"
+			case core.InstanceAgentPluginConfigDetailsDesiredStateDisabled:
+				state = "禁用"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", i+1, stringValue(p.Name), state)
+		}
+		w.Flush()
+		fmt.Println("\n配置为 \"默认\" 的插件按甲骨文的默认状态运行, 部分插件 (如 Bastion、Block Volume Management) 默认不启用")
+		fmt.Println("输入序号启用/禁用插件, m: 启用/禁用监控插件, g: 启用/禁用管理插件, a: 启用/禁用全部插件")
+		fmt.Print("请输入操作 (或 'b' 返回): ")
+
+		update := core.UpdateInstanceAgentConfigDetails{PluginsConfig: agentConfig.PluginsConfig}
+		input := readInput()
+		if input == "b" {
+			return
+		}
+		var plugin core.InstanceAgentPluginConfigDetails
+		switch input {
+		case "m", "g", "a":
+		default:
+			index, err := strconv.Atoi(input)
+			if err != nil || index <= 0 || index > len(plugins) {
+				fmt.Println("\033[1;31m输入无效。\033[0m")
+				promptToContinue()
+				continue
+			}
+			plugin = plugins[index-1]
+		}
+		// 明确选择启用或禁用, 不根据当前配置切换 ("默认" 配置的插件实际可能启用也可能未启用)
+		fmt.Print("1. 启用  2. 禁用\n请选择 (直接回车取消): ")
+		var disable bool
+		switch readInput() {
+		case "1":
+		case "2":
+			disable = true
+		default:
+			continue
+		}
+		switch input {
+		case "m":
+			update.IsMonitoringDisabled = &disable
+		case "g":
+			update.IsManagementDisabled = &disable
+		case "a":
+			update.AreAllPluginsDisabled = &disable
+		default:
+			plugin.DesiredState = core.InstanceAgentPluginConfigDetailsDesiredStateEnabled
+			if disable {
+				plugin.DesiredState = core.InstanceAgentPluginConfigDetailsDesiredStateDisabled
+			}
+			// 只提交已配置过的插件和本次修改的插件, 其余插件保持默认状态
+			update.PluginsConfig = nil
+			for _, p := range agentConfig.PluginsConfig {
+				if !strings.EqualFold(stringValue(p.Name), stringValue(plugin.Name)) {
+					update.PluginsConfig = append(update.PluginsConfig, p)
+				}
+			}
+			update.PluginsConfig = append(update.PluginsConfig, plugin)
+		}
+		_, err = updateInstance(account, instanceId, nil, nil, nil, &update)
+		handleActionError(err, "修改插件配置")
+		promptToContinue()
+	}
+}
+
+// manageConsoleConnections 管理实例的控制台连接, 实例无法通过 SSH 访问时可以通过串口控制台或 VNC 登录排查
+func manageConsoleConnections(instance core.Instance) {
+	for {
+		printMenuTitle("控制台连接与控制台历史")
+		connections, err := listConsoleConnections(account, instance.Id)
+		if err != nil {
+			printlnErr("获取控制台连接失败", err.Error())
+			promptToContinue()
+			return
+		}
+		if len(connections) == 0 {
+			fmt.Println("实例没有控制台连接。")
+		} else {
+			w := new(tabwriter.Writer)
+			w.Init(os.Stdout, 0, 8, 2, '\t', 0)
+			fmt.Fprintln(w, "序号\t状态\t公钥指纹")
+			fmt.Fprintln(w, "--\t--\t--")
+			for i, c := range connections {
+				fmt.Fprintf(w, "%d\t%s\t%s\n", i+1, c.LifecycleState, stringValue(c.Fingerprint))
+			}
+			w.Flush()
+		}
+
+		fmt.Print("\n输入序号查看连接命令, 'a' 创建控制台连接, 'd' 删除控制台连接, 'h' 截取控制台历史, 或 'b' 返回: ")
+		input := readInput()
+		switch {
+		case strings.EqualFold(input, "b"):
+			return
+		case strings.EqualFold(input, "a"):
+			publicKey, ok := readConsolePublicKey()
+			if ok {
+				fmt.Println("正在创建控制台连接...")
+				connection, err := createConsoleConnection(account, instance.Id, publicKey)
+				if handleActionError(err, "创建控制台连接") {
+					printConsoleConnectionCommands(connection)
+				}
+			}
+		case strings.EqualFold(input, "d"):
+			fmt.Print("请输入要删除的控制台连接的序号: ")
+			index, err := strconv.Atoi(readInput())
+			if err != nil || index < 1 || index > len(connections) {
+				fmt.Println("输入无效。")
+				break
+			}
+			err = deleteConsoleConnection(account, connections[index-1].Id)
+			handleActionError(err, "删除控制台连接")
+		case strings.EqualFold(input, "h"):
+			file := consoleHistoryFileName(instance)
+			fmt.Printf("请输入保存的文件 (直接回车使用 %s): ", file)
+			if input := readInput(); input != "" {
+				file = input
+			}
+			fmt.Println("正在截取控制台历史...")
+			n, err := saveConsoleHistory(account, instance.Id, file)
+			if handleActionError(err, "截取控制台历史") {
+				fmt.Printf("控制台历史已保存到 %s (%d 字节)\n", file, n)
+			}
+		default:
+			index, err := strconv.Atoi(input)
+			if err != nil || index < 1 || index > len(connections) {
+				fmt.Println("\033[1;31m输入无效!\033[0m")
+				time.Sleep(1 * time.Second)
+				continue
+			}
+			printConsoleConnectionCommands(connections[index-1])
+		}
+		promptToContinue()
+	}
+}
+
+// readConsolePublicKey 选择实例模板中的 SSH 公钥或手动输入公钥, 返回 false 表示取消
+func readConsolePublicKey() (string, bool) {
+	keys := templatePublicKeys(oracleSection)
+	for i, k := range keys {
+		fmt.Printf("%d. 使用模板 %s 中的公钥 (%s)\n", i+1, k.Template, truncateKey(k.Key))
+	}
+	fmt.Print("请输入公钥序号, 或直接粘贴 SSH 公钥 (直接回车取消): ")
+	input := readInput()
+	if input == "" {
+		return "", false
+	}
+	if index, err := strconv.Atoi(input); err == nil {
+		if index < 1 || index > len(keys) {
+			fmt.Println("输入无效。")
+			return "", false
+		}
+		return keys[index-1].Key, true
+	}
+	return input, true
+}
+
+// truncateKey 只显示公钥的类型和末尾部分
+func truncateKey(key string) string {
+	fields := strings.Fields(key)
+	if len(fields) < 2 {
+		return key
+	}
+	body := fields[1]
+	if len(body) > 16 {
+		body = "..." + body[len(body)-16:]
+	}
+	return strings.Join(append([]string{fields[0], body}, fields[2:]...), " ")
+}
+
 func manageIPv6(vnic *core.Vnic) {
 	printMenuTitle("管理 IPv6 地址")
 
-	ipv6s, err := ListIpv6s(vnic.Id)
+	ipv6s, err := ListIpv6s(account, vnic.Id)
 	if err != nil {
 		printlnErr("获取IPv6地址失败", err.Error())
 		promptToContinue()
@@ -315,7 +648,7 @@ func manageIPv6(vnic *core.Vnic) {
 		fmt.Print("\n是否要删除现有IPv6并添加一个新的 (替换)？(y/n): ")
 		if readInput() == "y" {
 			fmt.Println("正在删除旧的 IPv6 地址...")
-			err := DeleteIpv6(ipv6s[0].Id)
+			err := DeleteIpv6(account, ipv6s[0].Id)
 			if err != nil {
 				printlnErr("删除失败", err.Error())
 				promptToContinue()
@@ -328,7 +661,26 @@ func manageIPv6(vnic *core.Vnic) {
 		}
 	}
 
-	newIpv6, err := AddIpv6(vnic.Id)
+	subnet, err := GetSubnet(account, vnic.SubnetId)
+	if err != nil {
+		printlnErr("获取子网失败", err.Error())
+		promptToContinue()
+		return
+	}
+	if len(subnetIpv6Cidrs(subnet)) == 0 {
+		fmt.Print("实例所在的子网未启用 IPv6, 是否先为子网启用 IPv6？(y/n): ")
+		if readInput() != "y" {
+			return
+		}
+		_, err = enableIpv6(account, subnet)
+		if err != nil {
+			printlnErr("启用 IPv6 失败", err.Error())
+			promptToContinue()
+			return
+		}
+	}
+
+	newIpv6, err := AddIpv6(account, vnic.Id)
 	if err != nil {
 		printlnErr("添加IPv6地址失败", err.Error())
 	} else {
@@ -342,6 +694,7 @@ func showTrafficMenu(instanceId *string) {
 		printMenuTitle("查看实例流量")
 		fmt.Println("1. 最近 24 小时")
 		fmt.Println("2. 最近 7 天")
+		fmt.Println("3. 本月 (UTC)")
 		fmt.Println("\nb. 返回")
 		fmt.Print("\n请选择时间范围: ")
 
@@ -355,6 +708,8 @@ func showTrafficMenu(instanceId *string) {
 			startTime = now.Add(-24 * time.Hour)
 		case "2":
 			startTime = now.Add(-7 * 24 * time.Hour)
+		case "3":
+			startTime = monthStart(now)
 		case "b":
 			return
 		default:
@@ -364,7 +719,7 @@ func showTrafficMenu(instanceId *string) {
 		}
 
 		fmt.Println("正在查询监控数据，请稍候...")
-		bytesIn, bytesOut, err := GetInstanceNetworkMetrics(*instanceId, startTime.Format(time.RFC3339), endTime.Format(time.RFC3339))
+		bytesIn, bytesOut, err := GetInstanceNetworkMetrics(account, *instanceId, startTime.Format(time.RFC3339), endTime.Format(time.RFC3339))
 		if err != nil {
 			printlnErr("查询流量数据失败", err.Error())
 			promptToContinue()
@@ -378,13 +733,60 @@ func showTrafficMenu(instanceId *string) {
 	}
 }
 
+func showMetricsMenu(instanceId *string) {
+	for {
+		printMenuTitle("查看监控指标")
+		for i, r := range metricRanges {
+			fmt.Printf("%d. %s\n", i+1, r.Label)
+		}
+		fmt.Println("\nb. 返回")
+		fmt.Print("\n请选择时间范围: ")
+
+		input := readInput()
+		if input == "b" {
+			return
+		}
+		index, err := strconv.Atoi(input)
+		if err != nil || index < 1 || index > len(metricRanges) {
+			fmt.Println("\033[1;31m输入无效。\033[0m")
+			time.Sleep(1 * time.Second)
+			continue
+		}
+		r := metricRanges[index-1]
+		fmt.Printf("请输入采样间隔 1m/5m/1h/1d (默认 %s): ", r.Resolution)
+		resolution := readInput()
+		if resolution == "" {
+			resolution = r.Resolution
+		}
+		if err := checkMetricResolution(resolution, r.Duration); err != nil {
+			fmt.Printf("\033[1;31m%v\033[0m\n", err)
+			promptToContinue()
+			continue
+		}
+
+		fmt.Println("正在查询监控数据，请稍候...")
+		endTime := time.Now().UTC().Truncate(time.Minute)
+		startTime := endTime.Add(-r.Duration)
+		records, err := getInstanceMetrics(account, *instanceId, instanceMetrics, resolution, startTime, endTime)
+		if err != nil {
+			printlnErr("查询监控数据失败", err.Error())
+			promptToContinue()
+			continue
+		}
+		fmt.Printf("\n%s, 采样间隔 %s\n", r.Label, resolution)
+		printMetricRecords(records, startTime, endTime)
+		fmt.Println("\n没有数据时, 请确认实例已启用 Compute Instance Monitoring 插件。")
+		promptToContinue()
+	}
+}
+
 // --- 管理员 (IAM) 管理 ---
 
 func listAdmins() {
 	for {
 		printMenuTitle("管理员列表")
 		fmt.Println("正在获取管理员列表...")
-		users, err := ListUsers()
+		users, err := ListUsers(account)
 		if err != nil {
 			printlnErr("获取用户列表失败", err.Error())
 			promptToContinue()
@@ -467,7 +869,7 @@ func adminDetails(user *identity.User) {
 			fmt.Println("\033[1;31m输入无效。\033[0m")
 			time.Sleep(1 * time.Second)
 		}
-		refreshedUser, err := GetUser(user.Id)
+		refreshedUser, err := GetUser(account, user.Id)
 		if err == nil {
 			user = &refreshedUser
 		} else {
@@ -493,7 +895,7 @@ func createAdmin() {
 	}
 
 	fmt.Println("正在创建用户...")
-	user, err := CreateUser(name, description, email)
+	user, err := CreateUser(account, name, description, email)
 	if err != nil {
 		printlnErr("创建用户失败", err.Error())
 		promptToContinue()
@@ -502,7 +904,7 @@ func createAdmin() {
 	fmt.Printf("用户 '%s' 创建成功！\n", *user.Name)
 
 	fmt.Println("正在将用户添加到 'Administrators' 组...")
-	err = AddUserToAdminGroup(user.Id)
+	err = AddUserToAdminGroup(account, user.Id)
 	if err != nil {
 		printlnErr("添加至管理员组失败", err.Error())
 		fmt.Println("请注意：用户已创建，但需要手动将其加入 'Administrators' 组以获取完整权限。")
@@ -535,7 +937,7 @@ func updateAdminInfo(user *identity.User) {
 		newEmail = currentEmail
 	}
 
-	_, err := UpdateUser(user.Id, &newDesc, &newEmail)
+	_, err := UpdateUser(account, user.Id, &newDesc, &newEmail)
 	if err != nil {
 		printlnErr("更新信息失败", err.Error())
 	} else {
@@ -552,7 +954,7 @@ func resetAdminMFA(user *identity.User) {
 		promptToContinue()
 		return
 	}
-	err := ResetMFA(user.Id)
+	err := ResetMFA(account, user.Id)
 	if err != nil {
 		printlnErr("重置MFA失败", err.Error())
 	} else {
@@ -570,7 +972,7 @@ func deleteAdmin(user *identity.User) bool {
 		return false
 	}
 
-	err := DeleteUser(user.Id)
+	err := DeleteUser(account, user.Id)
 	if err != nil {
 		printlnErr("删除用户失败", err.Error())
 		promptToContinue()
@@ -585,9 +987,37 @@ func deleteAdmin(user *identity.User) bool {
 // --- 网络管理 ---
 
 func showNetworkMenu() {
-	printMenuTitle("网络管理")
+	for {
+		printMenuTitle("网络管理")
+		fmt.Println("1. 虚拟云网络 (VCN) 与防火墙")
+		fmt.Println("2. 预留公共IP")
+		fmt.Println("3. 网络安全组 (NSG)")
+		fmt.Println("4. 为子网启用 IPv6")
+		fmt.Println("\nb. 返回主菜单")
+		fmt.Print("\n请输入操作序号: ")
+
+		switch readInput() {
+		case "1":
+			listVcnsMenu()
+		case "2":
+			listReservedPublicIpsMenu()
+		case "3":
+			listNsgsMenu()
+		case "4":
+			enableSubnetIpv6Menu()
+		case "b":
+			return
+		default:
+			fmt.Println("\033[1;31m输入无效!\033[0m")
+			time.Sleep(1 * time.Second)
+		}
+	}
+}
+
+func listVcnsMenu() {
+	printMenuTitle("虚拟云网络 (VCN)")
 	fmt.Println("正在获取VCN列表...")
-	vcns, err := listVcns(ctx, networkClient)
+	vcns, err := listVcns(account)
 	if err != nil {
 		printlnErr("获取VCN列表失败", err.Error())
 		promptToContinue()
@@ -603,7 +1033,7 @@ func showNetworkMenu() {
 	}
 	w.Flush()
 
-	fmt.Print("\n输入VCN序号可查看其防火墙规则 (或 'b' 返回): ")
+	fmt.Print("\n输入VCN序号可管理其防火墙规则 (或 'b' 返回): ")
 	input := readInput()
 	if strings.EqualFold(input, "b") {
 		return
@@ -621,116 +1051,950 @@ func showNetworkMenu() {
 		showSecurityListDetails(vcn.DefaultSecurityListId)
 	} else {
 		fmt.Println("该VCN没有默认安全列表。")
+		promptToContinue()
 	}
-	promptToContinue()
 }
 
-func showSecurityListDetails(securityListId *string) {
-	sl, err := GetSecurityList(securityListId)
+// enableSubnetIpv6Menu 选择子网并启用 IPv6
+func enableSubnetIpv6Menu() {
+	printMenuTitle("为子网启用 IPv6")
+	fmt.Println("正在获取子网列表...")
+	vcns, err := listVcns(account)
 	if err != nil {
-		printlnErr("获取安全列表失败", err.Error())
+		printlnErr("获取VCN列表失败", err.Error())
+		promptToContinue()
 		return
 	}
-
-	printMenuTitle(fmt.Sprintf("防火墙规则 for %s", *sl.DisplayName))
-	fmt.Println("--- 入站规则 (Ingress) ---")
-	for _, rule := range sl.IngressSecurityRules {
-		desc := ""
-		if rule.Description != nil {
-			desc = *rule.Description
-		}
-		fmt.Printf("源: %-18s 协议: %-10s 描述: %s\n", *rule.Source, *rule.Protocol, desc)
-	}
-
-	fmt.Println("\n--- 出站规则 (Egress) ---")
-	for _, rule := range sl.EgressSecurityRules {
-		desc := ""
-		if rule.Description != nil {
-			desc = *rule.Description
+	var subnets []core.Subnet
+	vcnNames := map[string]string{}
+	for _, vcn := range vcns {
+		vcnNames[*vcn.Id] = *vcn.DisplayName
+		ss, err := listSubnets(account, vcn.Id)
+		if err != nil {
+			printlnErr("获取子网失败", err.Error())
+			promptToContinue()
+			return
 		}
-		fmt.Printf("目标: %-18s 协议: %-10s 描述: %s\n", *rule.Destination, *rule.Protocol, desc)
+		subnets = append(subnets, ss...)
 	}
-}
-
-// --- 引导卷管理 ---
-
-func listBootVolumes() {
-	printMenuTitle("引导卷管理")
-	var bootVolumes []core.BootVolume
-	var wg sync.WaitGroup
-	availabilityDomains, err := ListAvailabilityDomains()
-	if err != nil {
-		printlnErr("获取可用域失败", err.Error())
+	if len(subnets) == 0 {
+		fmt.Println("此账户下没有子网。")
 		promptToContinue()
 		return
 	}
-	for _, ad := range availabilityDomains {
-		wg.Add(1)
-		go func(adName *string) {
-			defer wg.Done()
-			volumes, err := getBootVolumes(adName)
-			if err != nil {
-				printlnErr("获取引导卷失败", err.Error())
-			} else {
-				bootVolumes = append(bootVolumes, volumes...)
-			}
-		}(ad.Name)
-	}
-	wg.Wait()
 
 	w := new(tabwriter.Writer)
 	w.Init(os.Stdout, 0, 8, 2, '\t', 0)
-	fmt.Fprintln(w, "序号\t名称\t状态\t大小(GB)")
-	fmt.Fprintln(w, "--\t--\t--\t---")
-	for i, volume := range bootVolumes {
-		fmt.Fprintf(w, "%d\t%s\t%s\t%d\n", i+1, *volume.DisplayName, getBootVolumeState(volume.LifecycleState), *volume.SizeInGBs)
+	fmt.Fprintln(w, "序号\t子网\tVCN\tIPv4\tIPv6")
+	fmt.Fprintln(w, "--\t--\t--\t--\t--")
+	for i, subnet := range subnets {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", i+1, *subnet.DisplayName, vcnNames[*subnet.VcnId], stringValue(subnet.CidrBlock), strings.Join(subnetIpv6Cidrs(subnet), ", "))
 	}
 	w.Flush()
-	fmt.Println()
 
-	fmt.Print("输入序号查看详情 (或 'b' 返回): ")
+	fmt.Print("\n请输入要启用 IPv6 的子网序号 (或 'b' 返回): ")
 	input := readInput()
 	if strings.EqualFold(input, "b") {
 		return
 	}
-
 	index, err := strconv.Atoi(input)
-	if err == nil && 0 < index && index <= len(bootVolumes) {
-		bootvolumeDetails(bootVolumes[index-1].Id)
-	} else {
+	if err != nil || index < 1 || index > len(subnets) {
 		fmt.Println("\033[1;31m输入无效。\033[0m")
-		time.Sleep(1 * time.Second)
+		promptToContinue()
+		return
+	}
+	fmt.Println("将为VCN分配甲骨文的 IPv6 前缀, 为子网分配 /64, 添加 ::/0 路由规则, 并在安全列表中添加 IPv6 规则。")
+	fmt.Print("确定启用？(输入 y 确认): ")
+	if readInput() == "y" {
+		subnet, err := enableIpv6(account, subnets[index-1])
+		if handleActionError(err, "启用 IPv6") {
+			fmt.Printf("子网 IPv6 前缀: %s\n", strings.Join(subnetIpv6Cidrs(subnet), ", "))
+		}
 	}
+	promptToContinue()
 }
 
-func bootvolumeDetails(bootVolumeId *string) {
+// --- 辅助网卡与辅助私有IP ---
+
+// manageInstanceVnics 列出实例的所有网卡, 添加或删除辅助网卡
+func manageInstanceVnics(instanceId *string) {
 	for {
-		printMenuTitle("引导卷详细信息")
-		bootVolume, err := getBootVolume(bootVolumeId)
+		printMenuTitle("管理网卡和私有IP")
+		fmt.Println("正在获取网卡...")
+		attachments, _, err := ListVnicAttachments(account, instanceId, nil)
 		if err != nil {
-			printlnErr("获取引导卷详细信息失败", err.Error())
+			printlnErr("获取网卡失败", err.Error())
 			promptToContinue()
 			return
 		}
-
-		attachments, _ := listBootVolumeAttachments(bootVolume.AvailabilityDomain, bootVolume.CompartmentId, bootVolume.Id)
-		var attachIns []string
+		var vnicAttachments []core.VnicAttachment
+		var vnics []core.Vnic
 		for _, attachment := range attachments {
-			ins, err := getInstance(attachment.InstanceId)
-			if err == nil {
-				attachIns = append(attachIns, *ins.DisplayName)
+			if attachment.LifecycleState != core.VnicAttachmentLifecycleStateAttached {
+				continue
+			}
+			vnic, err := GetVnic(account, attachment.VnicId)
+			if err != nil {
+				printlnErr("获取网卡失败", err.Error())
+				continue
 			}
+			vnicAttachments = append(vnicAttachments, attachment)
+			vnics = append(vnics, vnic)
 		}
 
-		var performance string
-		if bootVolume.VpusPerGB != nil {
-			switch *bootVolume.VpusPerGB {
-			case 10:
-				performance = fmt.Sprintf("均衡 (VPU:%d)", *bootVolume.VpusPerGB)
-			case 20:
-				performance = fmt.Sprintf("性能较高 (VPU:%d)", *bootVolume.VpusPerGB)
-			default:
-				performance = fmt.Sprintf("UHP (VPU:%d)", *bootVolume.VpusPerGB)
+		w := new(tabwriter.Writer)
+		w.Init(os.Stdout, 0, 8, 2, '\t', 0)
+		fmt.Fprintln(w, "序号\t名称\t类型\t私有IP\t公共IP")
+		fmt.Fprintln(w, "--\t--\t--\t--\t--")
+		var primaryVnic *core.Vnic
+		for i, vnic := range vnics {
+			kind := "辅助网卡"
+			if vnic.IsPrimary != nil && *vnic.IsPrimary {
+				kind = "主网卡"
+				primaryVnic = &vnics[i]
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", i+1, stringValue(vnic.DisplayName), kind, stringValue(vnic.PrivateIp), stringValue(vnic.PublicIp))
+		}
+		w.Flush()
+
+		fmt.Print("\n输入序号管理网卡的私有IP, 'a' 添加辅助网卡, 或 'b' 返回: ")
+		input := readInput()
+		switch {
+		case strings.EqualFold(input, "b"):
+			return
+		case strings.EqualFold(input, "a"):
+			if primaryVnic == nil {
+				fmt.Println("未找到主网卡，无法确定子网。")
+				promptToContinue()
+				continue
+			}
+			addSecondaryVnic(instanceId, primaryVnic.SubnetId)
+			promptToContinue()
+		default:
+			index, err := strconv.Atoi(input)
+			if err == nil && 0 < index && index <= len(vnics) {
+				manageVnicPrivateIps(vnics[index-1], vnicAttachments[index-1])
+			} else {
+				fmt.Println("\033[1;31m输入无效!\033[0m")
+				time.Sleep(1 * time.Second)
+			}
+		}
+	}
+}
+
+// readPublicIpChoice 选择为新的私有IP分配的公共IP: 不分配、临时公共IP 或未绑定的预留公共IP
+func readPublicIpChoice() (assign bool, reservedId *string, ok bool) {
+	fmt.Print("公共IP: 1. 临时公共IP  2. 预留公共IP  3. 不分配 (默认 1): ")
+	switch readInput() {
+	case "", "1":
+		return true, nil, true
+	case "3":
+		return false, nil, true
+	case "2":
+	default:
+		fmt.Println("\033[1;31m输入无效。\033[0m")
+		return false, nil, false
+	}
+
+	publicIps, err := listReservedPublicIps(account)
+	if err != nil {
+		printlnErr("获取预留公共IP失败", err.Error())
+		return false, nil, false
+	}
+	var available []core.PublicIp
+	for _, p := range publicIps {
+		if p.LifecycleState == core.PublicIpLifecycleStateAvailable {
+			available = append(available, p)
+		}
+	}
+	if len(available) == 0 {
+		fmt.Println("没有未绑定的预留公共IP, 请先在网络管理中创建。")
+		return false, nil, false
+	}
+	for i, p := range available {
+		fmt.Printf("%d. %s %s\n", i+1, stringValue(p.IpAddress), stringValue(p.DisplayName))
+	}
+	fmt.Print("请输入预留公共IP序号: ")
+	index, err := strconv.Atoi(readInput())
+	if err != nil || index < 1 || index > len(available) {
+		fmt.Println("\033[1;31m输入无效。\033[0m")
+		return false, nil, false
+	}
+	return true, available[index-1].Id, true
+}
+
+// addSecondaryVnic 在主网卡所在的子网中为实例添加辅助网卡
+func addSecondaryVnic(instanceId, subnetId *string) {
+	fmt.Print("请输入网卡名称 (可选): ")
+	name := readInput()
+	assign, reservedId, ok := readPublicIpChoice()
+	if !ok {
+		return
+	}
+	fmt.Println("正在添加辅助网卡...")
+	attachment, err := attachVnic(account, instanceId, subnetId, name, assign && reservedId == nil)
+	if err != nil {
+		printlnErr("添加辅助网卡失败", err.Error())
+		return
+	}
+	vnic, err := GetVnic(account, attachment.VnicId)
+	if err != nil {
+		printlnErr("获取网卡失败", err.Error())
+		return
+	}
+	if reservedId != nil {
+		privateIps, err := getPrivateIps(account, vnic.Id)
+		if err != nil {
+			printlnErr("获取私有IP失败", err.Error())
+			return
+		}
+		publicIp, err := updatePublicIpAssignment(account, reservedId, *privateIps[0].Id)
+		if err != nil {
+			printlnErr("绑定预留公共IP失败", err.Error())
+			return
+		}
+		vnic.PublicIp = publicIp.IpAddress
+	}
+	fmt.Printf("辅助网卡添加成功, 私有IP: %s, 公共IP: %s\n", stringValue(vnic.PrivateIp), stringValue(vnic.PublicIp))
+	fmt.Println("提示: 辅助网卡需要在实例的操作系统中配置后才能使用, 请参考甲骨文文档中的 secondary_vnic_all_configure.sh。")
+}
+
+// manageVnicPrivateIps 列出网卡的所有私有IP, 添加或删除辅助私有IP, 删除辅助网卡
+func manageVnicPrivateIps(vnic core.Vnic, attachment core.VnicAttachment) {
+	isPrimary := vnic.IsPrimary != nil && *vnic.IsPrimary
+	for {
+		printMenuTitle(fmt.Sprintf("网卡 %s 的私有IP", stringValue(vnic.DisplayName)))
+		privateIps, err := getPrivateIps(account, vnic.Id)
+		if err != nil {
+			printlnErr("获取私有IP失败", err.Error())
+			promptToContinue()
+			return
+		}
+
+		w := new(tabwriter.Writer)
+		w.Init(os.Stdout, 0, 8, 2, '\t', 0)
+		fmt.Fprintln(w, "序号\t私有IP\t类型\t公共IP")
+		fmt.Fprintln(w, "--\t--\t--\t--")
+		for i, p := range privateIps {
+			kind := "辅助私有IP"
+			if p.IsPrimary != nil && *p.IsPrimary {
+				kind = "主私有IP"
+			}
+			var publicIp string
+			if pub, err := getAssignedPublicIp(account, p.Id); err == nil && pub.IpAddress != nil {
+				publicIp = *pub.IpAddress
+				if pub.Lifetime == core.PublicIpLifetimeReserved {
+					publicIp += " (预留)"
+				}
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", i+1, stringValue(p.IpAddress), kind, publicIp)
+		}
+		w.Flush()
+
+		prompt := "\n输入序号删除辅助私有IP, 'a' 添加辅助私有IP"
+		if !isPrimary {
+			prompt += ", 'd' 删除此辅助网卡"
+		}
+		fmt.Print(prompt + ", 或 'b' 返回: ")
+		input := readInput()
+		switch {
+		case strings.EqualFold(input, "b"):
+			return
+		case strings.EqualFold(input, "a"):
+			assign, reservedId, ok := readPublicIpChoice()
+			if !ok {
+				promptToContinue()
+				continue
+			}
+			privateIp, err := createPrivateIp(account, vnic.Id, "")
+			if err != nil {
+				printlnErr("添加辅助私有IP失败", err.Error())
+				promptToContinue()
+				continue
+			}
+			fmt.Printf("辅助私有IP添加成功: %s\n", stringValue(privateIp.IpAddress))
+			if assign {
+				publicIp, err := assignPublicIpToPrivateIp(account, privateIp.Id, reservedId)
+				if handleActionError(err, "分配公共IP") {
+					fmt.Printf("公共IP: %s\n", stringValue(publicIp.IpAddress))
+				}
+			}
+			promptToContinue()
+		case strings.EqualFold(input, "d") && !isPrimary:
+			fmt.Print("确定删除此辅助网卡？网卡上的临时公共IP将被释放 (输入 y 确认): ")
+			if readInput() == "y" {
+				err := detachVnic(account, attachment.Id)
+				if handleActionError(err, "删除辅助网卡") {
+					promptToContinue()
+					return
+				}
+			}
+			promptToContinue()
+		default:
+			index, err := strconv.Atoi(input)
+			if err != nil || index < 1 || index > len(privateIps) {
+				fmt.Println("\033[1;31m输入无效!\033[0m")
+				time.Sleep(1 * time.Second)
+				continue
+			}
+			privateIp := privateIps[index-1]
+			if privateIp.IsPrimary != nil && *privateIp.IsPrimary {
+				fmt.Println("主私有IP无法删除。")
+				promptToContinue()
+				continue
+			}
+			fmt.Print("确定删除此辅助私有IP？绑定的临时公共IP将被释放, 预留公共IP会自动解绑 (输入 y 确认): ")
+			if readInput() == "y" {
+				err := deletePrivateIp(account, privateIp.Id)
+				handleActionError(err, "删除辅助私有IP")
+			}
+			promptToContinue()
+		}
+	}
+}
+
+// --- 网络安全组 ---
+
+func listNsgsMenu() {
+	for {
+		printMenuTitle("网络安全组 (NSG)")
+		fmt.Println("正在获取网络安全组...")
+		vcns, err := listVcns(account)
+		if err != nil {
+			printlnErr("获取VCN列表失败", err.Error())
+			promptToContinue()
+			return
+		}
+		vcnNames := map[string]string{}
+		for _, vcn := range vcns {
+			vcnNames[*vcn.Id] = *vcn.DisplayName
+		}
+		nsgs, err := listNetworkSecurityGroups(account, nil)
+		if err != nil {
+			printlnErr("获取网络安全组失败", err.Error())
+			promptToContinue()
+			return
+		}
+
+		if len(nsgs) == 0 {
+			fmt.Println("此账户下没有网络安全组。")
+		} else {
+			w := new(tabwriter.Writer)
+			w.Init(os.Stdout, 0, 8, 2, '\t', 0)
+			fmt.Fprintln(w, "序号\t名称\tVCN\t状态")
+			fmt.Fprintln(w, "--\t--\t--\t--")
+			for i, nsg := range nsgs {
+				fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", i+1, *nsg.DisplayName, vcnNames[*nsg.VcnId], nsg.LifecycleState)
+			}
+			w.Flush()
+		}
+
+		fmt.Print("\n输入序号管理网络安全组, 'n' 新建, 或 'b' 返回: ")
+		input := readInput()
+		switch {
+		case strings.EqualFold(input, "b"):
+			return
+		case strings.EqualFold(input, "n"):
+			createNsg(vcns)
+			promptToContinue()
+		default:
+			index, err := strconv.Atoi(input)
+			if err == nil && 0 < index && index <= len(nsgs) {
+				nsgDetails(nsgs[index-1])
+			} else {
+				fmt.Println("\033[1;31m输入无效!\033[0m")
+				time.Sleep(1 * time.Second)
+			}
+		}
+	}
+}
+
+func createNsg(vcns []core.Vcn) {
+	if len(vcns) == 0 {
+		fmt.Println("此账户下没有VCN。")
+		return
+	}
+	for i, vcn := range vcns {
+		fmt.Printf("%d. %s (%s)\n", i+1, *vcn.DisplayName, stringValue(vcn.CidrBlock))
+	}
+	fmt.Print("请选择网络安全组所在的VCN: ")
+	index, err := strconv.Atoi(readInput())
+	if err != nil || index < 1 || index > len(vcns) {
+		fmt.Println("\033[1;31m输入无效。\033[0m")
+		return
+	}
+	fmt.Print("请输入网络安全组名称: ")
+	name := readInput()
+	if name == "" {
+		fmt.Println("\033[1;31m名称不能为空。\033[0m")
+		return
+	}
+	_, err = createNetworkSecurityGroup(account, vcns[index-1].Id, name)
+	handleActionError(err, "创建网络安全组")
+}
+
+func nsgDetails(nsg core.NetworkSecurityGroup) {
+	for {
+		printMenuTitle(fmt.Sprintf("网络安全组 %s", *nsg.DisplayName))
+		rules, err := listNsgSecurityRules(account, nsg.Id)
+		if err != nil {
+			printlnErr("获取网络安全组规则失败", err.Error())
+			promptToContinue()
+			return
+		}
+		if len(rules) == 0 {
+			fmt.Println("没有规则。")
+		}
+		for i, rule := range rules {
+			fmt.Printf("%2d. %s\n", i+1, formatNsgRule(rule))
+		}
+
+		fmt.Println("\n--- 操作菜单 ---")
+		fmt.Println("1. 添加入站规则   2. 添加出站规则   3. 删除规则")
+		fmt.Println("4. 删除网络安全组")
+		fmt.Println("\nb. 返回")
+		fmt.Print("\n请输入操作序号: ")
+
+		switch input := readInput(); input {
+		case "1", "2":
+			ingress := input == "1"
+			prompt := "目标 CIDR"
+			if ingress {
+				prompt = "源 CIDR"
+			}
+			cidr, opts, ok := readRuleOptions(prompt)
+			if !ok {
+				continue
+			}
+			rule := opts.nsgRule(ingress, cidr)
+			fmt.Printf("\033[1;32m+ %s\033[0m\n", formatAddNsgRule(rule))
+			fmt.Print("确定添加？(输入 y 确认): ")
+			if readInput() == "y" {
+				err := addNsgSecurityRules(account, nsg.Id, []core.AddSecurityRuleDetails{rule})
+				handleActionError(err, "添加规则")
+			}
+		case "3":
+			index := readRuleIndex(len(rules))
+			if index < 0 {
+				continue
+			}
+			fmt.Printf("\033[1;31m- %s\033[0m\n", formatNsgRule(rules[index]))
+			fmt.Print("确定删除？(输入 y 确认): ")
+			if readInput() == "y" {
+				err := removeNsgSecurityRules(account, nsg.Id, []string{*rules[index].Id})
+				handleActionError(err, "删除规则")
+			}
+		case "4":
+			fmt.Print("确定删除网络安全组？需要先解除所有网卡的关联 (输入 y 确认): ")
+			if readInput() == "y" {
+				err := deleteNetworkSecurityGroup(account, nsg.Id)
+				if handleActionError(err, "删除网络安全组") {
+					promptToContinue()
+					return
+				}
+			}
+		case "b":
+			return
+		default:
+			fmt.Println("\033[1;31m输入无效。\033[0m")
+			time.Sleep(1 * time.Second)
+			continue
+		}
+		promptToContinue()
+	}
+}
+
+// manageVnicNsgs 关联或解除关联网卡所在 VCN 中的网络安全组
+func manageVnicNsgs(vnicId *string) {
+	for {
+		printMenuTitle("管理网络安全组")
+		vnic, err := GetVnic(account, vnicId)
+		if err != nil {
+			printlnErr("获取网卡失败", err.Error())
+			promptToContinue()
+			return
+		}
+		subnet, err := GetSubnet(account, vnic.SubnetId)
+		if err != nil {
+			printlnErr("获取子网失败", err.Error())
+			promptToContinue()
+			return
+		}
+		nsgs, err := listNetworkSecurityGroups(account, subnet.VcnId)
+		if err != nil {
+			printlnErr("获取网络安全组失败", err.Error())
+			promptToContinue()
+			return
+		}
+		if len(nsgs) == 0 {
+			fmt.Println("实例所在的VCN中没有网络安全组, 请先在网络管理中创建。")
+			promptToContinue()
+			return
+		}
+
+		w := new(tabwriter.Writer)
+		w.Init(os.Stdout, 0, 8, 2, '\t', 0)
+		fmt.Fprintln(w, "序号\t名称\t已关联")
+		fmt.Fprintln(w, "--\t--\t--")
+		for i, nsg := range nsgs {
+			attached := "否"
+			if containsString(vnic.NsgIds, *nsg.Id) {
+				attached = "是"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", i+1, *nsg.DisplayName, attached)
+		}
+		w.Flush()
+
+		fmt.Print("\n输入序号关联或解除关联网络安全组 (或 'b' 返回): ")
+		input := readInput()
+		if strings.EqualFold(input, "b") {
+			return
+		}
+		index, err := strconv.Atoi(input)
+		if err != nil || index < 1 || index > len(nsgs) {
+			fmt.Println("\033[1;31m输入无效!\033[0m")
+			time.Sleep(1 * time.Second)
+			continue
+		}
+		nsgId := *nsgs[index-1].Id
+		var nsgIds []string
+		action := "关联网络安全组"
+		for _, id := range vnic.NsgIds {
+			if id == nsgId {
+				action = "解除关联网络安全组"
+			} else {
+				nsgIds = append(nsgIds, id)
+			}
+		}
+		if action == "关联网络安全组" {
+			nsgIds = append(nsgIds, nsgId)
+		}
+		_, err = updateVnicNsgs(account, vnic.Id, nsgIds)
+		handleActionError(err, action)
+		promptToContinue()
+	}
+}
+
+// --- 预留公共IP ---
+
+func listReservedPublicIpsMenu() {
+	for {
+		printMenuTitle("预留公共IP")
+		fmt.Println("正在获取预留公共IP...")
+		publicIps, err := listReservedPublicIps(account)
+		if err != nil {
+			printlnErr("获取预留公共IP失败", err.Error())
+			promptToContinue()
+			return
+		}
+
+		if len(publicIps) == 0 {
+			fmt.Println("此账户下没有预留公共IP。")
+		} else {
+			w := new(tabwriter.Writer)
+			w.Init(os.Stdout, 0, 8, 2, '\t', 0)
+			fmt.Fprintln(w, "序号\t名称\tIP\t状态")
+			fmt.Fprintln(w, "--\t--\t--\t--")
+			for i, p := range publicIps {
+				fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", i+1, stringValue(p.DisplayName), stringValue(p.IpAddress), getPublicIpState(p.LifecycleState))
+			}
+			w.Flush()
+		}
+
+		fmt.Print("\n输入序号管理预留公共IP, 'n' 新建, 'c' 将实例的临时公共IP替换为预留公共IP, 或 'b' 返回: ")
+		input := readInput()
+		switch {
+		case strings.EqualFold(input, "b"):
+			return
+		case strings.EqualFold(input, "n"):
+			fmt.Print("请输入名称 (可选): ")
+			name := readInput()
+			publicIp, err := createReservedPublicIp(account, name, nil)
+			if handleActionError(err, "创建预留公共IP") {
+				fmt.Printf("预留公共IP: %s\n", stringValue(publicIp.IpAddress))
+			}
+			promptToContinue()
+		case strings.EqualFold(input, "c"):
+			instance := selectInstance()
+			if instance == nil {
+				continue
+			}
+			fmt.Println("注意: 甲骨文不支持直接转换, 将删除实例的临时公共IP并绑定新的预留公共IP, 公共IP地址会改变。")
+			fmt.Print("确定替换？(输入 y 确认): ")
+			if readInput() == "y" {
+				publicIp, err := convertToReservedPublicIp(account, instance.Id, *instance.DisplayName)
+				if handleActionError(err, "替换为预留公共IP") {
+					fmt.Printf("实例 %s 的预留公共IP: %s\n", *instance.DisplayName, stringValue(publicIp.IpAddress))
+				}
+			}
+			promptToContinue()
+		default:
+			index, err := strconv.Atoi(input)
+			if err == nil && 0 < index && index <= len(publicIps) {
+				reservedPublicIpDetails(publicIps[index-1])
+			} else {
+				fmt.Println("\033[1;31m输入无效!\033[0m")
+				time.Sleep(1 * time.Second)
+			}
+		}
+	}
+}
+
+func reservedPublicIpDetails(publicIp core.PublicIp) {
+	printMenuTitle("预留公共IP详细信息")
+	fmt.Printf("\033[1m%s\033[0m\n", stringValue(publicIp.IpAddress))
+	fmt.Println(strings.Repeat("-", 50))
+	fmt.Printf("%-12s: %s\n", "名称", stringValue(publicIp.DisplayName))
+	fmt.Printf("%-12s: %s\n", "状态", getPublicIpState(publicIp.LifecycleState))
+	fmt.Printf("%-12s: %s\n", "绑定私有IP", stringValue(publicIp.AssignedEntityId))
+	fmt.Printf("%-12s: %s\n", "OCID", stringValue(publicIp.Id))
+	fmt.Println(strings.Repeat("-", 50))
+
+	fmt.Println("\n--- 操作菜单 ---")
+	fmt.Println("1. 绑定到实例   2. 解绑   3. 释放")
+	fmt.Println("\nb. 返回")
+	fmt.Print("\n请输入操作序号: ")
+
+	switch readInput() {
+	case "1":
+		instance := selectInstance()
+		if instance == nil {
+			return
+		}
+		_, err := assignReservedPublicIp(account, publicIp.Id, instance.Id)
+		handleActionError(err, "绑定")
+	case "2":
+		_, err := updatePublicIpAssignment(account, publicIp.Id, "")
+		handleActionError(err, "解绑")
+	case "3":
+		fmt.Print("确定释放预留公共IP？释放后无法找回 (输入 y 确认): ")
+		if readInput() != "y" {
+			return
+		}
+		_, err := deletePublicIp(account, publicIp.Id)
+		handleActionError(err, "释放")
+	case "b":
+		return
+	default:
+		fmt.Println("\033[1;31m输入无效。\033[0m")
+	}
+	promptToContinue()
+}
+
+// selectInstance 列出当前账号未终止的实例供用户选择, 取消或出错时返回 nil
+func selectInstance() *core.Instance {
+	fmt.Println("正在获取实例数据...")
+	instances, err := listAllInstances(account)
+	if err != nil {
+		printlnErr("获取实例失败", err.Error())
+		promptToContinue()
+		return nil
+	}
+	var candidates []core.Instance
+	for _, ins := range instances {
+		if ins.LifecycleState != core.InstanceLifecycleStateTerminating && ins.LifecycleState != core.InstanceLifecycleStateTerminated {
+			candidates = append(candidates, ins)
+		}
+	}
+	if len(candidates) == 0 {
+		fmt.Println("此账户下没有实例。")
+		promptToContinue()
+		return nil
+	}
+
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 8, 2, '\t', 0)
+	fmt.Fprintln(w, "序号\t名称\t状态\t配置")
+	fmt.Fprintln(w, "--\t--\t--\t--")
+	for i, ins := range candidates {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", i+1, *ins.DisplayName, getInstanceState(ins.LifecycleState), *ins.Shape)
+	}
+	w.Flush()
+
+	fmt.Print("\n请输入实例序号 (或 'b' 返回): ")
+	index, err := strconv.Atoi(readInput())
+	if err != nil || index < 1 || index > len(candidates) {
+		return nil
+	}
+	return &candidates[index-1]
+}
+
+func showSecurityListDetails(securityListId *string) {
+	for {
+		sl, etag, err := GetSecurityList(account, securityListId)
+		if err != nil {
+			printlnErr("获取安全列表失败", err.Error())
+			promptToContinue()
+			return
+		}
+
+		printMenuTitle(fmt.Sprintf("防火墙规则 for %s", *sl.DisplayName))
+		fmt.Println("--- 入站规则 (Ingress) ---")
+		for i, rule := range sl.IngressSecurityRules {
+			fmt.Printf("%2d. 源: %s\n", i+1, formatIngressRule(rule))
+		}
+		fmt.Println("\n--- 出站规则 (Egress) ---")
+		for i, rule := range sl.EgressSecurityRules {
+			fmt.Printf("%2d. 目标: %s\n", i+1, formatEgressRule(rule))
+		}
+
+		fmt.Println("\n--- 操作菜单 ---")
+		fmt.Println("1. 添加入站规则   2. 编辑入站规则   3. 删除入站规则")
+		fmt.Println("4. 添加出站规则   5. 编辑出站规则   6. 删除出站规则")
+		fmt.Println("7. 应用预设规则")
+		fmt.Println("\nb. 返回")
+		fmt.Print("\n请输入操作序号: ")
+
+		ingress := append([]core.IngressSecurityRule{}, sl.IngressSecurityRules...)
+		egress := append([]core.EgressSecurityRule{}, sl.EgressSecurityRules...)
+		input := readInput()
+		switch input {
+		case "1", "2":
+			index := -1
+			if input == "2" {
+				if index = readRuleIndex(len(ingress)); index < 0 {
+					continue
+				}
+			}
+			cidr, opts, ok := readRuleOptions("源 CIDR")
+			if !ok {
+				continue
+			}
+			if index < 0 {
+				ingress = append(ingress, opts.ingress(cidr))
+			} else {
+				ingress[index] = opts.ingress(cidr)
+			}
+		case "4", "5":
+			index := -1
+			if input == "5" {
+				if index = readRuleIndex(len(egress)); index < 0 {
+					continue
+				}
+			}
+			cidr, opts, ok := readRuleOptions("目标 CIDR")
+			if !ok {
+				continue
+			}
+			if index < 0 {
+				egress = append(egress, opts.egress(cidr))
+			} else {
+				egress[index] = opts.egress(cidr)
+			}
+		case "3":
+			index := readRuleIndex(len(ingress))
+			if index < 0 {
+				continue
+			}
+			ingress = append(ingress[:index], ingress[index+1:]...)
+		case "6":
+			index := readRuleIndex(len(egress))
+			if index < 0 {
+				continue
+			}
+			egress = append(egress[:index], egress[index+1:]...)
+		case "7":
+			vcn, err := getVcn(account, sl.VcnId)
+			if err != nil {
+				printlnErr("获取 VCN 失败", err.Error())
+				promptToContinue()
+				continue
+			}
+			var ok bool
+			ingress, ok = readSecurityPreset(ingress, vcn.CidrBlocks)
+			if !ok {
+				continue
+			}
+		case "b":
+			return
+		default:
+			fmt.Println("\033[1;31m输入无效。\033[0m")
+			time.Sleep(1 * time.Second)
+			continue
+		}
+		confirmUpdateSecurityList(sl, etag, ingress, egress)
+		promptToContinue()
+	}
+}
+
+// readRuleIndex 读取规则序号, 返回从 0 开始的下标, 输入无效时返回 -1
+func readRuleIndex(count int) int {
+	fmt.Print("请输入规则序号: ")
+	index, err := strconv.Atoi(readInput())
+	if err != nil || index < 1 || index > count {
+		fmt.Println("\033[1;31m输入无效。\033[0m")
+		promptToContinue()
+		return -1
+	}
+	return index - 1
+}
+
+// readRuleOptions 读取规则的 CIDR、协议和端口
+func readRuleOptions(cidrPrompt string) (cidr string, opts ruleOptions, ok bool) {
+	fail := func(err error) (string, ruleOptions, bool) {
+		printlnErr("输入无效", err.Error())
+		promptToContinue()
+		return "", ruleOptions{}, false
+	}
+	fmt.Printf("%s (默认 0.0.0.0/0): ", cidrPrompt)
+	cidr = readInput()
+	if cidr == "" {
+		cidr = "0.0.0.0/0"
+	}
+	cidr, err := checkCIDR(cidr)
+	if err != nil {
+		return fail(err)
+	}
+	fmt.Print("协议 all/tcp/udp/icmp/icmpv6 (默认 tcp): ")
+	protocol := readInput()
+	if protocol == "" {
+		protocol = "tcp"
+	}
+	opts.Protocol, err = parseProtocol(protocol)
+	if err != nil {
+		return fail(err)
+	}
+	switch opts.Protocol {
+	case protocolTCP, protocolUDP:
+		fmt.Print("目标端口, 如 80 或 8000-9000 (直接回车表示所有端口): ")
+		opts.PortMin, opts.PortMax, err = parsePortRange(readInput())
+	case protocolICMP, protocolICMPv6:
+		fmt.Print("ICMP 类型和代码, 如 3 或 3,4 (直接回车表示所有类型): ")
+		opts.IcmpType, opts.IcmpCode, err = parseIcmpType(readInput())
+	}
+	if err != nil {
+		return fail(err)
+	}
+	fmt.Print("描述 (可选): ")
+	opts.Description = readInput()
+	return cidr, opts, true
+}
+
+// readSecurityPreset 选择预设规则并应用到入站规则, vcnCidrs 为安全列表所在 VCN 的 CIDR
+func readSecurityPreset(ingress []core.IngressSecurityRule, vcnCidrs []string) ([]core.IngressSecurityRule, bool) {
+	for i, p := range securityPresets {
+		fmt.Printf("%d. %s\n", i+1, p[1])
+	}
+	fmt.Print("请输入预设规则序号: ")
+	index, err := strconv.Atoi(readInput())
+	if err != nil || index < 1 || index > len(securityPresets) {
+		fmt.Println("\033[1;31m输入无效。\033[0m")
+		promptToContinue()
+		return nil, false
+	}
+	preset := securityPresets[index-1][0]
+
+	var myIp string
+	if preset == presetSSHMyIp {
+		detected, err := getMyPublicIp()
+		if err != nil {
+			fmt.Print("请输入允许访问 SSH 的IP或 CIDR: ")
+		} else {
+			fmt.Printf("请输入允许访问 SSH 的IP或 CIDR (直接回车使用本机IP %s): ", detected)
+		}
+		myIp = readInput()
+		if myIp == "" {
+			myIp = detected
+		}
+	}
+	ingress, err = applySecurityPreset(preset, ingress, myIp, vcnCidrs)
+	if err != nil {
+		printlnErr("应用预设规则失败", err.Error())
+		promptToContinue()
+		return nil, false
+	}
+	return ingress, true
+}
+
+// confirmUpdateSecurityList 显示规则的变化, 确认后更新安全列表。etag 为获取安全列表时的 etag
+func confirmUpdateSecurityList(sl core.SecurityList, etag string, ingress []core.IngressSecurityRule, egress []core.EgressSecurityRule) {
+	fmt.Println()
+	changed := printRuleDiff("入站规则 (Ingress)", formatIngressRules(sl.IngressSecurityRules), formatIngressRules(ingress))
+	if printRuleDiff("出站规则 (Egress)", formatEgressRules(sl.EgressSecurityRules), formatEgressRules(egress)) {
+		changed = true
+	}
+	if !changed {
+		fmt.Println("规则没有变化。")
+		return
+	}
+	fmt.Print("\n确定应用以上修改？(输入 y 确认): ")
+	if readInput() != "y" {
+		return
+	}
+	_, err := UpdateSecurityList(account, sl.Id, etag, ingress, egress)
+	handleActionError(err, "更新防火墙规则")
+}
+
+// --- 引导卷管理 ---
+
+func listBootVolumes() {
+	printMenuTitle("引导卷管理")
+	bootVolumes, err := listAllBootVolumes(account)
+	if err != nil {
+		printlnErr("获取引导卷失败", err.Error())
+		if len(bootVolumes) == 0 {
+			promptToContinue()
+			return
+		}
+	}
+
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 8, 2, '\t', 0)
+	fmt.Fprintln(w, "序号\t名称\t状态\t大小(GB)")
+	fmt.Fprintln(w, "--\t--\t--\t---")
+	for i, volume := range bootVolumes {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%d\n", i+1, *volume.DisplayName, getBootVolumeState(volume.LifecycleState), *volume.SizeInGBs)
+	}
+	w.Flush()
+	fmt.Println()
+
+	fmt.Print("输入序号查看详情, 'k' 查看所有引导卷备份 (或 'b' 返回): ")
+	input := readInput()
+	if strings.EqualFold(input, "b") {
+		return
+	}
+	if strings.EqualFold(input, "k") {
+		manageBootVolumeBackups(nil)
+		return
+	}
+
+	index, err := strconv.Atoi(input)
+	if err == nil && 0 < index && index <= len(bootVolumes) {
+		bootvolumeDetails(bootVolumes[index-1].Id)
+	} else {
+		fmt.Println("\033[1;31m输入无效。\033[0m")
+		time.Sleep(1 * time.Second)
+	}
+}
+
+func bootvolumeDetails(bootVolumeId *string) {
+	for {
+		printMenuTitle("引导卷详细信息")
+		bootVolume, err := getBootVolume(account, bootVolumeId)
+		if err != nil {
+			printlnErr("获取引导卷详细信息失败", err.Error())
+			promptToContinue()
+			return
+		}
+
+		attachments, _ := listBootVolumeAttachments(account, bootVolume.AvailabilityDomain, bootVolume.CompartmentId, bootVolume.Id)
+		var attachIns []string
+		for _, attachment := range attachments {
+			ins, err := getInstance(account, attachment.InstanceId)
+			if err == nil {
+				attachIns = append(attachIns, *ins.DisplayName)
+			}
+		}
+
+		var performance string
+		if bootVolume.VpusPerGB != nil {
+			switch *bootVolume.VpusPerGB {
+			case 10:
+				performance = fmt.Sprintf("均衡 (VPU:%d)", *bootVolume.VpusPerGB)
+			case 20:
+				performance = fmt.Sprintf("性能较高 (VPU:%d)", *bootVolume.VpusPerGB)
+			default:
+				performance = fmt.Sprintf("UHP (VPU:%d)", *bootVolume.VpusPerGB)
 			}
 		}
 
@@ -739,9 +2003,11 @@ func bootvolumeDetails(bootVolumeId *string) {
 		fmt.Printf("大小(GB): %d\n", *bootVolume.SizeInGBs)
 		fmt.Printf("性能: %s\n", performance)
 		fmt.Printf("附加的实例: %s\n", strings.Join(attachIns, ", "))
+		fmt.Printf("备份策略: %s\n", getBackupPolicyName(bootVolume.Id))
 		fmt.Println(strings.Repeat("-", 40))
 
 		fmt.Println("1. 修改性能   2. 修改大小   3. 分离   4. 终止")
+		fmt.Println("5. 备份管理   6. 设置备份策略   7. 从此引导卷创建实例")
 		fmt.Println("\nb. 返回")
 		fmt.Print("\n请输入操作序号: ")
 
@@ -758,33 +2024,455 @@ func bootvolumeDetails(bootVolumeId *string) {
 			} else {
 				fmt.Println("输入无效。"); continue
 			}
-			_, err := updateBootVolume(bootVolume.Id, nil, &vpus)
+			_, err := updateBootVolume(account, bootVolume.Id, nil, &vpus)
 			handleActionError(err, "修改性能")
 		case "2":
 			fmt.Printf("修改引导卷大小, 请输入 (例如修改为50GB, 输入50): ")
 			sizeInGBs, err := strconv.ParseInt(readInput(), 10, 64)
 			if err == nil && sizeInGBs > 0 {
-				_, err := updateBootVolume(bootVolume.Id, &sizeInGBs, nil)
+				_, err := updateBootVolume(account, bootVolume.Id, &sizeInGBs, nil)
 				handleActionError(err, "修改大小")
 			} else {
 				fmt.Println("输入无效。")
 			}
-		case "3":
-			fmt.Printf("确定分离引导卷？(y/n): ")
-			if readInput() == "y" {
-				for _, attachment := range attachments {
-					_, err := detachBootVolume(attachment.Id)
-					handleActionError(err, "分离")
-				}
+		case "3":
+			fmt.Printf("确定分离引导卷？(y/n): ")
+			if readInput() == "y" {
+				for _, attachment := range attachments {
+					_, err := detachBootVolume(account, attachment.Id)
+					handleActionError(err, "分离")
+				}
+			}
+		case "4":
+			fmt.Printf("确定终止引导卷？(y/n): ")
+			if readInput() == "y" {
+				_, err := deleteBootVolume(account, bootVolume.Id)
+				if handleActionError(err, "终止") {
+					return
+				}
+			}
+		case "5":
+			manageBootVolumeBackups(&bootVolume)
+			continue
+		case "6":
+			setBackupPolicyMenu(bootVolume.Id)
+		case "7":
+			launchFromBootVolume(bootVolume)
+			continue
+		case "b":
+			return
+		default:
+			fmt.Println("\033[1;31m输入无效。\033[0m")
+		}
+		if input != "b" {
+			promptToContinue()
+		}
+	}
+}
+
+// --- 引导卷备份 ---
+
+// getBackupPolicyName 返回引导卷或块存储卷当前的备份策略名称
+func getBackupPolicyName(assetId *string) string {
+	assignment, err := getVolumeBackupPolicyAssignment(account, assetId)
+	if err != nil {
+		return "获取失败"
+	}
+	if assignment == nil {
+		return "无"
+	}
+	policies, err := listVolumeBackupPolicies(account)
+	if err == nil {
+		for _, policy := range policies {
+			if stringValue(policy.Id) == stringValue(assignment.PolicyId) {
+				return stringValue(policy.DisplayName)
+			}
+		}
+	}
+	return stringValue(assignment.PolicyId)
+}
+
+// setBackupPolicyMenu 为引导卷或块存储卷选择备份策略, 包括甲骨文预定义的 bronze/silver/gold 和自定义策略
+func setBackupPolicyMenu(assetId *string) {
+	policies, err := listVolumeBackupPolicies(account)
+	if err != nil {
+		printlnErr("获取备份策略失败", err.Error())
+		return
+	}
+	fmt.Println("0. 不使用备份策略")
+	for i, policy := range policies {
+		kind := "预定义"
+		if policy.CompartmentId != nil {
+			kind = "自定义"
+		}
+		fmt.Printf("%d. %s (%s, %d 个计划)\n", i+1, stringValue(policy.DisplayName), kind, len(policy.Schedules))
+	}
+	fmt.Print("请选择备份策略: ")
+	index, err := strconv.Atoi(readInput())
+	if err != nil || index < 0 || index > len(policies) {
+		fmt.Println("\033[1;31m输入无效。\033[0m")
+		return
+	}
+	var policyId *string
+	if index > 0 {
+		policyId = policies[index-1].Id
+	}
+	err = setVolumeBackupPolicy(account, assetId, policyId)
+	handleActionError(err, "设置备份策略")
+}
+
+// readAvailabilityDomain 选择可用性域, 直接回车时使用 defaultAd
+func readAvailabilityDomain(defaultAd *string) *string {
+	availabilityDomains, err := ListAvailabilityDomains(account)
+	if err != nil {
+		printlnErr("获取可用性域失败", err.Error())
+		return nil
+	}
+	for i, ad := range availabilityDomains {
+		fmt.Printf("%d. %s\n", i+1, stringValue(ad.Name))
+	}
+	if defaultAd != nil {
+		fmt.Printf("请选择可用性域 (默认 %s): ", *defaultAd)
+	} else {
+		fmt.Print("请选择可用性域: ")
+	}
+	input := readInput()
+	if input == "" && defaultAd != nil {
+		return defaultAd
+	}
+	index, err := strconv.Atoi(input)
+	if err != nil || index < 1 || index > len(availabilityDomains) {
+		fmt.Println("\033[1;31m输入无效。\033[0m")
+		return nil
+	}
+	return availabilityDomains[index-1].Name
+}
+
+// manageBootVolumeBackups 管理引导卷的备份, bootVolume 为 nil 时列出账号下所有引导卷的备份 (包括已终止的引导卷)
+func manageBootVolumeBackups(bootVolume *core.BootVolume) {
+	for {
+		printMenuTitle("引导卷备份")
+		var bootVolumeId, defaultAd *string
+		if bootVolume != nil {
+			bootVolumeId = bootVolume.Id
+			defaultAd = bootVolume.AvailabilityDomain
+		}
+		backups, err := listBootVolumeBackups(account, bootVolumeId)
+		if err != nil {
+			printlnErr("获取引导卷备份失败", err.Error())
+			promptToContinue()
+			return
+		}
+
+		w := new(tabwriter.Writer)
+		w.Init(os.Stdout, 0, 8, 2, '\t', 0)
+		fmt.Fprintln(w, "序号\t名称\t状态\t类型\t来源\t大小(GB)\t创建时间")
+		fmt.Fprintln(w, "--\t--\t--\t--\t--\t--\t--")
+		for i, backup := range backups {
+			kind := "完整"
+			if backup.Type == core.BootVolumeBackupTypeIncremental {
+				kind = "增量"
+			}
+			source := "手动"
+			if backup.SourceType == core.BootVolumeBackupSourceTypeScheduled {
+				source = "策略"
+			}
+			var size int64
+			if backup.SizeInGBs != nil {
+				size = *backup.SizeInGBs
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%d\t%s\n", i+1, stringValue(backup.DisplayName), getBootVolumeBackupState(backup.LifecycleState),
+				kind, source, size, backup.TimeCreated.Local().Format("2006-01-02 15:04"))
+		}
+		w.Flush()
+		fmt.Println("\nAlways Free 账号可以免费保存 5 个卷备份 (包括引导卷和块存储卷)。")
+
+		prompt := "\n输入序号恢复或删除备份"
+		if bootVolume != nil {
+			prompt += ", 'a' 创建备份"
+		}
+		fmt.Print(prompt + ", 'p' 清理旧的手动备份, 或 'b' 返回: ")
+		input := readInput()
+		switch {
+		case strings.EqualFold(input, "b"):
+			return
+		case strings.EqualFold(input, "a") && bootVolume != nil:
+			fmt.Print("请输入备份名称 (可选): ")
+			name := readInput()
+			fmt.Print("备份类型 1. 完整备份  2. 增量备份 (默认 1): ")
+			incremental := readInput() == "2"
+			backup, err := createBootVolumeBackup(account, bootVolume.Id, name, incremental)
+			if handleActionError(err, "创建备份") {
+				fmt.Printf("备份名称: %s\n", stringValue(backup.DisplayName))
+			}
+			promptToContinue()
+		case strings.EqualFold(input, "p"):
+			pruneBootVolumeBackupsMenu(backups)
+			promptToContinue()
+		default:
+			index, err := strconv.Atoi(input)
+			if err != nil || index < 1 || index > len(backups) {
+				fmt.Println("\033[1;31m输入无效!\033[0m")
+				time.Sleep(1 * time.Second)
+				continue
+			}
+			bootVolumeBackupActions(backups[index-1], defaultAd)
+			promptToContinue()
+		}
+	}
+}
+
+// bootVolumeBackupActions 将备份恢复为新的引导卷或删除备份
+func bootVolumeBackupActions(backup core.BootVolumeBackup, defaultAd *string) {
+	fmt.Printf("\n备份: %s\n", stringValue(backup.DisplayName))
+	fmt.Println("1. 恢复为新的引导卷   2. 删除备份")
+	fmt.Print("请输入操作序号: ")
+	switch readInput() {
+	case "1":
+		if backup.LifecycleState != core.BootVolumeBackupLifecycleStateAvailable {
+			fmt.Println("备份尚不可用。")
+			return
+		}
+		ad := readAvailabilityDomain(defaultAd)
+		if ad == nil {
+			return
+		}
+		fmt.Print("请输入新引导卷名称 (可选): ")
+		volume, err := restoreBootVolumeBackup(account, backup.Id, ad, readInput())
+		if handleActionError(err, "恢复备份") {
+			fmt.Printf("新引导卷: %s, 恢复完成后可在引导卷管理中查看\n", stringValue(volume.DisplayName))
+		}
+	case "2":
+		fmt.Print("确定删除此备份？(输入 y 确认): ")
+		if readInput() == "y" {
+			err := deleteBootVolumeBackup(account, backup.Id)
+			handleActionError(err, "删除备份")
+		}
+	default:
+		fmt.Println("\033[1;31m输入无效。\033[0m")
+	}
+}
+
+// pruneBootVolumeBackupsMenu 每个引导卷只保留最新的 N 个手动备份
+func pruneBootVolumeBackupsMenu(backups []core.BootVolumeBackup) {
+	fmt.Print("每个引导卷保留最新的手动备份个数: ")
+	keep, err := strconv.Atoi(readInput())
+	if err != nil || keep < 0 {
+		fmt.Println("\033[1;31m输入无效。\033[0m")
+		return
+	}
+	pruned := pruneBootVolumeBackups(backups, keep)
+	if len(pruned) == 0 {
+		fmt.Println("没有需要清理的备份。")
+		return
+	}
+	fmt.Println("将删除以下备份:")
+	for _, backup := range pruned {
+		var created string
+		if backup.TimeCreated != nil {
+			created = backup.TimeCreated.Local().Format("2006-01-02 15:04")
+		}
+		fmt.Printf("  %s (%s)\n", stringValue(backup.DisplayName), created)
+	}
+	fmt.Print("确定删除？(输入 y 确认): ")
+	if readInput() != "y" {
+		return
+	}
+	for _, backup := range pruned {
+		err := deleteBootVolumeBackup(account, backup.Id)
+		handleActionError(err, "删除备份 "+stringValue(backup.DisplayName))
+	}
+}
+
+// --- 块存储卷管理 ---
+
+// volumePerformance 返回块存储卷性能的描述
+func volumePerformance(vpusPerGB *int64) string {
+	if vpusPerGB == nil {
+		return ""
+	}
+	switch *vpusPerGB {
+	case 0:
+		return "低成本 (VPU:0)"
+	case 10:
+		return "均衡 (VPU:10)"
+	case 20:
+		return "性能较高 (VPU:20)"
+	default:
+		return fmt.Sprintf("UHP (VPU:%d)", *vpusPerGB)
+	}
+}
+
+// readVolumeVpus 选择块存储卷性能, 直接回车时使用均衡
+func readVolumeVpus() (int64, bool) {
+	fmt.Print("性能 0: 低成本  1: 均衡  2: 性能较高 (默认 1): ")
+	switch readInput() {
+	case "0":
+		return 0, true
+	case "", "1":
+		return 10, true
+	case "2":
+		return 20, true
+	}
+	fmt.Println("\033[1;31m输入无效。\033[0m")
+	return 0, false
+}
+
+func listBlockVolumes() {
+	for {
+		printMenuTitle("块存储卷管理")
+		fmt.Println("正在获取块存储卷...")
+		volumes, err := listAllVolumes(account)
+		if err != nil {
+			printlnErr("获取块存储卷失败", err.Error())
+			promptToContinue()
+			return
+		}
+
+		w := new(tabwriter.Writer)
+		w.Init(os.Stdout, 0, 8, 2, '\t', 0)
+		fmt.Fprintln(w, "序号\t名称\t状态\t大小(GB)\t可用区")
+		fmt.Fprintln(w, "--\t--\t--\t---\t--")
+		for i, volume := range volumes {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%s\n", i+1, stringValue(volume.DisplayName), getVolumeState(volume.LifecycleState), *volume.SizeInGBs, stringValue(volume.AvailabilityDomain))
+		}
+		w.Flush()
+
+		fmt.Print("\n输入序号查看详情, 'a' 创建块存储卷 (或 'b' 返回): ")
+		input := readInput()
+		switch {
+		case strings.EqualFold(input, "b"):
+			return
+		case strings.EqualFold(input, "a"):
+			createBlockVolume()
+			promptToContinue()
+		default:
+			index, err := strconv.Atoi(input)
+			if err == nil && 0 < index && index <= len(volumes) {
+				volumeDetails(volumes[index-1].Id)
+			} else {
+				fmt.Println("\033[1;31m输入无效。\033[0m")
+				time.Sleep(1 * time.Second)
+			}
+		}
+	}
+}
+
+func createBlockVolume() {
+	ad := readAvailabilityDomain(nil)
+	if ad == nil {
+		return
+	}
+	fmt.Print("请输入块存储卷名称 (可选): ")
+	name := readInput()
+	fmt.Print("请输入大小 (GB, 50 - 32768, 默认 50): ")
+	sizeInGBs := int64(50)
+	if input := readInput(); input != "" {
+		size, err := strconv.ParseInt(input, 10, 64)
+		if err != nil || size < 50 || size > 32768 {
+			fmt.Println("\033[1;31m输入无效。\033[0m")
+			return
+		}
+		sizeInGBs = size
+	}
+	vpus, ok := readVolumeVpus()
+	if !ok {
+		return
+	}
+	if err := checkBlockStorageQuota(account, sizeInGBs); err != nil {
+		printlnErr("已拒绝创建", err.Error())
+		return
+	}
+	volume, err := createVolume(account, ad, name, sizeInGBs, vpus)
+	if handleActionError(err, "创建块存储卷") {
+		fmt.Printf("块存储卷名称: %s\n", stringValue(volume.DisplayName))
+	}
+}
+
+func volumeDetails(volumeId *string) {
+	for {
+		printMenuTitle("块存储卷详细信息")
+		volume, err := getVolume(account, volumeId)
+		if err != nil {
+			printlnErr("获取块存储卷详细信息失败", err.Error())
+			promptToContinue()
+			return
+		}
+		attachments, err := listVolumeAttachments(account, volume.Id, nil)
+		if err != nil {
+			printlnErr("获取挂载信息失败", err.Error())
+		}
+
+		fmt.Printf("名称: %s\n", stringValue(volume.DisplayName))
+		fmt.Printf("状态: %s\n", getVolumeState(volume.LifecycleState))
+		fmt.Printf("大小(GB): %d\n", *volume.SizeInGBs)
+		fmt.Printf("性能: %s\n", volumePerformance(volume.VpusPerGB))
+		fmt.Printf("可用区: %s\n", stringValue(volume.AvailabilityDomain))
+		fmt.Printf("备份策略: %s\n", getBackupPolicyName(volume.Id))
+		fmt.Println("挂载的实例:")
+		for i, attachment := range attachments {
+			fmt.Printf("  %d. %s\n", i+1, formatVolumeAttachment(attachment))
+		}
+		fmt.Println(strings.Repeat("-", 40))
+
+		fmt.Println("1. 修改性能   2. 扩容   3. 挂载到实例   4. 分离   5. 终止")
+		fmt.Println("6. 显示 iSCSI 连接命令   7. 设置备份策略")
+		fmt.Println("\nb. 返回")
+		fmt.Print("\n请输入操作序号: ")
+
+		input := readInput()
+		switch input {
+		case "1":
+			vpus, ok := readVolumeVpus()
+			if ok {
+				_, err := updateVolume(account, volume.Id, nil, &vpus)
+				handleActionError(err, "修改性能")
+			}
+		case "2":
+			fmt.Printf("请输入新的大小 (GB, 当前 %d, 只能增加): ", *volume.SizeInGBs)
+			sizeInGBs, err := strconv.ParseInt(readInput(), 10, 64)
+			if err != nil || sizeInGBs <= *volume.SizeInGBs {
+				fmt.Println("\033[1;31m输入无效。\033[0m")
+				break
+			}
+			if err := checkBlockStorageQuota(account, sizeInGBs-*volume.SizeInGBs); err != nil {
+				printlnErr("已拒绝扩容", err.Error())
+				break
 			}
+			_, err = updateVolume(account, volume.Id, &sizeInGBs, nil)
+			if handleActionError(err, "扩容") {
+				fmt.Println("扩容完成后需要在实例中重新扫描磁盘并扩展分区和文件系统, 如 growpart 和 resize2fs/xfs_growfs。")
+			}
+		case "3":
+			attachVolumeMenu(volume)
 		case "4":
-			fmt.Printf("确定终止引导卷？(y/n): ")
+			detachVolumeMenu(attachments)
+		case "5":
+			if len(attachments) > 0 {
+				fmt.Println("请先分离块存储卷。")
+				break
+			}
+			fmt.Print("确定终止块存储卷？数据将被删除 (输入 y 确认): ")
 			if readInput() == "y" {
-				_, err := deleteBootVolume(bootVolume.Id)
+				err := deleteVolume(account, volume.Id)
 				if handleActionError(err, "终止") {
+					promptToContinue()
 					return
 				}
 			}
+		case "6":
+			found := false
+			for _, attachment := range attachments {
+				if cmds := iscsiCommands(attachment, true); cmds != nil {
+					found = true
+					fmt.Printf("\n%s:\n%s\n", formatVolumeAttachment(attachment), strings.Join(cmds, "\n"))
+				}
+			}
+			if !found {
+				fmt.Println("没有 iSCSI 挂载。")
+			}
+		case "7":
+			setBackupPolicyMenu(volume.Id)
 		case "b":
 			return
 		default:
@@ -796,17 +2484,274 @@ func bootvolumeDetails(bootVolumeId *string) {
 	}
 }
 
+// formatVolumeAttachment 返回卷挂载的描述: 实例名称、挂载方式和访问方式
+func formatVolumeAttachment(attachment core.VolumeAttachment) string {
+	name := stringValue(attachment.GetInstanceId())
+	if ins, err := getInstance(account, attachment.GetInstanceId()); err == nil {
+		name = stringValue(ins.DisplayName)
+	}
+	kind := "半虚拟化"
+	if _, ok := attachment.(core.IScsiVolumeAttachment); ok {
+		kind = "iSCSI"
+	}
+	var flags []string
+	if b := attachment.GetIsReadOnly(); b != nil && *b {
+		flags = append(flags, "只读")
+	}
+	if b := attachment.GetIsShareable(); b != nil && *b {
+		flags = append(flags, "可共享")
+	}
+	if device := stringValue(attachment.GetDevice()); device != "" {
+		flags = append(flags, device)
+	}
+	desc := fmt.Sprintf("%s (%s", name, kind)
+	if len(flags) > 0 {
+		desc += ", " + strings.Join(flags, ", ")
+	}
+	return desc + ") " + string(attachment.GetLifecycleState())
+}
+
+func attachVolumeMenu(volume core.Volume) {
+	instance := selectInstance()
+	if instance == nil {
+		return
+	}
+	if stringValue(instance.AvailabilityDomain) != stringValue(volume.AvailabilityDomain) {
+		fmt.Println("实例与块存储卷不在同一个可用区，无法挂载。")
+		return
+	}
+	fmt.Print("挂载方式 1. 半虚拟化  2. iSCSI (默认 1): ")
+	iscsi := readInput() == "2"
+	fmt.Print("是否只读？(y/N): ")
+	readOnly := strings.EqualFold(readInput(), "y")
+	fmt.Print("是否允许挂载到多个实例 (可共享)？(y/N): ")
+	shareable := strings.EqualFold(readInput(), "y")
+
+	fmt.Println("正在挂载，请稍候...")
+	attachment, err := attachVolume(account, instance.Id, volume.Id, iscsi, readOnly, shareable)
+	if !handleActionError(err, "挂载") {
+		return
+	}
+	if cmds := iscsiCommands(attachment, true); cmds != nil {
+		fmt.Println("\n请在实例中执行以下命令连接块存储卷:")
+		fmt.Println(strings.Join(cmds, "\n"))
+	}
+	fmt.Println("\n挂载完成后可在实例中执行 lsblk 查看新磁盘，新磁盘需要格式化并挂载后才能使用。")
+}
+
+func detachVolumeMenu(attachments []core.VolumeAttachment) {
+	if len(attachments) == 0 {
+		fmt.Println("块存储卷未挂载。")
+		return
+	}
+	attachment := attachments[0]
+	if len(attachments) > 1 {
+		fmt.Print("请输入要分离的挂载序号: ")
+		index, err := strconv.Atoi(readInput())
+		if err != nil || index < 1 || index > len(attachments) {
+			fmt.Println("\033[1;31m输入无效。\033[0m")
+			return
+		}
+		attachment = attachments[index-1]
+	}
+	if cmds := iscsiCommands(attachment, false); cmds != nil {
+		fmt.Println("分离前请先在实例中卸载文件系统并执行以下命令断开连接:")
+		fmt.Println(strings.Join(cmds, "\n"))
+	} else {
+		fmt.Println("分离前请先在实例中卸载文件系统。")
+	}
+	fmt.Print("确定分离块存储卷？(输入 y 确认): ")
+	if readInput() == "y" {
+		err := detachVolume(account, attachment.GetId())
+		handleActionError(err, "分离")
+	}
+}
+
+// --- 自定义镜像 ---
+
+func listCustomImagesMenu() {
+	for {
+		printMenuTitle("自定义镜像管理")
+		fmt.Println("正在获取自定义镜像...")
+		images, err := listCustomImages(account)
+		if err != nil {
+			printlnErr("获取自定义镜像失败", err.Error())
+			promptToContinue()
+			return
+		}
+
+		w := new(tabwriter.Writer)
+		w.Init(os.Stdout, 0, 8, 2, '\t', 0)
+		fmt.Fprintln(w, "序号\t名称\t状态\t操作系统\t创建时间")
+		fmt.Fprintln(w, "--\t--\t--\t--\t--")
+		for i, image := range images {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s %s\t%s\n", i+1, stringValue(image.DisplayName), getImageState(image.LifecycleState),
+				stringValue(image.OperatingSystem), stringValue(image.OperatingSystemVersion), image.TimeCreated.Local().Format("2006-01-02 15:04"))
+		}
+		w.Flush()
+		fmt.Println("\n从实例创建镜像请在实例详情中选择 创建自定义镜像。")
+
+		fmt.Print("\n输入序号查看详情 (或 'b' 返回): ")
+		input := readInput()
+		if strings.EqualFold(input, "b") {
+			return
+		}
+		index, err := strconv.Atoi(input)
+		if err == nil && 0 < index && index <= len(images) {
+			customImageDetails(images[index-1])
+		} else {
+			fmt.Println("\033[1;31m输入无效。\033[0m")
+			time.Sleep(1 * time.Second)
+		}
+	}
+}
+
+func customImageDetails(image core.Image) {
+	printMenuTitle("自定义镜像详细信息")
+	fmt.Printf("名称: %s\n", stringValue(image.DisplayName))
+	fmt.Printf("状态: %s\n", getImageState(image.LifecycleState))
+	fmt.Printf("操作系统: %s %s\n", stringValue(image.OperatingSystem), stringValue(image.OperatingSystemVersion))
+	if image.SizeInMBs != nil {
+		fmt.Printf("大小(GB): %.1f\n", float64(*image.SizeInMBs)/1024)
+	}
+	fmt.Printf("OCID: %s\n", stringValue(image.Id))
+	fmt.Println(strings.Repeat("-", 40))
+
+	fmt.Println("1. 从此镜像创建实例   2. 复制到其他区域   3. 删除")
+	fmt.Println("\nb. 返回")
+	fmt.Print("\n请输入操作序号: ")
+
+	switch readInput() {
+	case "1":
+		launchFromCustomImage(image)
+		return
+	case "2":
+		copyCustomImageMenu(image)
+	case "3":
+		fmt.Print("确定删除镜像？(输入 y 确认): ")
+		if readInput() == "y" {
+			err := deleteImage(account, image.Id)
+			handleActionError(err, "删除镜像")
+		}
+	case "b":
+		return
+	default:
+		fmt.Println("\033[1;31m输入无效。\033[0m")
+	}
+	promptToContinue()
+}
+
+// launchFromCustomImage 使用自定义镜像创建实例, 实例的配置和网络取自选择的实例模板
+func launchFromCustomImage(image core.Image) {
+	if image.LifecycleState != core.ImageLifecycleStateAvailable {
+		fmt.Println("镜像当前不可用，无法创建实例。")
+		promptToContinue()
+		return
+	}
+	printMenuTitle("从自定义镜像创建实例")
+	fmt.Printf("镜像: %s\n", stringValue(image.DisplayName))
+	fmt.Println("将使用所选模板的配置和网络, 模板中的操作系统不会生效。")
+	spec, ok := selectInstanceTemplate("请输入要使用的实例模板的序号 (或 'b' 返回): ")
+	if !ok {
+		return
+	}
+	spec.ImageId, spec.ImageDisplayName = *image.Id, ""
+	spec.BootVolumeId, spec.BootVolumeDisplayName = "", ""
+	// 临时指定的镜像与模板本身的创建进度无关, 不保存进度
+	spec.Template = ""
+	spec.DesiredState = false
+	availabilityDomains, err := ListAvailabilityDomains(account)
+	if err != nil {
+		printlnErr("获取可用性域失败", err.Error())
+	} else if _, _, err := LaunchInstances(account, spec, availabilityDomains, nil); err != nil {
+		printlnErr("创建实例失败", err.Error())
+	}
+	promptToContinue()
+}
+
+// copyCustomImageMenu 选择订阅的其他区域并复制镜像, 需要等待镜像导出完成
+func copyCustomImageMenu(image core.Image) {
+	subscriptions, err := listRegionSubscriptions(account)
+	if err != nil {
+		printlnErr("获取订阅的区域失败", err.Error())
+		return
+	}
+	var regions []string
+	for _, sub := range subscriptions {
+		if region := stringValue(sub.RegionName); region != account.Oracle.Region {
+			regions = append(regions, region)
+		}
+	}
+	if len(regions) == 0 {
+		fmt.Println("租户没有订阅其他区域。")
+		return
+	}
+	for i, region := range regions {
+		fmt.Printf("%d. %s\n", i+1, region)
+	}
+	fmt.Print("请选择目标区域: ")
+	index, err := strconv.Atoi(readInput())
+	if err != nil || index < 1 || index > len(regions) {
+		fmt.Println("输入无效。")
+		return
+	}
+	fmt.Printf("镜像将先导出到存储桶 %s, 可能需要几十分钟, 确定复制？(输入 y 确认): ", defaultImageBucket)
+	if readInput() != "y" {
+		return
+	}
+	newImage, err := copyImageToRegion(account, image.Id, regions[index-1], defaultImageBucket)
+	if handleActionError(err, "复制镜像") {
+		fmt.Printf("镜像已复制到区域 %s (%s)。\n", regions[index-1], stringValue(newImage.Id))
+	}
+}
+
 // --- 实例创建 ---
 
 func listLaunchInstanceTemplates() {
 	printMenuTitle("从模板创建实例")
-	var instanceSections []*ini.Section
-	instanceSections = append(instanceSections, instanceBaseSection.ChildSections()...)
-	instanceSections = append(instanceSections, oracleSection.ChildSections()...)
+	spec, ok := selectInstanceTemplate("请输入要创建的实例的序号 (或 'b' 返回): ")
+	if !ok {
+		return
+	}
+	availabilityDomains, err := ListAvailabilityDomains(account)
+	if err != nil {
+		printlnErr("获取可用性域失败", err.Error())
+	} else if _, _, err := LaunchInstances(account, spec, availabilityDomains, nil); err != nil {
+		printlnErr("创建实例失败", err.Error())
+	}
+	promptToContinue()
+}
+
+// launchFromBootVolume 使用已有的引导卷创建实例, 实例的配置和网络取自选择的实例模板
+func launchFromBootVolume(bootVolume core.BootVolume) {
+	printMenuTitle("从引导卷创建实例")
+	fmt.Printf("引导卷: %s, 可用性域: %s\n", *bootVolume.DisplayName, *bootVolume.AvailabilityDomain)
+	fmt.Println("将使用所选模板的 Shape、CPU、内存和网络配置, 模板中的系统镜像和引导卷大小不会生效。")
+	spec, ok := selectInstanceTemplate("请输入要使用的实例模板的序号 (或 'b' 返回): ")
+	if !ok {
+		return
+	}
+	spec.BootVolumeId, spec.BootVolumeDisplayName = *bootVolume.Id, ""
+	spec.ImageId, spec.ImageDisplayName = "", ""
+	// 临时指定的引导卷与模板本身的创建进度无关, 不保存进度
+	spec.Template = ""
+	spec.DesiredState = false
+	availabilityDomains, err := ListAvailabilityDomains(account)
+	if err != nil {
+		printlnErr("获取可用性域失败", err.Error())
+	} else if _, _, err := LaunchInstances(account, spec, availabilityDomains, nil); err != nil {
+		printlnErr("创建实例失败", err.Error())
+	}
+	promptToContinue()
+}
+
+// selectInstanceTemplate 列出当前账号的实例模板并读取用户的选择, 返回 false 表示用户取消或选择无效
+func selectInstanceTemplate(prompt string) (Instance, bool) {
+	instanceSections := getInstanceSections(oracleSection)
 	if len(instanceSections) == 0 {
 		fmt.Println("未找到任何实例模版。")
 		promptToContinue()
-		return
+		return Instance{}, false
 	}
 
 	w := new(tabwriter.Writer)
@@ -827,83 +2772,115 @@ func listLaunchInstanceTemplates() {
 	w.Flush()
 	fmt.Println()
 
-	fmt.Print("请输入要创建的实例的序号 (或 'b' 返回): ")
+	fmt.Print(prompt)
 	input := readInput()
 	if strings.EqualFold(input, "b") {
-		return
+		return Instance{}, false
 	}
 
 	index, err := strconv.Atoi(input)
-	if err == nil && 0 < index && index <= len(instanceSections) {
-		instanceSection := instanceSections[index-1]
-		instance = Instance{}
-		instanceBaseSection.MapTo(&instance)
-		instanceSection.MapTo(&instance)
-
-		availabilityDomains, err := ListAvailabilityDomains()
-		if err != nil {
-			printlnErr("获取可用性域失败", err.Error())
-		} else {
-			LaunchInstances(availabilityDomains)
-		}
-	} else {
+	if err != nil || index <= 0 || index > len(instanceSections) {
 		fmt.Println("\033[1;31m输入无效。\033[0m")
+		promptToContinue()
+		return Instance{}, false
 	}
-	promptToContinue()
+	spec, err := loadInstanceTemplate(instanceSections[index-1])
+	if err != nil {
+		printlnErr("解析实例模板失败", err.Error())
+		promptToContinue()
+		return Instance{}, false
+	}
+	return spec, true
 }
 
 // --- 批量操作 ---
 
+// multiBatchLaunchInstances 为所有账号并发执行实例模板, 同时执行的账号数由配置文件中的 concurrency 限制
 func multiBatchLaunchInstances() {
 	IPsFilePath := IPsFilePrefix + "-" + time.Now().Format("2006-01-02-150405.txt")
-	for _, sec := range oracleSections {
-		err := initOCIClient(sec)
+	runAccountsParallel(oracleSections, func(sec *ini.Section) {
+		acc, err := newAccount(sec)
 		if err != nil {
-			continue
+			return
 		}
-		batchLaunchInstances(sec)
-		batchListInstancesIp(IPsFilePath, sec)
+		batchLaunchInstances(acc, sec)
+		batchListInstancesIp(acc, IPsFilePath)
 		command(cmd)
-		sleepRandomSecond(5, 5)
+	})
+}
+
+// runAccountsParallel 并发地为每个账号执行 fn 并等待全部完成。
+// 同时执行的账号数不超过 concurrency, concurrency <= 0 时不限制。
+func runAccountsParallel(secs []*ini.Section, fn func(sec *ini.Section)) {
+	n := concurrency
+	if n <= 0 || n > len(secs) {
+		n = len(secs)
+	}
+	sem := make(chan struct{}, n)
+	var wg sync.WaitGroup
+	for _, sec := range secs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(sec *ini.Section) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			fn(sec)
+		}(sec)
 	}
+	wg.Wait()
 }
 
-func batchLaunchInstances(oracleSec *ini.Section) {
-	var instanceSections []*ini.Section
-	instanceSections = append(instanceSections, instanceBaseSection.ChildSections()...)
-	instanceSections = append(instanceSections, oracleSec.ChildSections()...)
+// batchLaunchInstances 在账号 acc 中并发执行 oracleSec 下的所有实例模板, 每个模板使用各自的配置
+func batchLaunchInstances(acc *Account, oracleSec *ini.Section) {
+	instanceSections := getInstanceSections(oracleSec)
 	if len(instanceSections) == 0 {
 		return
 	}
 
-	printf("\033[1;36m[%s] 开始批量创建\033[0m\n", oracleSectionName)
-	sendMessage(fmt.Sprintf("[%s]", oracleSectionName), "开始批量创建")
+	// 先在当前 goroutine 中解析所有模板, 每个 goroutine 只使用自己的模板副本
+	var specs []Instance
+	for _, instanceSec := range instanceSections {
+		spec, err := loadInstanceTemplate(instanceSec)
+		if err != nil {
+			printlnErr(fmt.Sprintf("[%s] 解析实例模板 %s 失败", acc.Name, instanceSec.Name()), err.Error())
+			continue
+		}
+		specs = append(specs, spec)
+	}
+
+	availabilityDomains, err := ListAvailabilityDomains(acc)
+	if err != nil {
+		printlnErr(fmt.Sprintf("[%s] 获取可用性域失败", acc.Name), err.Error())
+		return
+	}
+
+	printf("\033[1;36m[%s] 开始批量创建\033[0m\n", acc.Name)
+	notify(fmt.Sprintf("[%s]", acc.Name), "开始批量创建")
 	var totalSUM, totalNUM int32
+	var mu sync.Mutex
 
 	var wg sync.WaitGroup
-	for _, instanceSec := range instanceSections {
+	for _, spec := range specs {
 		wg.Add(1)
-		go func(sec *ini.Section) {
+		go func(spec Instance) {
 			defer wg.Done()
-			localInstance := Instance{}
-			instanceBaseSection.MapTo(&localInstance)
-			sec.MapTo(&localInstance)
-
-			availabilityDomains, err := ListAvailabilityDomains()
+			sum, num, err := LaunchInstances(acc, spec, availabilityDomains, nil)
 			if err != nil {
-				printlnErr("获取可用性域失败", err.Error())
-				return
+				printlnErr(fmt.Sprintf("[%s] 模板 %s 创建实例失败", acc.Name, spec.Template), err.Error())
 			}
-			sum, num := LaunchInstances(availabilityDomains)
+			mu.Lock()
 			totalSUM += sum
 			totalNUM += num
-		}(instanceSec)
+			mu.Unlock()
+		}(spec)
 	}
 	wg.Wait()
 
 	text := fmt.Sprintf("结束创建。总计: %d, 成功: %d, 失败: %d", totalSUM, totalNUM, totalSUM-totalNUM)
-	printf("\033[1;36m[%s] %s\033[0m\n", oracleSectionName, text)
-	sendMessage(fmt.Sprintf("[%s]", oracleSectionName), text)
+	printf("\033[1;36m[%s] %s\033[0m\n", acc.Name, text)
+	notify(fmt.Sprintf("[%s]", acc.Name), text)
 }
 
 func multiBatchListInstancesIp() {
@@ -915,70 +2892,280 @@ func multiBatchListInstancesIp() {
 
 	fmt.Printf("正在导出所有实例公共IP地址...\n")
 	for _, sec := range oracleSections {
-		err := initOCIClient(sec)
+		acc, err := newAccount(sec)
 		if err != nil {
 			continue
 		}
-		ListInstancesIPs(IPsFilePath, sec.Name())
+		ListInstancesIPs(acc, IPsFilePath)
 	}
 	fmt.Printf("导出完成，请查看文件 %s\n", IPsFilePath)
 }
 
-func batchListInstancesIp(filePath string, sec *ini.Section) {
+// rotateTarget 批量更换IP时选中的实例
+type rotateTarget struct {
+	acc      *Account
+	instance core.Instance
+	ip       string
+	err      error
+}
+
+// multiBatchChangePublicIp 为所有账号中选中的实例更换公共IP, 可指定不希望使用的IP段, 新IP落在其中时继续更换。
+// 结果写入IP导出文件并发送消息提醒
+func multiBatchChangePublicIp() {
+	fmt.Println("正在获取所有账号的实例...")
+	var targets []*rotateTarget
+	for _, sec := range oracleSections {
+		acc, err := newAccount(sec)
+		if err != nil {
+			continue
+		}
+		instances, err := listAllInstances(acc)
+		if err != nil {
+			printlnErr(fmt.Sprintf("[%s] 获取实例失败", acc.Name), err.Error())
+			continue
+		}
+		for _, ins := range instances {
+			if ins.LifecycleState == core.InstanceLifecycleStateRunning {
+				targets = append(targets, &rotateTarget{acc: acc, instance: ins})
+			}
+		}
+	}
+	if len(targets) == 0 {
+		fmt.Println("没有正在运行的实例。")
+		return
+	}
+
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 8, 2, '\t', 0)
+	fmt.Fprintln(w, "序号\t账号\t名称\t配置")
+	fmt.Fprintln(w, "--\t--\t--\t--")
+	for i, t := range targets {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", i+1, t.acc.Name, *t.instance.DisplayName, *t.instance.Shape)
+	}
+	w.Flush()
+
+	fmt.Print("\n请输入要更换IP的实例序号, 多个以逗号分隔, 'all' 全部 (或 'b' 返回): ")
+	input := readInput()
+	if strings.EqualFold(input, "b") {
+		return
+	}
+	var selected []*rotateTarget
+	if strings.EqualFold(input, "all") {
+		selected = targets
+	} else {
+		for _, item := range strings.Split(input, ",") {
+			index, err := strconv.Atoi(strings.TrimSpace(item))
+			if err != nil || index < 1 || index > len(targets) {
+				fmt.Println("\033[1;31m输入无效。\033[0m")
+				return
+			}
+			selected = append(selected, targets[index-1])
+		}
+	}
+
+	fmt.Print("请输入不希望使用的IP段 (CIDR, 多个以逗号分隔, 直接回车跳过): ")
+	blocklist, err := parseCIDRs(readInput())
+	if err != nil {
+		printlnErr("解析IP段失败", err.Error())
+		return
+	}
+	maxTries := 1
+	if len(blocklist) > 0 {
+		maxTries = 10
+		fmt.Printf("请输入每个实例最多更换的次数 (默认 %d): ", maxTries)
+		if input := readInput(); input != "" {
+			maxTries, err = strconv.Atoi(input)
+			if err != nil || maxTries < 1 {
+				fmt.Println("\033[1;31m输入无效。\033[0m")
+				return
+			}
+		}
+	}
+
+	// 同一账号内依次更换, 不同账号并发执行
+	var secs []*ini.Section
+	byAccount := map[string][]*rotateTarget{}
+	for _, t := range selected {
+		if _, ok := byAccount[t.acc.Name]; !ok {
+			sec, _ := getOracleSection(t.acc.Name)
+			secs = append(secs, sec)
+		}
+		byAccount[t.acc.Name] = append(byAccount[t.acc.Name], t)
+	}
+	runAccountsParallel(secs, func(sec *ini.Section) {
+		for _, t := range byAccount[sec.Name()] {
+			printf("[%s] 正在更换实例 %s 的公共IP...\n", t.acc.Name, *t.instance.DisplayName)
+			t.ip, t.err = rotatePublicIp(t.acc, t.instance.Id, blocklist, maxTries)
+			if t.err != nil {
+				printlnErr(fmt.Sprintf("[%s] 更换实例 %s 的公共IP失败", t.acc.Name, *t.instance.DisplayName), t.err.Error())
+			} else {
+				printf("\033[1;32m[%s] 实例 %s 的新公共IP: %s\033[0m\n", t.acc.Name, *t.instance.DisplayName, t.ip)
+			}
+		}
+	})
+
+	IPsFilePath := IPsFilePrefix + "-" + time.Now().Format("2006-01-02-150405.txt")
+	var lines []string
+	var num int
+	for _, sec := range secs {
+		for _, t := range byAccount[sec.Name()] {
+			if t.err != nil {
+				lines = append(lines, fmt.Sprintf("[%s] %s: 更换失败, %v", t.acc.Name, *t.instance.DisplayName, t.err))
+			} else {
+				num++
+				lines = append(lines, fmt.Sprintf("[%s] %s: %s", t.acc.Name, *t.instance.DisplayName, t.ip))
+			}
+		}
+		writeRotatedIPs(IPsFilePath, sec.Name(), byAccount[sec.Name()])
+	}
+	text := fmt.Sprintf("总计: %d, 成功: %d, 失败: %d\n%s", len(selected), num, len(selected)-num, strings.Join(lines, "\n"))
+	notify("批量更换IP", text)
+	fmt.Printf("更换完成，请查看文件 %s\n", IPsFilePath)
+}
+
+// writeRotatedIPs 将账号下更换成功的公共IP追加到IP导出文件, 格式与 ListInstancesIPs 相同
+func writeRotatedIPs(filePath, accountName string, targets []*rotateTarget) error {
+	ipsFileMutex.Lock()
+	defer ipsFileMutex.Unlock()
+	file, err := os.OpenFile(filePath, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		fmt.Printf("打开文件失败, Error: %s\n", err.Error())
+		return err
+	}
+	defer file.Close()
+
+	io.WriteString(file, "["+accountName+"]\n")
+	for _, t := range targets {
+		if t.err == nil {
+			io.WriteString(file, fmt.Sprintf("实例: %s, IP: %s\n", *t.instance.DisplayName, t.ip))
+		}
+	}
+	io.WriteString(file, "\n")
+	return nil
+}
+
+func batchListInstancesIp(acc *Account, filePath string) {
+	ipsFileMutex.Lock()
 	_, err := os.Stat(filePath)
 	if err != nil && os.IsNotExist(err) {
 		os.Create(filePath)
 	}
-	fmt.Printf("正在为账号 [%s] 导出实例公共IP地址...\n", sec.Name())
-	ListInstancesIPs(filePath, sec.Name())
+	ipsFileMutex.Unlock()
+	fmt.Printf("正在为账号 [%s] 导出实例公共IP地址...\n", acc.Name)
+	ListInstancesIPs(acc, filePath)
 	fmt.Printf("导出完成，请查看文件 %s\n", filePath)
 }
 
-func ListInstancesIPs(filePath string, sectionName string) {
+// collectInstanceIPs 获取账号下所有网卡的公共IP, 包括辅助私有IP的公共IP
+func collectInstanceIPs(acc *Account) ([]IPRecord, error) {
 	var vnicAttachments []core.VnicAttachment
 	var vas []core.VnicAttachment
 	var nextPage *string
 	var err error
 	for {
-		vas, nextPage, err = ListVnicAttachments(ctx, computeClient, nil, nextPage)
-		if err == nil {
-			vnicAttachments = append(vnicAttachments, vas...)
+		vas, nextPage, err = ListVnicAttachments(acc, nil, nextPage)
+		for _, va := range vas {
+			// 已分离或正在分离的网卡没有可用的IP
+			if va.LifecycleState == core.VnicAttachmentLifecycleStateAttached {
+				vnicAttachments = append(vnicAttachments, va)
+			}
 		}
 		if nextPage == nil || len(vas) == 0 {
 			break
 		}
 	}
-
-	if err != nil {
-		fmt.Printf("ListVnicAttachments Error: %s\n", err.Error())
-		return
-	}
-	file, err := os.OpenFile(filePath, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
 	if err != nil {
-		fmt.Printf("打开文件失败, Error: %s\n", err.Error())
-		return
+		return nil, err
 	}
-	defer file.Close()
-
-	io.WriteString(file, "["+sectionName+"]\n")
 
+	results := make([][]IPRecord, len(vnicAttachments))
+	failures := make([][]string, len(vnicAttachments))
 	var wg sync.WaitGroup
-	for _, vnicAttachment := range vnicAttachments {
+	for i, vnicAttachment := range vnicAttachments {
 		wg.Add(1)
-		go func(va core.VnicAttachment) {
+		go func(i int, va core.VnicAttachment) {
 			defer wg.Done()
-			vnic, err := GetVnic(ctx, networkClient, va.VnicId)
+			vnic, err := GetVnic(acc, va.VnicId)
 			if err != nil {
-				fmt.Printf("IP地址获取失败, %s\n", err.Error())
+				failures[i] = append(failures[i], fmt.Sprintf("%s: %v", stringValue(va.VnicId), err))
 				return
 			}
+			record := IPRecord{
+				Account:    acc.Name,
+				InstanceId: stringValue(va.InstanceId),
+				VnicName:   stringValue(vnic.DisplayName),
+				VnicId:     stringValue(vnic.Id),
+			}
+			label := record.VnicName // 错误信息中的网卡名称, 没有名称时使用网卡 ID
+			if label == "" {
+				label = record.VnicId
+			}
+			// 主私有IP的公共IP可以直接从网卡获取, 辅助私有IP的公共IP需要逐个查询
+			privateIps, err := getPrivateIps(acc, vnic.Id)
+			if err != nil {
+				failures[i] = append(failures[i], fmt.Sprintf("%s: %v", label, err))
+			}
+			for _, p := range privateIps {
+				if p.IsPrimary != nil && *p.IsPrimary {
+					continue
+				}
+				publicIp, err := getAssignedPublicIp(acc, p.Id)
+				if err != nil {
+					failures[i] = append(failures[i], fmt.Sprintf("%s %s: %v", label, stringValue(p.IpAddress), err))
+					continue
+				}
+				if publicIp.IpAddress != nil {
+					record.PublicIp, record.PrivateIp = *publicIp.IpAddress, stringValue(p.IpAddress)
+					results[i] = append(results[i], record)
+				}
+			}
 			if vnic.PublicIp != nil && *vnic.PublicIp != "" {
-				line := fmt.Sprintf("实例: %s, IP: %s\n", *vnic.DisplayName, *vnic.PublicIp)
-				fmt.Print(line)
-				io.WriteString(file, line)
+				record.PublicIp, record.PrivateIp = *vnic.PublicIp, stringValue(vnic.PrivateIp)
+				results[i] = append([]IPRecord{record}, results[i]...)
 			}
-		}(vnicAttachment)
+		}(i, vnicAttachment)
 	}
 	wg.Wait()
+
+	var records []IPRecord
+	var failed []string
+	for i, rs := range results {
+		records = append(records, rs...)
+		failed = append(failed, failures[i]...)
+	}
+	if len(failed) > 0 {
+		return records, fmt.Errorf("以下网卡的IP获取失败: %s", strings.Join(failed, "; "))
+	}
+	return records, nil
+}
+
+// ipsFileMutex 多个账号并发导出IP时, 保证每个账号的IP在文件中连续写入
+var ipsFileMutex sync.Mutex
+
+func ListInstancesIPs(acc *Account, filePath string) error {
+	// 部分网卡获取失败时仍然写入已获取到的IP, 最后返回错误
+	records, collectErr := collectInstanceIPs(acc)
+	if collectErr != nil {
+		fmt.Printf("[%s] 获取IP失败: %s\n", acc.Name, collectErr.Error())
+		if records == nil {
+			return collectErr
+		}
+	}
+	ipsFileMutex.Lock()
+	defer ipsFileMutex.Unlock()
+	file, err := os.OpenFile(filePath, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		fmt.Printf("打开文件失败, Error: %s\n", err.Error())
+		return err
+	}
+	defer file.Close()
+
+	io.WriteString(file, "["+acc.Name+"]\n")
+	for _, r := range records {
+		line := fmt.Sprintf("实例: %s, IP: %s\n", r.VnicName, r.PublicIp)
+		fmt.Print(line)
+		io.WriteString(file, line)
+	}
 	io.WriteString(file, "\n")
+	return collectErr
 }
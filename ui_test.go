@@ -0,0 +1,28 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/core"
+)
+
+// TestCollectInstanceIPsPartialFailure 部分网卡获取失败时返回已获取到的IP和错误, 而不是静默忽略
+func TestCollectInstanceIPsPartialFailure(t *testing.T) {
+	fake := newFakeOCI()
+	acc := newTestAccount(t, "acc", fake)
+	for _, id := range []string{"a", "b"} {
+		fake.instances = append(fake.instances, core.Instance{Id: common.String(id), LifecycleState: core.InstanceLifecycleStateRunning})
+	}
+	// 网卡 b 没有私有IP, 获取私有IP失败
+	fake.privateIps["ocid1.vnic.a"] = []core.PrivateIp{{IpAddress: common.String("10.0.0.2"), IsPrimary: common.Bool(true)}}
+
+	records, err := collectInstanceIPs(acc)
+	if err == nil || !strings.Contains(err.Error(), "ocid1.vnic.b") {
+		t.Errorf("err=%v, 期望包含网卡 ocid1.vnic.b 的错误", err)
+	}
+	if len(records) != 2 {
+		t.Errorf("获取到 %d 个IP, 期望 2 个: %+v", len(records), records)
+	}
+}
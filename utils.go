@@ -4,8 +4,11 @@ import (
 	"bytes"
 	"fmt"
 	"math/rand"
+	"net"
+	"os"
 	"os/exec"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/oracle/oci-go-sdk/v65/common"
@@ -18,13 +21,18 @@ func printf(format string, a ...interface{}) {
 	fmt.Printf(format, a...)
 }
 
-// printlnErr 以红色打印格式化的错误消息
+// printlnErr 以红色打印格式化的错误消息, 输出到标准错误以免混入命令行的结构化输出
 func printlnErr(desc, detail string) {
-	fmt.Printf("\033[1;31mError: %s. %s\033[0m\n", desc, detail)
+	fmt.Fprintf(os.Stderr, "\033[1;31mError: %s. %s\033[0m\n", desc, detail)
 }
 
 // sleepRandomSecond 在指定的最小和最大值之间随机休眠一段时间（单位：秒）
 func sleepRandomSecond(min, max int32) {
+	sleepRandomSecondUntil(min, max, nil)
+}
+
+// sleepRandomSecondUntil 与 sleepRandomSecond 相同, 但 stop 被关闭时会提前结束休眠并返回 false
+func sleepRandomSecondUntil(min, max int32, stop <-chan struct{}) bool {
 	var second int32
 	if min <= 0 || max <= 0 {
 		second = 1
@@ -35,7 +43,74 @@ func sleepRandomSecond(min, max int32) {
 		second = rand.Int31n(max-min) + min
 	}
 	printf("Sleep %d Second...\n", second)
-	time.Sleep(time.Duration(second) * time.Second)
+	timer := time.NewTimer(time.Duration(second) * time.Second)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-stop:
+		return false
+	}
+}
+
+// isStopped 检查 stop 是否已关闭, stop 为 nil 时永远返回 false
+func isStopped(stop <-chan struct{}) bool {
+	select {
+	case <-stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// 暂停创建实例, 暂停期间 LaunchInstances 不再发起新的创建请求
+var (
+	pauseMutex sync.Mutex
+	resumeCh   chan struct{} // 暂停时不为 nil, 恢复时关闭
+)
+
+// pauseLaunch 暂停所有账号的实例创建, 已经暂停时返回 false
+func pauseLaunch() bool {
+	pauseMutex.Lock()
+	defer pauseMutex.Unlock()
+	if resumeCh != nil {
+		return false
+	}
+	resumeCh = make(chan struct{})
+	return true
+}
+
+// resumeLaunch 恢复实例创建, 未暂停时返回 false
+func resumeLaunch() bool {
+	pauseMutex.Lock()
+	defer pauseMutex.Unlock()
+	if resumeCh == nil {
+		return false
+	}
+	close(resumeCh)
+	resumeCh = nil
+	return true
+}
+
+// isPaused 检查实例创建是否已暂停
+func isPaused() bool {
+	pauseMutex.Lock()
+	defer pauseMutex.Unlock()
+	return resumeCh != nil
+}
+
+// waitIfPaused 实例创建已暂停时等待恢复, stop 被关闭时提前返回
+func waitIfPaused(stop <-chan struct{}) {
+	pauseMutex.Lock()
+	ch := resumeCh
+	pauseMutex.Unlock()
+	if ch == nil {
+		return
+	}
+	select {
+	case <-ch:
+	case <-stop:
+	}
 }
 
 // fmtDuration 将 time.Duration 类型转换为 "X 天 X 时 X 分 X 秒" 的可读格式
@@ -65,6 +140,58 @@ func fmtDuration(d time.Duration) string {
 	return buffer.String()
 }
 
+// containsString 检查字符串切片中是否包含指定字符串
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCIDRs 解析以逗号分隔的 CIDR 列表, 不带前缀长度的 IP 视为单个地址
+func parseCIDRs(s string) ([]*net.IPNet, error) {
+	var cidrs []*net.IPNet
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if !strings.Contains(item, "/") {
+			ip := net.ParseIP(item)
+			if ip == nil {
+				return nil, fmt.Errorf("无效的IP地址: %s", item)
+			}
+			bits := 32
+			if ip.To4() == nil {
+				bits = 128
+			}
+			item = fmt.Sprintf("%s/%d", item, bits)
+		}
+		_, cidr, err := net.ParseCIDR(item)
+		if err != nil {
+			return nil, fmt.Errorf("无效的 CIDR: %s", item)
+		}
+		cidrs = append(cidrs, cidr)
+	}
+	return cidrs, nil
+}
+
+// ipInCIDRs 检查 IP 是否落在任一 CIDR 中
+func ipInCIDRs(ipStr string, cidrs []*net.IPNet) bool {
+	ip := net.ParseIP(ipStr)
+	if ip == nil {
+		return false
+	}
+	for _, cidr := range cidrs {
+		if cidr.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
 // getInstanceState 将实例的生命周期状态转换为中文描述
 func getInstanceState(state core.InstanceLifecycleStateEnum) string {
 	var friendlyState string
@@ -91,6 +218,32 @@ func getInstanceState(state core.InstanceLifecycleStateEnum) string {
 	return friendlyState
 }
 
+// getPublicIpState 将公共IP的生命周期状态转换为中文描述
+func getPublicIpState(state core.PublicIpLifecycleStateEnum) string {
+	var friendlyState string
+	switch state {
+	case core.PublicIpLifecycleStateProvisioning:
+		friendlyState = "正在预配"
+	case core.PublicIpLifecycleStateAvailable:
+		friendlyState = "未绑定　"
+	case core.PublicIpLifecycleStateAssigning:
+		friendlyState = "正在绑定"
+	case core.PublicIpLifecycleStateAssigned:
+		friendlyState = "已绑定　"
+	case core.PublicIpLifecycleStateUnassigning:
+		friendlyState = "正在解绑"
+	case core.PublicIpLifecycleStateUnassigned:
+		friendlyState = "未绑定　"
+	case core.PublicIpLifecycleStateTerminating:
+		friendlyState = "正在释放"
+	case core.PublicIpLifecycleStateTerminated:
+		friendlyState = "已释放　"
+	default:
+		friendlyState = string(state)
+	}
+	return friendlyState
+}
+
 // getBootVolumeState 将引导卷的生命周期状态转换为中文描述
 func getBootVolumeState(state core.BootVolumeLifecycleStateEnum) string {
 	var friendlyState string
@@ -113,6 +266,70 @@ func getBootVolumeState(state core.BootVolumeLifecycleStateEnum) string {
 	return friendlyState
 }
 
+// getVolumeState 将块存储卷的生命周期状态转换为中文描述
+func getVolumeState(state core.VolumeLifecycleStateEnum) string {
+	var friendlyState string
+	switch state {
+	case core.VolumeLifecycleStateProvisioning:
+		friendlyState = "正在预配"
+	case core.VolumeLifecycleStateRestoring:
+		friendlyState = "正在恢复"
+	case core.VolumeLifecycleStateAvailable:
+		friendlyState = "可用　　"
+	case core.VolumeLifecycleStateTerminating:
+		friendlyState = "正在终止"
+	case core.VolumeLifecycleStateTerminated:
+		friendlyState = "已终止　"
+	case core.VolumeLifecycleStateFaulty:
+		friendlyState = "故障　　"
+	default:
+		friendlyState = string(state)
+	}
+	return friendlyState
+}
+
+// getImageState 将镜像的生命周期状态转换为中文描述
+func getImageState(state core.ImageLifecycleStateEnum) string {
+	var friendlyState string
+	switch state {
+	case core.ImageLifecycleStateProvisioning:
+		friendlyState = "正在创建"
+	case core.ImageLifecycleStateImporting:
+		friendlyState = "正在导入"
+	case core.ImageLifecycleStateAvailable:
+		friendlyState = "可用　　"
+	case core.ImageLifecycleStateExporting:
+		friendlyState = "正在导出"
+	case core.ImageLifecycleStateDisabled:
+		friendlyState = "已禁用　"
+	case core.ImageLifecycleStateDeleted:
+		friendlyState = "已删除　"
+	default:
+		friendlyState = string(state)
+	}
+	return friendlyState
+}
+
+// getBootVolumeBackupState 将引导卷备份的生命周期状态转换为中文描述
+func getBootVolumeBackupState(state core.BootVolumeBackupLifecycleStateEnum) string {
+	var friendlyState string
+	switch state {
+	case core.BootVolumeBackupLifecycleStateCreating, core.BootVolumeBackupLifecycleStateRequestReceived:
+		friendlyState = "正在创建"
+	case core.BootVolumeBackupLifecycleStateAvailable:
+		friendlyState = "可用　　"
+	case core.BootVolumeBackupLifecycleStateTerminating:
+		friendlyState = "正在删除"
+	case core.BootVolumeBackupLifecycleStateTerminated:
+		friendlyState = "已删除　"
+	case core.BootVolumeBackupLifecycleStateFaulty:
+		friendlyState = "故障　　"
+	default:
+		friendlyState = string(state)
+	}
+	return friendlyState
+}
+
 // command 执行一个外部shell命令
 func command(cmd string) {
 	res := strings.Fields(cmd)
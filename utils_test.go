@@ -0,0 +1,61 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCIDRs(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []string
+		wantErr bool
+	}{
+		{"", nil, false},
+		{" , ", nil, false},
+		// 不带前缀长度的 IP 视为单个地址, 主机位会被清零
+		{"192.0.2.1, 10.0.0.5/24,", []string{"192.0.2.1/32", "10.0.0.0/24"}, false},
+		{"2001:db8::1,2001:db8:1::/48", []string{"2001:db8::1/128", "2001:db8:1::/48"}, false},
+		{"192.0.2.256", nil, true},
+		{"10.0.0.0/33", nil, true},
+		{"10.0.0.0/8,example.com", nil, true},
+	}
+	for _, tt := range tests {
+		cidrs, err := parseCIDRs(tt.in)
+		var got []string
+		for _, cidr := range cidrs {
+			got = append(got, cidr.String())
+		}
+		if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseCIDRs(%q) = %v, %v, 期望 %v, 出错: %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestIpInCIDRs(t *testing.T) {
+	cidrs, err := parseCIDRs("10.0.0.0/8,192.0.2.1,2001:db8::/32")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"10.1.2.3", true},
+		{"11.0.0.1", false},
+		{"192.0.2.1", true},
+		{"192.0.2.2", false},
+		{"2001:db8:ffff::1", true},
+		{"2001:db9::1", false},
+		{"", false},
+		{"not-an-ip", false},
+	}
+	for _, tt := range tests {
+		if got := ipInCIDRs(tt.ip, cidrs); got != tt.want {
+			t.Errorf("ipInCIDRs(%q) = %v, 期望 %v", tt.ip, got, tt.want)
+		}
+	}
+	if ipInCIDRs("10.1.2.3", nil) {
+		t.Error("CIDR 列表为空时不应匹配任何 IP")
+	}
+}